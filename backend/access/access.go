@@ -0,0 +1,76 @@
+// Package access memuat permission user dari role-nya dan menyiapkan role bawaan.
+// Pemeriksaan permission di route ada di middleware.RequirePermission.
+package access
+
+import (
+	"log"
+
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// defaultRoles adalah role bawaan yang dibuat saat migrasi jika belum ada. Permission role
+// yang sudah ada tidak diubah agar penyesuaian admin tidak tertimpa.
+var defaultRoles = []struct {
+    Nama        string
+    Label       string
+    Permissions []string
+}{
+    {models.RoleAdmin, "Administrator", nil},
+    {models.RoleUser, "Pengguna", nil},
+    {models.RoleOperatorKepegawaian, "Operator Kepegawaian", []string{
+        models.PermEmployeeRead, models.PermEmployeeReadSensitive, models.PermEmployeeWrite,
+    }},
+    {models.RolePengelolaJDIH, "Pengelola JDIH", []string{
+        models.PermPeraturanWrite, models.PermPeraturanReadAll, models.PermKategoriWrite, models.PermFAQWrite,
+        models.PermSuggestionManage, models.PermAnalyticsRead, models.PermAuditRead, models.PermShareLinkManage,
+    }},
+    {models.RolePejabatStruktural, "Pejabat Struktural", []string{
+        models.PermEmployeeRead,
+    }},
+    {models.RolePimpinan, "Pimpinan", []string{
+        models.PermPeraturanReadAll, models.PermAnalyticsRead, models.PermEmployeeRead,
+    }},
+}
+
+// Migrate membuat role bawaan yang belum ada
+func Migrate(db *gorm.DB) error {
+    for _, def := range defaultRoles {
+        var count int64
+        if err := db.Model(&models.Role{}).Where("nama = ?", def.Nama).Count(&count).Error; err != nil {
+            return err
+        }
+        if count > 0 {
+            continue
+        }
+        role := models.Role{Nama: def.Nama, Label: def.Label, System: true}
+        for _, p := range def.Permissions {
+            role.Permissions = append(role.Permissions, models.RolePermission{Permission: p})
+        }
+        if err := db.Create(&role).Error; err != nil {
+            return err
+        }
+        log.Printf("INFO: Created default role %s", def.Nama)
+    }
+    return nil
+}
+
+// Load mengisi user.Permissions dari role-nya. Role admin selalu mendapat semua permission,
+// termasuk permission baru yang ditambahkan kemudian.
+func Load(db *gorm.DB, user *models.User) error {
+    if user.Role == models.RoleAdmin {
+        user.Permissions = models.PermissionCodes()
+        return nil
+    }
+    permissions := []string{}
+    if err := db.Model(&models.RolePermission{}).
+        Joins("JOIN roles ON roles.id = role_permissions.role_id").
+        Where("roles.nama = ?", user.Role).
+        Order("role_permissions.permission").
+        Pluck("role_permissions.permission", &permissions).Error; err != nil {
+        return err
+    }
+    user.Permissions = permissions
+    return nil
+}
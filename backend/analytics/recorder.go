@@ -0,0 +1,93 @@
+// Package analytics mencatat event akses file peraturan (view/download) tanpa memperlambat
+// response: event dikumpulkan di channel lalu disimpan per batch oleh goroutine terpisah.
+package analytics
+
+import (
+	"log"
+	"net/url"
+	"time"
+
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+    bufferSize    = 1024
+    batchSize     = 100
+    flushInterval = 5 * time.Second
+)
+
+type Recorder struct {
+    db     *gorm.DB
+    events chan models.PeraturanAkses
+}
+
+// NewRecorder membuat recorder dan menjalankan goroutine penyimpan batch
+func NewRecorder(db *gorm.DB) *Recorder {
+    r := &Recorder{db: db, events: make(chan models.PeraturanAkses, bufferSize)}
+    go r.run()
+    return r
+}
+
+// Record mengantrekan event. Jika antrean penuh event dibuang agar request tidak tertahan.
+// Aman dipanggil pada Recorder nil (misal handler yang dibuat dari CLI).
+func (r *Recorder) Record(event models.PeraturanAkses) {
+    if r == nil {
+        return
+    }
+    if event.CreatedAt.IsZero() {
+        event.CreatedAt = time.Now()
+    }
+    event.Referrer = CleanReferrer(event.Referrer)
+
+    select {
+    case r.events <- event:
+    default:
+        log.Printf("WARNING: Analytics buffer full, dropping %s event for peraturan %d", event.Jenis, event.PeraturanID)
+    }
+}
+
+func (r *Recorder) run() {
+    ticker := time.NewTicker(flushInterval)
+    defer ticker.Stop()
+
+    batch := make([]models.PeraturanAkses, 0, batchSize)
+    flush := func() {
+        if len(batch) == 0 {
+            return
+        }
+        if err := r.db.CreateInBatches(&batch, batchSize).Error; err != nil {
+            log.Printf("WARNING: Failed to save %d analytics events: %v", len(batch), err)
+        }
+        batch = batch[:0]
+    }
+
+    for {
+        select {
+        case event := <-r.events:
+            batch = append(batch, event)
+            if len(batch) >= batchSize {
+                flush()
+            }
+        case <-ticker.C:
+            flush()
+        }
+    }
+}
+
+// CleanReferrer hanya menyimpan host dan path referrer (query string bisa berisi data pribadi)
+func CleanReferrer(referrer string) string {
+    if referrer == "" {
+        return ""
+    }
+    u, err := url.Parse(referrer)
+    if err != nil || u.Host == "" {
+        return ""
+    }
+    cleaned := u.Host + u.EscapedPath()
+    if len(cleaned) > 255 {
+        cleaned = cleaned[:255]
+    }
+    return cleaned
+}
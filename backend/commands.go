@@ -0,0 +1,200 @@
+package main
+
+import (
+	"archive/zip"
+	"backend/handlers"
+	"backend/models"
+	"backend/search"
+	"backend/storage"
+	"backend/upload"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+)
+
+// runCommand menjalankan subcommand CLI untuk tugas pemeliharaan
+func runCommand(db *gorm.DB, store storage.Storage, scanner upload.Scanner, args []string) error {
+	switch args[0] {
+	case "reindex":
+		// Backfill indeks full-text untuk file yang sudah ada
+		all := hasFlag(args[1:], "--all")
+		indexed, err := search.Reindex(db, store, all)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ %d peraturan indexed", indexed)
+		return nil
+	case "migrate-storage":
+		return migrateStorage(db, store, hasFlag(args[1:], "--delete-old"))
+	case "import":
+		// Import massal: go run . import <folder|file.zip> [--manifest file.csv] [--dry-run] [--allow-duplicates] [--report hasil.json]
+		h := &handlers.PeraturanHandler{DB: db, Storage: store, Scanner: scanner, MaxUploadSize: upload.MaxSizeFromEnv()}
+		return importPeraturan(h, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q (available: reindex, migrate-storage, import)", args[0])
+	}
+}
+
+// flagValue mengambil nilai flag berbentuk "--name value" atau "--name=value"
+func flagValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if len(arg) > len(flag) && arg[:len(flag)+1] == flag+"=" {
+			return arg[len(flag)+1:]
+		}
+	}
+	return ""
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyFilePath mencari file lama. Record dari CreatePeraturan menyimpan absolute path
+// (bisa dari server lain), sedangkan UpdatePeraturan menyimpan path relatif.
+func legacyFilePath(p models.Peraturan) (string, bool) {
+	candidates := []string{
+		p.PathFile,
+		filepath.Join("uploads", "peraturan", filepath.Base(p.PathFile)),
+	}
+	for _, candidate := range candidates {
+		if st, err := os.Stat(candidate); err == nil && !st.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// migrateStorage memindahkan file lama di uploads/peraturan ke storage content-addressed
+// dan menulis ulang PathFile menjadi key storage
+func migrateStorage(db *gorm.DB, store storage.Storage, deleteOld bool) error {
+	var peraturans []models.Peraturan
+	if err := db.Where("path_file <> '' AND path_file NOT LIKE 'sha256/%'").Order("id asc").Find(&peraturans).Error; err != nil {
+		return err
+	}
+
+	migrated, missing := 0, 0
+	for _, p := range peraturans {
+		path, ok := legacyFilePath(p)
+		if !ok {
+			log.Printf("⚠️ Peraturan %d: file not found (%s)", p.ID, p.PathFile)
+			missing++
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		obj, err := storage.PutContent(context.Background(), store, f, mime.TypeByExtension(filepath.Ext(p.NamaFile)))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("peraturan %d: %w", p.ID, err)
+		}
+
+		if err := db.Model(&models.Peraturan{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+			"path_file": obj.Key,
+			"file_hash": obj.Hash,
+			"file_size": obj.Size,
+		}).Error; err != nil {
+			return err
+		}
+
+		if deleteOld {
+			if err := os.Remove(path); err != nil {
+				log.Printf("⚠️ Failed to delete old file %s: %v", path, err)
+			}
+		}
+		migrated++
+	}
+
+	log.Printf("✅ %d peraturan migrated to storage, %d files missing", migrated, missing)
+	return nil
+}
+
+// importPeraturan menjalankan import massal dari folder atau ZIP lalu mencetak laporan per baris
+func importPeraturan(h *handlers.PeraturanHandler, args []string) error {
+	if len(args) == 0 || args[0] == "" || args[0][0] == '-' {
+		return fmt.Errorf("usage: import <folder|file.zip> [--manifest file.csv|file.xlsx] [--dry-run] [--allow-duplicates] [--report hasil.json]")
+	}
+	source := args[0]
+	dryRun := hasFlag(args[1:], "--dry-run")
+	allowDuplicates := hasFlag(args[1:], "--allow-duplicates")
+
+	var fsys fs.FS
+	st, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if st.IsDir() {
+		fsys = os.DirFS(source)
+	} else {
+		zr, err := zip.OpenReader(source)
+		if err != nil {
+			return fmt.Errorf("%s is not a folder or ZIP file: %w", source, err)
+		}
+		defer zr.Close()
+		fsys = zr
+	}
+
+	var manifest *handlers.ImportManifest
+	if name := flagValue(args[1:], "--manifest"); name != "" {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		manifest, err = handlers.LoadImportManifest(name, bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := h.RunImport(context.Background(), fsys, manifest, handlers.ImportOptions{DryRun: dryRun, AllowDuplicates: allowDuplicates})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		line := fmt.Sprintf("row %d [%s] %s %s", row.Row, row.Status, row.JenisPeraturan, row.Nomor)
+		for _, msg := range row.Errors {
+			line += "\n    - " + msg
+		}
+		log.Println(line)
+	}
+	if jsonPath := flagValue(args[1:], "--report"); jsonPath != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		log.Printf("✅ Dry run: %d rows, %d valid, %d skipped, %d errors (nothing saved)", report.Total, report.Valid, report.Skipped, report.Failed)
+		return nil
+	}
+	log.Printf("✅ Import: %d rows, %d imported, %d skipped, %d errors", report.Total, report.Imported, report.Skipped, report.Failed)
+
+	if report.Imported > 0 {
+		indexed, err := search.Reindex(h.DB, h.Storage, false)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ %d peraturan indexed", indexed)
+	}
+	return nil
+}
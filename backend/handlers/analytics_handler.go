@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AnalyticsHandler struct {
+    DB *gorm.DB
+}
+
+// TopDokumen adalah satu baris peringkat dokumen paling banyak diakses
+type TopDokumen struct {
+    PeraturanID    uint   `json:"peraturan_id"`
+    Nomor          string `json:"nomor"`
+    Judul          string `json:"judul"`
+    JenisPeraturan string `json:"jenis_peraturan"`
+    Views          int64  `json:"views"`
+    Downloads      int64  `json:"downloads"`
+    Total          int64  `json:"total"`
+    UniqueUsers    int64  `json:"unique_users"`
+}
+
+// TrendPoint adalah jumlah akses dalam satu periode
+type TrendPoint struct {
+    Periode time.Time `json:"periode"`
+    Jumlah  int64     `json:"jumlah"`
+}
+
+// KategoriTrend adalah deret waktu akses untuk satu kategori
+type KategoriTrend struct {
+    KategoriID uint         `json:"kategori_id"`
+    Nama       string       `json:"nama"`
+    Total      int64        `json:"total"`
+    Points     []TrendPoint `json:"points"`
+}
+
+var trendIntervals = map[string]bool{"day": true, "week": true, "month": true}
+
+// recordAkses mencatat event view/download. Range request lanjutan dari PDF viewer
+// (bukan dari byte 0) tidak dihitung agar satu kali buka tidak tercatat berkali-kali.
+// View oleh user yang login juga masuk ke riwayat baca.
+func (h *PeraturanHandler) recordAkses(c *gin.Context, peraturan *models.Peraturan, jenis string) {
+    if isRangeContinuation(c) {
+        return
+    }
+    userID := currentUserID(c)
+    h.Analytics.Record(models.PeraturanAkses{
+        PeraturanID: peraturan.ID,
+        UserID:      userID,
+        Jenis:       jenis,
+        Referrer:    c.Request.Referer(),
+    })
+    if jenis == models.AksesView && userID != nil {
+        recordRiwayatBaca(h.DB, *userID, peraturan.ID)
+    }
+}
+
+// isRangeContinuation menandai range request lanjutan dari PDF viewer (bukan dari byte 0),
+// yang merupakan bagian dari satu kali buka/unduh yang sama
+func isRangeContinuation(c *gin.Context) bool {
+    r := c.GetHeader("Range")
+    return r != "" && !strings.HasPrefix(r, "bytes=0-")
+}
+
+// analyticsPeriod membaca ?from=YYYY-MM-DD&to=YYYY-MM-DD (to inklusif) atau ?days=N (default 30)
+func analyticsPeriod(c *gin.Context) (time.Time, time.Time, error) {
+    now := time.Now()
+    to := now
+    if s := c.Query("to"); s != "" {
+        t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+        if err != nil {
+            return time.Time{}, time.Time{}, fmt.Errorf("invalid to date, use YYYY-MM-DD")
+        }
+        to = t.AddDate(0, 0, 1)
+    }
+    if s := c.Query("from"); s != "" {
+        from, err := time.ParseInLocation("2006-01-02", s, time.Local)
+        if err != nil {
+            return time.Time{}, time.Time{}, fmt.Errorf("invalid from date, use YYYY-MM-DD")
+        }
+        return from, to, nil
+    }
+
+    days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+    if err != nil || days < 1 {
+        days = 30
+    }
+    return to.AddDate(0, 0, -days), to, nil
+}
+
+// GetAnalyticsSummary - Ringkasan penggunaan untuk dashboard
+func (h *AnalyticsHandler) GetAnalyticsSummary(c *gin.Context) {
+    from, to, err := analyticsPeriod(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var totals struct {
+        Views       int64 `json:"views"`
+        Downloads   int64 `json:"downloads"`
+        UniqueUsers int64 `json:"unique_users"`
+        Documents   int64 `json:"documents_opened"`
+    }
+    if err := h.DB.Model(&models.PeraturanAkses{}).
+        Select(`COUNT(*) FILTER (WHERE jenis = ?) AS views,
+            COUNT(*) FILTER (WHERE jenis = ?) AS downloads,
+            COUNT(DISTINCT user_id) AS unique_users,
+            COUNT(DISTINCT peraturan_id) AS documents`, models.AksesView, models.AksesDownload).
+        Where("created_at >= ? AND created_at < ?", from, to).
+        Scan(&totals).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics"})
+        return
+    }
+
+    var totalPeraturan, neverOpened int64
+    h.DB.Model(&models.Peraturan{}).Count(&totalPeraturan)
+    h.DB.Model(&models.Peraturan{}).Where(neverOpenedCondition).Count(&neverOpened)
+
+    var daily []struct {
+        Tanggal   time.Time `json:"tanggal"`
+        Views     int64     `json:"views"`
+        Downloads int64     `json:"downloads"`
+    }
+    if err := h.DB.Model(&models.PeraturanAkses{}).
+        Select(`date_trunc('day', created_at) AS tanggal,
+            COUNT(*) FILTER (WHERE jenis = ?) AS views,
+            COUNT(*) FILTER (WHERE jenis = ?) AS downloads`, models.AksesView, models.AksesDownload).
+        Where("created_at >= ? AND created_at < ?", from, to).
+        Group("tanggal").Order("tanggal asc").
+        Scan(&daily).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analytics"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "from":            from,
+        "to":              to,
+        "totals":          totals,
+        "total_peraturan": totalPeraturan,
+        "never_opened":    neverOpened,
+        "daily":           daily,
+    })
+}
+
+// GetTopDokumen - Dokumen paling banyak dibuka/diunduh dalam periode (?jenis=view|download&limit=10)
+func (h *AnalyticsHandler) GetTopDokumen(c *gin.Context) {
+    from, to, err := analyticsPeriod(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit < 1 || limit > 100 {
+        limit = 10
+    }
+
+    orderBy := "total DESC"
+    switch c.Query("jenis") {
+    case models.AksesView:
+        orderBy = "views DESC"
+    case models.AksesDownload:
+        orderBy = "downloads DESC"
+    }
+
+    var top []TopDokumen
+    if err := h.DB.Table("peraturan_akses a").
+        Select(`a.peraturan_id, p.nomor, p.judul, p.jenis_peraturan,
+            COUNT(*) FILTER (WHERE a.jenis = ?) AS views,
+            COUNT(*) FILTER (WHERE a.jenis = ?) AS downloads,
+            COUNT(*) AS total,
+            COUNT(DISTINCT a.user_id) AS unique_users`, models.AksesView, models.AksesDownload).
+        Joins("JOIN peraturans p ON p.id = a.peraturan_id").
+        Where("a.created_at >= ? AND a.created_at < ?", from, to).
+        Group("a.peraturan_id, p.nomor, p.judul, p.jenis_peraturan").
+        Order(orderBy).Order("a.peraturan_id").
+        Limit(limit).
+        Scan(&top).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top documents"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "data": top})
+}
+
+// GetKategoriTrends - Jumlah akses per kategori per periode (?interval=day|week|month)
+func (h *AnalyticsHandler) GetKategoriTrends(c *gin.Context) {
+    from, to, err := analyticsPeriod(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    interval := c.DefaultQuery("interval", "week")
+    if !trendIntervals[interval] {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day, week or month"})
+        return
+    }
+
+    var rows []struct {
+        KategoriID uint
+        Nama       string
+        Periode    time.Time
+        Jumlah     int64
+    }
+    // interval sudah divalidasi terhadap whitelist sehingga aman disisipkan ke SQL
+    periode := fmt.Sprintf("date_trunc('%s', a.created_at)", interval)
+    if err := h.DB.Table("peraturan_akses a").
+        Select("k.id AS kategori_id, k.nama, "+periode+" AS periode, COUNT(*) AS jumlah").
+        Joins("JOIN peraturan_kategori pk ON pk.peraturan_id = a.peraturan_id").
+        Joins("JOIN kategori k ON k.id = pk.kategori_id").
+        Where("a.created_at >= ? AND a.created_at < ?", from, to).
+        Group("k.id, k.nama, " + periode).
+        Order("periode asc").
+        Scan(&rows).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trends"})
+        return
+    }
+
+    trends := []*KategoriTrend{}
+    byID := map[uint]*KategoriTrend{}
+    for _, row := range rows {
+        trend, ok := byID[row.KategoriID]
+        if !ok {
+            trend = &KategoriTrend{KategoriID: row.KategoriID, Nama: row.Nama}
+            byID[row.KategoriID] = trend
+            trends = append(trends, trend)
+        }
+        trend.Points = append(trend.Points, TrendPoint{Periode: row.Periode, Jumlah: row.Jumlah})
+        trend.Total += row.Jumlah
+    }
+
+    c.JSON(http.StatusOK, gin.H{"from": from, "to": to, "interval": interval, "data": trends})
+}
+
+// neverOpenedCondition memilih peraturan yang belum pernah dibuka maupun diunduh
+const neverOpenedCondition = "NOT EXISTS (SELECT 1 FROM peraturan_akses a WHERE a.peraturan_id = peraturans.id)"
+
+// GetNeverOpened - Daftar peraturan yang belum pernah dibuka (mendukung page/limit/sort)
+func (h *AnalyticsHandler) GetNeverOpened(c *gin.Context) {
+    query, pagination, err := paginate(c, h.DB.Model(&models.Peraturan{}).Where(neverOpenedCondition))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count peraturans"})
+        return
+    }
+
+    var peraturans []models.Peraturan
+    if err := query.Preload("KategoriItems").Find(&peraturans).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"data": peraturans, "pagination": pagination})
+}
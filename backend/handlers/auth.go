@@ -2,9 +2,12 @@
 package handlers
 
 import (
+	"backend/access"
+	"backend/mailer"
 	"backend/models"
+	"backend/session"
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -12,7 +15,9 @@ import (
 )
 
 type AuthHandler struct {
-    DB *gorm.DB
+    DB     *gorm.DB
+    Mailer mailer.Sender // Pengirim email reset password
+    AppURL string        // Alamat frontend untuk tautan reset password
 }
 
 type RegisterRequest struct {
@@ -20,6 +25,9 @@ type RegisterRequest struct {
     Email    string `json:"email" binding:"required,email"`
     Password string `json:"password" binding:"required,min=6"`
     FullName string `json:"full_name" binding:"required"`
+    // Opsional: klaim data pegawai, diverifikasi admin bersama persetujuan pendaftaran
+    NIP      string `json:"nip"`
+    TglLahir string `json:"tgl_lahir"`
 }
 
 type LoginRequest struct {
@@ -41,6 +49,25 @@ func (h *AuthHandler) Register(c *gin.Context) {
         return
     }
 
+    // NIP dicocokkan dengan tanggal lahir sebelum akun dibuat
+    var employeeID *uint
+    if req.NIP != "" {
+        if req.TglLahir == "" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "tgl_lahir is required when nip is provided"})
+            return
+        }
+        employee, err := findEmployeeForUser(h.DB, req.NIP, req.TglLahir, 0)
+        if errors.Is(err, errEmployeeTaken) {
+            // Endpoint publik: NIP yang sudah dipakai dijawab sama seperti NIP tidak ditemukan
+            err = errEmployeeNotFound
+        }
+        if err != nil {
+            employeeError(c, err)
+            return
+        }
+        employeeID = &employee.ID
+    }
+
     // Hash password
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
     if err != nil {
@@ -50,11 +77,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
     // Create user
     user := models.User{
-        Username: req.Username,
-        Password: string(hashedPassword),
-        Email:    req.Email,
-        FullName: req.FullName,
-        Role:     "user",
+        Username:   req.Username,
+        Password:   string(hashedPassword),
+        Email:      req.Email,
+        FullName:   req.FullName,
+        Role:       "user",
+        Status:     models.UserPending,
+        EmployeeID: employeeID,
     }
 
     if err := h.DB.Create(&user).Error; err != nil {
@@ -62,7 +91,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
         return
     }
 
-    c.JSON(http.StatusCreated, gin.H{"message": "User created successfully"})
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Registration submitted, waiting for admin approval",
+        "status":  user.Status,
+    })
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -74,7 +106,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
     // Find user by username or email
     var user models.User
-    if err := h.DB.Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
+    if err := h.DB.Preload("Employee").Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
         return
     }
@@ -85,57 +117,60 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
 
-    // Generate token
-    token := generateToken()
-
-    // Set expiration time (24 hours)
-    expiresAt := time.Now().Add(24 * time.Hour)
+    // Hanya akun aktif yang boleh login; dicek setelah password agar status akun tidak bocor
+    if msg := loginStatusError(user); msg != "" {
+        c.JSON(http.StatusForbidden, gin.H{"error": msg, "status": user.Status, "alasan": user.StatusAlasan})
+        return
+    }
 
-    // Create session
-    session := models.Session{
-        UserID:    user.ID,
-        Token:     token,
-        ExpiresAt: expiresAt,
-        CreatedAt: time.Now(),
+    // Unit mengikuti bidang terbaru dari data pegawai
+    if err := syncEmployeeUnit(h.DB, &user); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync employee unit"})
+        return
     }
 
-    if err := h.DB.Create(&session).Error; err != nil {
+    // Session lama di browser ini dicabut agar token yang mungkin sudah diketahui pihak lain
+    // tidak ikut terbawa ke login baru
+    if err := session.Revoke(c, h.DB); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+        return
+    }
+    if err := session.Create(c, h.DB, user.ID); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
         return
     }
 
-    // Set token in cookie
-    c.SetCookie("token", token, int(time.Until(expiresAt).Seconds()), "/", "", false, true)
+    if err := access.Load(h.DB, &user); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load permissions"})
+        return
+    }
 
     c.JSON(http.StatusOK, gin.H{
         "message": "Login successful",
         "user": gin.H{
-            "id":       user.ID,
-            "username": user.Username,
-            "email":    user.Email,
-            "fullName": user.FullName,
-            "role":     user.Role,
+            "id":          user.ID,
+            "username":    user.Username,
+            "email":       user.Email,
+            "fullName":    user.FullName,
+            "role":        user.Role,
+            "permissions": user.Permissions,
         },
     })
 }
 
 func (h *AuthHandler) Logout(c *gin.Context) {
     // Get token from cookie
-    token, err := c.Cookie("token")
-    if err != nil {
+    if _, err := c.Cookie(session.CookieName); err != nil {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "No token found"})
         return
     }
 
-    // Delete session from database
-    if err := h.DB.Where("token = ?", token).Delete(&models.Session{}).Error; err != nil {
+    // Delete session from database and clear cookie
+    if err := session.Revoke(c, h.DB); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
         return
     }
 
-    // Clear cookie
-    c.SetCookie("token", "", -1, "/", "", false, true)
-
     c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
 }
 
@@ -149,15 +184,17 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 
     userModel := user.(models.User)
 
-    c.JSON(http.StatusOK, gin.H{
-        "id":       userModel.ID,
-        "username": userModel.Username,
-        "email":    userModel.Email,
-        "fullName": userModel.FullName,
-        "role":     userModel.Role,
-    })
+    response := gin.H{
+        "id":          userModel.ID,
+        "username":    userModel.Username,
+        "email":       userModel.Email,
+        "fullName":    userModel.FullName,
+        "role":        userModel.Role,
+        "unit":        userModel.Unit,
+        "permissions": userModel.Permissions,
+    }
+    for key, value := range employeeProfile(c, h.DB, userModel) {
+        response[key] = value
+    }
+    c.JSON(http.StatusOK, response)
 }
-
-func generateToken() string {
-    return "generated-token-" + time.Now().Format("20060102150405")
-}
\ No newline at end of file
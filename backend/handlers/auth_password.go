@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend/mailer"
+	"backend/models"
+	"backend/session"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+    passwordResetTTL      = time.Hour   // Masa berlaku token reset password
+    passwordResetInterval = time.Minute // Jeda minimal antar permintaan reset untuk satu user
+)
+
+var errResetTokenInvalid = errors.New("reset token is invalid, expired or already used")
+
+type ChangePasswordRequest struct {
+    CurrentPassword string `json:"current_password" binding:"required"`
+    NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+    Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+    Token       string `json:"token" binding:"required"`
+    NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ChangePassword - Mengganti password user yang login; password lama wajib benar. Session lain
+// dicabut dan session ini diganti token baru.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var req ChangePasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+        return
+    }
+    if req.NewPassword == req.CurrentPassword {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "New password must be different from the current password"})
+        return
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+        return
+    }
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+            return err
+        }
+        // Token reset yang belum dipakai tidak boleh lagi mengganti password baru ini
+        return tx.Model(&models.PasswordReset{}).
+            Where("user_id = ? AND used_at IS NULL", user.ID).
+            Update("used_at", time.Now()).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+        return
+    }
+    if err := session.Rotate(c, h.DB, user.ID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed but failed to renew session"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// ForgotPassword - Mengirim tautan reset password ke email user. Response selalu sama agar
+// keberadaan email tidak bisa ditebak; email dikirim di background.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+    var req ForgotPasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    response := gin.H{"message": "If the email is registered, a password reset link has been sent"}
+
+    var user models.User
+    if err := h.DB.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(req.Email))).First(&user).Error; err != nil ||
+        user.Status == models.UserRejected || user.Status == models.UserSuspended {
+        c.JSON(http.StatusOK, response)
+        return
+    }
+
+    now := time.Now()
+    var recent int64
+    h.DB.Model(&models.PasswordReset{}).
+        Where("user_id = ? AND created_at > ?", user.ID, now.Add(-passwordResetInterval)).
+        Count(&recent)
+    if recent > 0 {
+        c.JSON(http.StatusOK, response)
+        return
+    }
+
+    token, err := session.NewToken()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+        return
+    }
+    reset := models.PasswordReset{
+        UserID:    user.ID,
+        TokenHash: session.HashToken(token),
+        ExpiresAt: now.Add(passwordResetTTL),
+        IPAddress: c.ClientIP(),
+        CreatedAt: now,
+    }
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        // Hanya token terbaru yang berlaku
+        if err := tx.Model(&models.PasswordReset{}).
+            Where("user_id = ? AND used_at IS NULL", user.ID).
+            Update("used_at", now).Error; err != nil {
+            return err
+        }
+        return tx.Create(&reset).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reset token"})
+        return
+    }
+
+    // Email dikirim langsung, bukan lewat outbox, agar token tidak tersimpan di database
+    msg := mailer.Message{
+        To:      []string{user.Email},
+        Subject: "Reset password akun Anda",
+        Body: fmt.Sprintf("Halo %s,\n\nKami menerima permintaan reset password untuk akun %s.\n"+
+            "Buka tautan berikut dalam %d menit untuk membuat password baru:\n%s/reset-password?token=%s\n\n"+
+            "Tautan hanya bisa dipakai sekali. Abaikan email ini jika Anda tidak meminta reset password.",
+            displayUserName(user), user.Username, int(passwordResetTTL.Minutes()), strings.TrimRight(h.AppURL, "/"), url.QueryEscape(token)),
+    }
+    if h.Mailer != nil {
+        go func() {
+            ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+            defer cancel()
+            if err := h.Mailer.Send(ctx, msg); err != nil {
+                log.Printf("WARNING: Failed to send password reset email to user %d: %v", user.ID, err)
+            }
+        }()
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword - Mengganti password dengan token dari email. Token hanya bisa dipakai sekali
+// dan semua session user dicabut sehingga perlu login ulang.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+    var req ResetPasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        userID, err := consumeResetToken(tx, req.Token, time.Now())
+        if err != nil {
+            return err
+        }
+        if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("password", string(hashedPassword)).Error; err != nil {
+            return err
+        }
+        return session.RevokeUser(tx, userID, 0)
+    })
+    switch {
+    case errors.Is(err, errResetTokenInvalid):
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Reset token is invalid, expired or already used"})
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in with the new password"})
+}
+
+// consumeResetToken menandai token reset terpakai dan mengembalikan id user pemiliknya. Token yang
+// tidak dikenal, sudah dipakai, atau kedaluwarsa menghasilkan errResetTokenInvalid.
+func consumeResetToken(tx *gorm.DB, token string, now time.Time) (int64, error) {
+    tokenHash := session.HashToken(strings.TrimSpace(token))
+    // Token ditandai terpakai di UPDATE yang sama agar tidak bisa dipakai dua kali bersamaan
+    result := tx.Model(&models.PasswordReset{}).
+        Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+        Update("used_at", now)
+    if result.Error != nil {
+        return 0, result.Error
+    }
+    if result.RowsAffected == 0 {
+        return 0, errResetTokenInvalid
+    }
+
+    var reset models.PasswordReset
+    if err := tx.Where("token_hash = ?", tokenHash).First(&reset).Error; err != nil {
+        return 0, err
+    }
+    return reset.UserID, nil
+}
+
+// displayUserName adalah nama lengkap user, atau username jika kosong
+func displayUserName(user models.User) string {
+    if user.FullName != "" {
+        return user.FullName
+    }
+    return user.Username
+}
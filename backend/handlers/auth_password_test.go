@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/dbtest"
+	"backend/models"
+	"backend/session"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func postJSON(handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+    gin.SetMode(gin.TestMode)
+    w := httptest.NewRecorder()
+    c, _ := gin.CreateTestContext(w)
+    c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+    c.Request.Header.Set("Content-Type", "application/json")
+    handler(c)
+    return w
+}
+
+// createReset menyimpan token reset untuk user dengan masa berlaku dan status pakai tertentu
+func createReset(t *testing.T, db *gorm.DB, userID int64, expiresIn time.Duration, used bool) string {
+    t.Helper()
+    token, err := session.NewToken()
+    if err != nil {
+        t.Fatal(err)
+    }
+    now := time.Now()
+    reset := models.PasswordReset{
+        UserID:    userID,
+        TokenHash: session.HashToken(token),
+        ExpiresAt: now.Add(expiresIn),
+        CreatedAt: now,
+    }
+    if used {
+        reset.UsedAt = &now
+    }
+    if err := db.Create(&reset).Error; err != nil {
+        t.Fatal(err)
+    }
+    return token
+}
+
+func TestConsumeResetToken(t *testing.T) {
+    db := dbtest.Open(t)
+    user := dbtest.CreateUser(t, db, "reset-consume")
+    valid := createReset(t, db, user.ID, time.Hour, false)
+
+    // Token hanya bisa dipakai sekali
+    userID, err := consumeResetToken(db, " "+valid+" ", time.Now())
+    if err != nil || userID != user.ID {
+        t.Fatalf("first use = %d, %v; want user %d", userID, err, user.ID)
+    }
+    var reset models.PasswordReset
+    if err := db.Where("token_hash = ?", session.HashToken(valid)).First(&reset).Error; err != nil || reset.UsedAt == nil {
+        t.Fatalf("token not marked used: %+v, %v", reset, err)
+    }
+    if _, err := consumeResetToken(db, valid, time.Now()); !errors.Is(err, errResetTokenInvalid) {
+        t.Fatalf("second use: err = %v, want errResetTokenInvalid", err)
+    }
+
+    cases := map[string]string{
+        "unknown": "not-a-token",
+        "expired": createReset(t, db, user.ID, -time.Second, false),
+        "used":    createReset(t, db, user.ID, time.Hour, true),
+    }
+    for name, token := range cases {
+        t.Run(name, func(t *testing.T) {
+            if _, err := consumeResetToken(db, token, time.Now()); !errors.Is(err, errResetTokenInvalid) {
+                t.Fatalf("err = %v, want errResetTokenInvalid", err)
+            }
+        })
+    }
+}
+
+func TestResetPassword(t *testing.T) {
+    db := dbtest.Open(t)
+    user := dbtest.CreateUser(t, db, "reset-password")
+    token := createReset(t, db, user.ID, time.Hour, false)
+    if err := db.Create(&models.Session{UserID: user.ID, TokenHash: session.HashToken("old-session"), ExpiresAt: time.Now().Add(time.Hour)}).Error; err != nil {
+        t.Fatal(err)
+    }
+    h := &AuthHandler{DB: db}
+    body := `{"token":"` + token + `","new_password":"rahasia-baru"}`
+
+    if w := postJSON(h.ResetPassword, body); w.Code != http.StatusOK {
+        t.Fatalf("status = %d: %s", w.Code, w.Body)
+    }
+    var updated models.User
+    if err := db.First(&updated, user.ID).Error; err != nil {
+        t.Fatal(err)
+    }
+    if bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("rahasia-baru")) != nil {
+        t.Fatal("password not changed")
+    }
+    var sessions int64
+    db.Model(&models.Session{}).Where("user_id = ?", user.ID).Count(&sessions)
+    if sessions != 0 {
+        t.Fatalf("%d sessions left after reset", sessions)
+    }
+
+    // Token yang sama tidak bisa dipakai ulang
+    if w := postJSON(h.ResetPassword, body); w.Code != http.StatusBadRequest {
+        t.Fatalf("reuse: status = %d, want 400: %s", w.Code, w.Body)
+    }
+}
+
+func TestResetPasswordValidation(t *testing.T) {
+    h := &AuthHandler{}
+    cases := map[string]string{
+        "missing token":  `{"new_password":"rahasia123"}`,
+        "short password": `{"token":"abc","new_password":"123"}`,
+        "not json":       `token=abc`,
+    }
+    for name, body := range cases {
+        t.Run(name, func(t *testing.T) {
+            if w := postJSON(h.ResetPassword, body); w.Code != http.StatusBadRequest {
+                t.Fatalf("status = %d, want 400: %s", w.Code, w.Body)
+            }
+        })
+    }
+}
+
+func TestForgotPasswordValidation(t *testing.T) {
+    h := &AuthHandler{}
+    for _, body := range []string{`{}`, `{"email":"bukan-email"}`, `email=a@example.com`} {
+        if w := postJSON(h.ForgotPassword, body); w.Code != http.StatusBadRequest {
+            t.Fatalf("%s: status = %d, want 400", body, w.Code)
+        }
+    }
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRiwayatBaca adalah jumlah riwayat yang disimpan per user
+const maxRiwayatBaca = 50
+
+type BookmarkHandler struct {
+    DB *gorm.DB
+}
+
+type BookmarkRequest struct {
+    Catatan string `json:"catatan"`
+}
+
+// recordRiwayatBaca mencatat peraturan yang baru dibuka user dan memangkas riwayat lama.
+// Dipanggil dari endpoint file view, dijalankan di background agar tidak menahan response.
+func recordRiwayatBaca(db *gorm.DB, userID int64, peraturanID uint) {
+    go func() {
+        riwayat := models.RiwayatBaca{UserID: userID, PeraturanID: peraturanID, DibukaAt: time.Now()}
+        err := db.Clauses(clause.OnConflict{
+            Columns:   []clause.Column{{Name: "user_id"}, {Name: "peraturan_id"}},
+            DoUpdates: clause.AssignmentColumns([]string{"dibuka_at"}),
+        }).Create(&riwayat).Error
+        if err == nil {
+            err = db.Where("user_id = ? AND peraturan_id NOT IN (?)", userID,
+                db.Model(&models.RiwayatBaca{}).Select("peraturan_id").Where("user_id = ?", userID).Order("dibuka_at desc").Limit(maxRiwayatBaca),
+            ).Delete(&models.RiwayatBaca{}).Error
+        }
+        if err != nil {
+            log.Printf("WARNING: Failed to record reading history for user %d: %v", userID, err)
+        }
+    }()
+}
+
+// GetBookmarks - Daftar bookmark milik user yang login
+func (h *BookmarkHandler) GetBookmarks(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var bookmarks []models.Bookmark
+    if err := h.DB.Preload("Peraturan.KategoriItems").Where("user_id = ? AND peraturan_id IN (?)", user.ID, visibleIDs(h.DB, &user)).
+        Order("created_at desc").Find(&bookmarks).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": bookmarks})
+}
+
+// AddBookmark - Menandai peraturan (idempoten; memanggil ulang memperbarui catatan)
+func (h *BookmarkHandler) AddBookmark(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var peraturan models.Peraturan
+    if err := h.DB.Preload("KategoriItems").First(&peraturan, c.Param("peraturanId")).Error; err != nil || !canView(h.DB, &peraturan, &user) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return
+    }
+
+    var req BookmarkRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    bookmark := models.Bookmark{UserID: user.ID, PeraturanID: peraturan.ID, Catatan: req.Catatan, CreatedAt: time.Now()}
+    if err := h.DB.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "user_id"}, {Name: "peraturan_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"catatan"}),
+    }).Create(&bookmark).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bookmark"})
+        return
+    }
+
+    h.DB.Where("user_id = ? AND peraturan_id = ?", user.ID, peraturan.ID).First(&bookmark)
+    bookmark.Peraturan = &peraturan
+    c.JSON(http.StatusOK, gin.H{"message": "Bookmark saved", "data": bookmark})
+}
+
+// RemoveBookmark - Menghapus tanda bookmark
+func (h *BookmarkHandler) RemoveBookmark(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    result := h.DB.Where("user_id = ? AND peraturan_id = ?", user.ID, c.Param("peraturanId")).Delete(&models.Bookmark{})
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bookmark"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// GetRecentlyViewed - Peraturan yang terakhir dibuka user (?limit=10)
+func (h *BookmarkHandler) GetRecentlyViewed(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit < 1 || limit > maxRiwayatBaca {
+        limit = 10
+    }
+
+    var riwayat []models.RiwayatBaca
+    if err := h.DB.Preload("Peraturan.KategoriItems").Where("user_id = ? AND peraturan_id IN (?)", user.ID, visibleIDs(h.DB, &user)).
+        Order("dibuka_at desc").Limit(limit).Find(&riwayat).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading history"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": riwayat})
+}
+
+// ClearRecentlyViewed - Menghapus seluruh riwayat baca user
+func (h *BookmarkHandler) ClearRecentlyViewed(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    if err := h.DB.Where("user_id = ?", user.ID).Delete(&models.RiwayatBaca{}).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear reading history"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Reading history cleared"})
+}
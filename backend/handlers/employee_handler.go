@@ -3,6 +3,7 @@ package handlers
 import (
 	"backend/models"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -12,6 +13,24 @@ type EmployeeHandler struct {
     DB *gorm.DB
 }
 
+// redactEmployee mengosongkan data pribadi pegawai (dan atasannya) jika viewer tidak memiliki
+// permission employee.read_sensitive
+func redactEmployee(c *gin.Context, employee *models.Employee) {
+    if employee == nil || viewerFromContext(c).Can(models.PermEmployeeReadSensitive) {
+        return
+    }
+    employee.TglLahir = time.Time{}
+    employee.Agama = ""
+    redactEmployee(c, employee.AtasanLangsung)
+    redactEmployees(c, employee.Bawahan)
+}
+
+func redactEmployees(c *gin.Context, employees []models.Employee) {
+    for i := range employees {
+        redactEmployee(c, &employees[i])
+    }
+}
+
 // GetEmployees mengambil semua data pegawai
 func (h *EmployeeHandler) GetEmployees(c *gin.Context) {
     var employees []models.Employee
@@ -23,6 +42,7 @@ func (h *EmployeeHandler) GetEmployees(c *gin.Context) {
         return
     }
     
+    redactEmployees(c, employees)
     c.JSON(http.StatusOK, employees)
 }
 
@@ -55,6 +75,7 @@ func (h *EmployeeHandler) UpdatePLTPosition(c *gin.Context) {
         return
     }
     
+    redactEmployee(c, &employee)
     c.JSON(http.StatusOK, employee)
 }
 
@@ -95,5 +116,6 @@ func (h *EmployeeHandler) GetEmployeesKepegawaian(c *gin.Context) {
         return
     }
     
+    redactEmployees(c, employees)
     c.JSON(http.StatusOK, employees)
 }
\ No newline at end of file
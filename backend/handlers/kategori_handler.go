@@ -0,0 +1,536 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type KategoriHandler struct {
+    DB *gorm.DB
+}
+
+type KategoriRequest struct {
+    Nama     string `json:"nama" binding:"required"`
+    ParentID *uint  `json:"parent_id"`
+}
+
+type MergeKategoriRequest struct {
+    TargetID uint `json:"target_id" binding:"required"`
+}
+
+// kategoriPair adalah satu baris join table peraturan_kategori
+type kategoriPair struct {
+    PeraturanID uint
+    KategoriID  uint
+}
+
+// parseKategoriNames membaca nilai kategori lama: JSON array atau string tunggal
+func parseKategoriNames(raw string) ([]string, error) {
+    raw = strings.TrimSpace(raw)
+    if raw == "" {
+        return nil, nil
+    }
+    if strings.HasPrefix(raw, "[") {
+        var names []string
+        if err := json.Unmarshal([]byte(raw), &names); err != nil {
+            return nil, err
+        }
+        return names, nil
+    }
+    return []string{raw}, nil
+}
+
+// resolveKategori mencari kategori berdasarkan nama (case-insensitive); yang belum ada dibuat di level teratas
+func resolveKategori(tx *gorm.DB, names []string) ([]models.Kategori, error) {
+    seen := map[string]bool{}
+    kategoris := []models.Kategori{}
+
+    for _, name := range names {
+        name = strings.TrimSpace(name)
+        key := strings.ToLower(name)
+        if name == "" || seen[key] {
+            continue
+        }
+        seen[key] = true
+
+        var kategori models.Kategori
+        err := tx.Where("LOWER(nama) = ?", key).Order("parent_id IS NOT NULL, id").First(&kategori).Error
+        if err == gorm.ErrRecordNotFound {
+            kategori = models.Kategori{Nama: name}
+            err = tx.Create(&kategori).Error
+        }
+        if err != nil {
+            return nil, err
+        }
+        kategoris = append(kategoris, kategori)
+    }
+    return kategoris, nil
+}
+
+// kategoriByPeraturan memuat kategori semua peraturan sekaligus, untuk pembacaan streaming
+// (ScanRows) yang tidak bisa memakai Preload
+func kategoriByPeraturan(db *gorm.DB) (map[uint][]models.Kategori, error) {
+    var rows []struct {
+        PeraturanID uint
+        models.Kategori
+    }
+    if err := db.Table("peraturan_kategori pk").
+        Select("pk.peraturan_id, kategori.*").
+        Joins("JOIN kategori ON kategori.id = pk.kategori_id").
+        Scan(&rows).Error; err != nil {
+        return nil, err
+    }
+    result := map[uint][]models.Kategori{}
+    for _, row := range rows {
+        result[row.PeraturanID] = append(result[row.PeraturanID], row.Kategori)
+    }
+    return result, nil
+}
+
+// taggedPeraturanIDs mengembalikan id peraturan yang ditandai dengan kategori tertentu
+func taggedPeraturanIDs(tx *gorm.DB, kategoriIDs ...uint) ([]uint, error) {
+    var ids []uint
+    err := tx.Table("peraturan_kategori").Distinct("peraturan_id").Where("kategori_id IN ?", kategoriIDs).Pluck("peraturan_id", &ids).Error
+    return ids, err
+}
+
+// kategoriSubtreeIDs mengembalikan id kategori yang cocok dengan nama/id beserta seluruh turunannya
+func kategoriSubtreeIDs(db *gorm.DB, value string) ([]uint, error) {
+    var all []models.Kategori
+    if err := db.Find(&all).Error; err != nil {
+        return nil, err
+    }
+
+    children := map[uint][]uint{}
+    var queue []uint
+    id, idErr := strconv.ParseUint(value, 10, 32)
+    for _, k := range all {
+        if k.ParentID != nil {
+            children[*k.ParentID] = append(children[*k.ParentID], k.ID)
+        }
+        if strings.EqualFold(k.Nama, value) || (idErr == nil && k.ID == uint(id)) {
+            queue = append(queue, k.ID)
+        }
+    }
+
+    seen := map[uint]bool{}
+    var ids []uint
+    for len(queue) > 0 {
+        current := queue[0]
+        queue = queue[1:]
+        if seen[current] {
+            continue
+        }
+        seen[current] = true
+        ids = append(ids, current)
+        queue = append(queue, children[current]...)
+    }
+    return ids, nil
+}
+
+// isKategoriDescendant mengecek apakah candidate berada di bawah ancestor (mencegah siklus)
+func isKategoriDescendant(db *gorm.DB, ancestor, candidate uint) (bool, error) {
+    current := candidate
+    for i := 0; i < 64; i++ {
+        if current == ancestor {
+            return true, nil
+        }
+        var k models.Kategori
+        if err := db.Select("id", "parent_id").First(&k, current).Error; err != nil {
+            return false, err
+        }
+        if k.ParentID == nil {
+            return false, nil
+        }
+        current = *k.ParentID
+    }
+    return false, nil
+}
+
+// kategoriNameTaken mengecek duplikasi nama di bawah parent yang sama
+func kategoriNameTaken(db *gorm.DB, nama string, parentID *uint, excludeID uint) (bool, error) {
+    query := db.Model(&models.Kategori{}).Where("LOWER(nama) = ? AND id <> ?", strings.ToLower(nama), excludeID)
+    if parentID == nil {
+        query = query.Where("parent_id IS NULL")
+    } else {
+        query = query.Where("parent_id = ?", *parentID)
+    }
+    var count int64
+    if err := query.Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+// loadKategoriWithCounts memuat semua kategori beserta jumlah peraturan langsung dan total subtree.
+// Hanya peraturan yang boleh dilihat user yang dihitung.
+func loadKategoriWithCounts(db *gorm.DB, user *models.User) ([]models.Kategori, error) {
+    var all []models.Kategori
+    if err := db.Order("nama asc").Find(&all).Error; err != nil {
+        return nil, err
+    }
+    var pairs []kategoriPair
+    if err := db.Table("peraturan_kategori").Select("peraturan_id, kategori_id").
+        Where("peraturan_id IN (?)", visibleIDs(db, user)).Scan(&pairs).Error; err != nil {
+        return nil, err
+    }
+
+    parent := map[uint]*uint{}
+    for _, k := range all {
+        parent[k.ID] = k.ParentID
+    }
+
+    direct := map[uint]int64{}
+    subtree := map[uint]map[uint]bool{}
+    for _, p := range pairs {
+        direct[p.KategoriID]++
+        // Tambahkan peraturan ke kategori ini dan semua leluhurnya
+        current := &p.KategoriID
+        for depth := 0; current != nil && depth < 64; depth++ {
+            if subtree[*current] == nil {
+                subtree[*current] = map[uint]bool{}
+            }
+            subtree[*current][p.PeraturanID] = true
+            current = parent[*current]
+        }
+    }
+
+    for i := range all {
+        all[i].Jumlah = direct[all[i].ID]
+        all[i].JumlahTotal = int64(len(subtree[all[i].ID]))
+    }
+    return all, nil
+}
+
+// buildKategoriTree menyusun daftar datar menjadi pohon
+func buildKategoriTree(all []models.Kategori) []models.Kategori {
+    byParent := map[uint][]models.Kategori{}
+    var roots []models.Kategori
+    for _, k := range all {
+        if k.ParentID == nil {
+            roots = append(roots, k)
+        } else {
+            byParent[*k.ParentID] = append(byParent[*k.ParentID], k)
+        }
+    }
+
+    var attach func(nodes []models.Kategori, depth int) []models.Kategori
+    attach = func(nodes []models.Kategori, depth int) []models.Kategori {
+        for i := range nodes {
+            if depth < 64 {
+                nodes[i].Children = attach(byParent[nodes[i].ID], depth+1)
+            }
+        }
+        return nodes
+    }
+    if roots == nil {
+        roots = []models.Kategori{}
+    }
+    return attach(roots, 0)
+}
+
+// GetKategori - Daftar kategori dalam bentuk pohon (?flat=true untuk daftar datar)
+func (h *KategoriHandler) GetKategori(c *gin.Context) {
+    all, err := loadKategoriWithCounts(h.DB, viewerFromContext(c))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kategori: " + err.Error()})
+        return
+    }
+
+    if c.Query("flat") == "true" {
+        c.JSON(http.StatusOK, gin.H{"data": all})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": buildKategoriTree(all)})
+}
+
+// GetKategoriCounts - Jumlah peraturan per kategori untuk statistik dashboard
+func (h *KategoriHandler) GetKategoriCounts(c *gin.Context) {
+    all, err := loadKategoriWithCounts(h.DB, viewerFromContext(c))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kategori counts: " + err.Error()})
+        return
+    }
+
+    sort.SliceStable(all, func(i, j int) bool { return all[i].JumlahTotal > all[j].JumlahTotal })
+
+    var uncategorized int64
+    scopeVisible(h.DB, h.DB.Model(&models.Peraturan{}), viewerFromContext(c)).
+        Where("id NOT IN (?)", h.DB.Table("peraturan_kategori").Select("peraturan_id")).
+        Count(&uncategorized)
+
+    c.JSON(http.StatusOK, gin.H{
+        "data":          all,
+        "uncategorized": uncategorized,
+    })
+}
+
+// CreateKategori - Menambahkan kategori baru (opsional di bawah parent)
+func (h *KategoriHandler) CreateKategori(c *gin.Context) {
+    var req KategoriRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    req.Nama = strings.TrimSpace(req.Nama)
+
+    if req.ParentID != nil {
+        if err := h.DB.First(&models.Kategori{}, *req.ParentID).Error; err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parent kategori not found"})
+            return
+        }
+    }
+    taken, err := kategoriNameTaken(h.DB, req.Nama, req.ParentID, 0)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check kategori name"})
+        return
+    }
+    if taken {
+        c.JSON(http.StatusConflict, gin.H{"error": "Kategori with the same name already exists"})
+        return
+    }
+
+    kategori := models.Kategori{Nama: req.Nama, ParentID: req.ParentID}
+    if err := h.DB.Create(&kategori).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create kategori: " + err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"data": kategori})
+}
+
+// UpdateKategori - Rename dan/atau pindah parent; teks kategori pada peraturan ikut diperbarui
+func (h *KategoriHandler) UpdateKategori(c *gin.Context) {
+    var kategori models.Kategori
+    if err := h.DB.First(&kategori, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Kategori not found"})
+        return
+    }
+
+    var req KategoriRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    req.Nama = strings.TrimSpace(req.Nama)
+
+    if req.ParentID != nil {
+        cyclic, err := isKategoriDescendant(h.DB, kategori.ID, *req.ParentID)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parent kategori not found"})
+            return
+        }
+        if cyclic {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Kategori cannot be moved under itself or its descendants"})
+            return
+        }
+    }
+    taken, err := kategoriNameTaken(h.DB, req.Nama, req.ParentID, kategori.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check kategori name"})
+        return
+    }
+    if taken {
+        c.JSON(http.StatusConflict, gin.H{"error": "Kategori with the same name already exists"})
+        return
+    }
+
+    err = h.DB.Model(&kategori).Updates(map[string]interface{}{
+        "nama":      req.Nama,
+        "parent_id": req.ParentID,
+    }).Error
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update kategori: " + err.Error()})
+        return
+    }
+
+    h.DB.First(&kategori, kategori.ID)
+    c.JSON(http.StatusOK, gin.H{"data": kategori})
+}
+
+// MergeKategori - Menggabungkan kategori :id ke target; dokumen dan sub-kategori dipindahkan ke target
+func (h *KategoriHandler) MergeKategori(c *gin.Context) {
+    var source models.Kategori
+    if err := h.DB.First(&source, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Kategori not found"})
+        return
+    }
+
+    var req MergeKategoriRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var target models.Kategori
+    if err := h.DB.First(&target, req.TargetID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Target kategori not found"})
+        return
+    }
+    if isDescendant, _ := isKategoriDescendant(h.DB, source.ID, target.ID); isDescendant {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Kategori cannot be merged into itself or its descendants"})
+        return
+    }
+
+    var affected []uint
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        var err error
+        affected, err = taggedPeraturanIDs(tx, source.ID)
+        if err != nil {
+            return err
+        }
+
+        // Tandai ulang dokumen ke target, lewati yang sudah punya target agar tidak duplikat
+        if err := tx.Exec(`INSERT INTO peraturan_kategori (peraturan_id, kategori_id)
+            SELECT peraturan_id, ? FROM peraturan_kategori WHERE kategori_id = ?
+            ON CONFLICT DO NOTHING`, target.ID, source.ID).Error; err != nil {
+            return err
+        }
+        if err := tx.Exec("DELETE FROM peraturan_kategori WHERE kategori_id = ?", source.ID).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&models.Kategori{}).Where("parent_id = ?", source.ID).Update("parent_id", target.ID).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&source).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge kategori: " + err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":          "Kategori merged successfully",
+        "data":             target,
+        "retagged_records": len(affected),
+    })
+}
+
+// DeleteKategori - Menghapus kategori tanpa sub-kategori; tag pada dokumen ikut dilepas
+func (h *KategoriHandler) DeleteKategori(c *gin.Context) {
+    var kategori models.Kategori
+    if err := h.DB.First(&kategori, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Kategori not found"})
+        return
+    }
+
+    var childCount int64
+    h.DB.Model(&models.Kategori{}).Where("parent_id = ?", kategori.ID).Count(&childCount)
+    if childCount > 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "Kategori still has sub-kategori, move or merge them first"})
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM peraturan_kategori WHERE kategori_id = ?", kategori.ID).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&kategori).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete kategori: " + err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Kategori deleted successfully"})
+}
+
+// MigrateKategori memindahkan kategori lama (JSON di kolom teks peraturans.kategori) ke tabel
+// kategori dan peraturan_kategori, lalu menghapus kolom tersebut. Kolom hanya dihapus jika semua
+// baris berhasil dipindahkan; jika ada yang gagal kolom dibiarkan agar datanya bisa diperbaiki
+// lalu migrasi dijalankan ulang. Aman dijalankan berulang: setelah kolom dihapus tidak ada yang dilakukan.
+func MigrateKategori(db *gorm.DB) error {
+    if !db.Migrator().HasColumn(&models.Peraturan{}, "kategori") {
+        return nil
+    }
+
+    rows, err := legacyKategoriRows(db)
+    if err != nil {
+        return err
+    }
+
+    migrated, failed := 0, 0
+    for _, row := range rows {
+        names, err := parseKategoriNames(row.Kategori)
+        if err != nil {
+            log.Printf("WARNING: Invalid kategori on peraturan %d: %q", row.ID, row.Kategori)
+            failed++
+            continue
+        }
+        if !hasKategoriNames(names) {
+            continue
+        }
+
+        err = db.Transaction(func(tx *gorm.DB) error {
+            kategoris, err := resolveKategori(tx, names)
+            if err != nil {
+                return err
+            }
+            return tx.Model(&models.Peraturan{ID: row.ID}).Association("KategoriItems").Replace(kategoris)
+        })
+        if err != nil {
+            return err
+        }
+        migrated++
+    }
+
+    if migrated > 0 {
+        log.Printf("INFO: Migrated kategori for %d peraturan", migrated)
+    }
+    if failed > 0 {
+        return fmt.Errorf("kategori of %d peraturan could not be parsed, fix peraturans.kategori and restart to finish the migration", failed)
+    }
+
+    // Pastikan setiap baris lama sudah punya relasi sebelum kolomnya dihapus
+    rows, err = legacyKategoriRows(db)
+    if err != nil {
+        return err
+    }
+    for _, row := range rows {
+        if names, err := parseKategoriNames(row.Kategori); err != nil || hasKategoriNames(names) {
+            return fmt.Errorf("peraturan %d still has kategori %q without peraturan_kategori rows", row.ID, row.Kategori)
+        }
+    }
+
+    if err := db.Migrator().DropColumn(&models.Peraturan{}, "kategori"); err != nil {
+        return err
+    }
+    log.Printf("INFO: Dropped legacy column peraturans.kategori")
+    return nil
+}
+
+// hasKategoriNames mengecek ada nama yang tidak kosong; nama kosong dilewati resolveKategori
+func hasKategoriNames(names []string) bool {
+    for _, name := range names {
+        if strings.TrimSpace(name) != "" {
+            return true
+        }
+    }
+    return false
+}
+
+// legacyKategoriRow adalah isi kolom lama peraturans.kategori
+type legacyKategoriRow struct {
+    ID       uint
+    Kategori string
+}
+
+// legacyKategoriRows mengambil baris yang punya kategori lama tetapi belum punya relasi di peraturan_kategori
+func legacyKategoriRows(db *gorm.DB) ([]legacyKategoriRow, error) {
+    var rows []legacyKategoriRow
+    err := db.Table("peraturans").Select("id, kategori").
+        Where("kategori IS NOT NULL AND kategori NOT IN ('', '[]', 'null')").
+        Where("id NOT IN (?)", db.Table("peraturan_kategori").Select("peraturan_id")).
+        Scan(&rows).Error
+    return rows, err
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotifikasiHandler struct {
+    DB *gorm.DB
+}
+
+type LanggananRequest struct {
+    Tipe       string `json:"tipe" binding:"required"`
+    KategoriID *uint  `json:"kategori_id"`
+    Nilai      string `json:"nilai"`
+}
+
+type PreferensiRequest struct {
+    Frekuensi string `json:"frekuensi" binding:"required"`
+    Email     *bool  `json:"email"`
+}
+
+// GetSubscriptions - Daftar langganan milik user yang login
+func (h *NotifikasiHandler) GetSubscriptions(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var langganan []models.Langganan
+    if err := h.DB.Preload("Kategori").Where("user_id = ?", user.ID).Order("created_at desc").Find(&langganan).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": langganan})
+}
+
+// CreateSubscription - Berlangganan kategori (beserta sub-kategorinya), jenis, atau instansi
+func (h *NotifikasiHandler) CreateSubscription(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var req LanggananRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    langganan := models.Langganan{UserID: user.ID, Tipe: req.Tipe, CreatedAt: time.Now()}
+    existing := h.DB.Model(&models.Langganan{}).Where("user_id = ? AND tipe = ?", user.ID, req.Tipe)
+    switch req.Tipe {
+    case models.LanggananKategori:
+        if req.KategoriID == nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "kategori_id is required"})
+            return
+        }
+        var kategori models.Kategori
+        if err := h.DB.First(&kategori, *req.KategoriID).Error; err != nil {
+            c.JSON(http.StatusNotFound, gin.H{"error": "Kategori not found"})
+            return
+        }
+        langganan.KategoriID = &kategori.ID
+        langganan.Kategori = &kategori
+        existing = existing.Where("kategori_id = ?", kategori.ID)
+    case models.LanggananJenis, models.LanggananInstansi:
+        langganan.Nilai = strings.TrimSpace(req.Nilai)
+        if langganan.Nilai == "" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "nilai is required"})
+            return
+        }
+        existing = existing.Where("LOWER(nilai) = LOWER(?)", langganan.Nilai)
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "tipe must be kategori, jenis, or instansi"})
+        return
+    }
+
+    var count int64
+    if err := existing.Count(&count).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check subscriptions"})
+        return
+    }
+    if count > 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "Already subscribed"})
+        return
+    }
+
+    if err := h.DB.Omit("Kategori").Create(&langganan).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+        return
+    }
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Subscription created successfully",
+        "data":    langganan,
+    })
+}
+
+// DeleteSubscription - Berhenti berlangganan
+func (h *NotifikasiHandler) DeleteSubscription(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    result := h.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).Delete(&models.Langganan{})
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Subscription deleted successfully"})
+}
+
+// GetPreferences - Preferensi email notifikasi user (default: email langsung)
+func (h *NotifikasiHandler) GetPreferences(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    pref := models.PreferensiNotifikasi{UserID: user.ID, Frekuensi: models.FrekuensiLangsung, Email: true}
+    if err := h.DB.Where("user_id = ?", user.ID).Limit(1).Find(&pref).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification preferences"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": pref})
+}
+
+// UpdatePreferences - Memilih email langsung atau ringkasan harian, atau mematikan email
+func (h *NotifikasiHandler) UpdatePreferences(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var req PreferensiRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.Frekuensi != models.FrekuensiLangsung && req.Frekuensi != models.FrekuensiHarian {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "frekuensi must be immediate or daily"})
+        return
+    }
+
+    pref := models.PreferensiNotifikasi{UserID: user.ID, Frekuensi: req.Frekuensi, Email: true, UpdatedAt: time.Now()}
+    if req.Email != nil {
+        pref.Email = *req.Email
+    }
+    if err := h.DB.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "user_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"frekuensi", "email", "updated_at"}),
+    }).Create(&pref).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Notification preferences updated successfully",
+        "data":    pref,
+    })
+}
+
+// GetNotifications - Inbox notifikasi terbaru (opsional ?unread=true, page/limit)
+func (h *NotifikasiHandler) GetNotifications(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    // Notifikasi untuk peraturan yang kemudian disembunyikan dari user tidak ditampilkan
+    query := h.DB.Model(&models.Notifikasi{}).Where("user_id = ? AND peraturan_id IN (?)", user.ID, visibleIDs(h.DB, &user))
+    if c.Query("unread") == "true" {
+        query = query.Where("dibaca_at IS NULL")
+    }
+
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page < 1 {
+        page = 1
+    }
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if err != nil || limit < 1 {
+        limit = 20
+    }
+    if limit > maxPageLimit {
+        limit = maxPageLimit
+    }
+
+    var total int64
+    if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+        return
+    }
+    var notifikasi []models.Notifikasi
+    if err := query.Preload("Peraturan.KategoriItems").Order("created_at desc").Order("id desc").
+        Offset((page - 1) * limit).Limit(limit).Find(&notifikasi).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "data": notifikasi,
+        "pagination": Pagination{
+            Page:       page,
+            Limit:      limit,
+            Total:      total,
+            TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+        },
+    })
+}
+
+// GetUnreadCount - Jumlah notifikasi yang belum dibaca (untuk badge)
+func (h *NotifikasiHandler) GetUnreadCount(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var count int64
+    if err := h.DB.Model(&models.Notifikasi{}).Where("user_id = ? AND dibaca_at IS NULL AND peraturan_id IN (?)", user.ID, visibleIDs(h.DB, &user)).
+        Count(&count).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// MarkNotificationRead - Menandai satu notifikasi sudah dibaca
+func (h *NotifikasiHandler) MarkNotificationRead(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var notifikasi models.Notifikasi
+    if err := h.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&notifikasi).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+        return
+    }
+    if notifikasi.DibacaAt == nil {
+        now := time.Now()
+        if err := h.DB.Model(&notifikasi).Update("dibaca_at", now).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+            return
+        }
+        notifikasi.DibacaAt = &now
+    }
+    c.JSON(http.StatusOK, gin.H{"data": notifikasi})
+}
+
+// MarkAllNotificationsRead - Menandai seluruh notifikasi user sudah dibaca
+func (h *NotifikasiHandler) MarkAllNotificationsRead(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    result := h.DB.Model(&models.Notifikasi{}).Where("user_id = ? AND dibaca_at IS NULL", user.ID).Update("dibaca_at", time.Now())
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Notifications marked as read",
+        "updated": result.RowsAffected,
+    })
+}
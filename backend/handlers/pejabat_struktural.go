@@ -27,6 +27,7 @@ func (h *PejabatStrukturalHandler) GetPejabatStruktural(c *gin.Context) {
         return
     }
     
+    redactEmployees(c, employees)
     c.JSON(http.StatusOK, employees)
 }
 
@@ -46,6 +47,7 @@ func (h *PejabatStrukturalHandler) GetPejabatById(c *gin.Context) {
         return
     }
     
+    redactEmployee(c, &employee)
     c.JSON(http.StatusOK, employee)
 }
 
@@ -62,6 +64,7 @@ func (h *PejabatStrukturalHandler) GetAvailableForStruktural(c *gin.Context) {
         return
     }
     
+    redactEmployees(c, employees)
     c.JSON(http.StatusOK, employees)
 }
 
@@ -300,6 +303,7 @@ func (h *PejabatStrukturalHandler) GetBawahanByPejabat(c *gin.Context) {
         return
     }
     
+    redactEmployees(c, employees)
     c.JSON(http.StatusOK, employees)
 }
 
@@ -326,5 +330,6 @@ func (h *EmployeeHandler) GetEmployeesByBidang(c *gin.Context) {
         return
     }
     
+    redactEmployees(c, employees)
     c.JSON(http.StatusOK, employees)
 }
\ No newline at end of file
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas default paket ZIP; bisa diubah lewat BUNDLE_MAX_FILES dan BUNDLE_MAX_SIZE_MB
+const (
+    defaultBundleMaxFiles = 50
+    defaultBundleMaxSize  = 200 << 20
+)
+
+// bundleItem adalah satu peraturan di paket beserta nama file-nya di dalam ZIP
+type bundleItem struct {
+    Peraturan models.Peraturan
+    ZipName   string // Kosong jika peraturan tidak punya file
+    Missing   bool   // File tidak ditemukan di storage saat paket dibuat
+}
+
+var bundleIndexTemplate = template.Must(template.New("index").Funcs(template.FuncMap{
+    "inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Daftar Peraturan</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 4px 6px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>Daftar Peraturan</h1>
+<p>Dibuat {{.Generated}} &middot; {{len .Items}} peraturan</p>
+<table>
+<tr><th>No</th><th>Jenis</th><th>Nomor</th><th>Tahun</th><th>Judul</th><th>Instansi</th><th>Kategori</th><th>Status</th><th>File</th></tr>
+{{range $i, $item := .Items}}<tr>
+<td>{{inc $i}}</td>
+<td>{{$item.Jenis}}</td>
+<td>{{$item.Peraturan.Nomor}}</td>
+<td>{{$item.Peraturan.TanggalDitetapkan.Year}}</td>
+<td>{{$item.Peraturan.Judul}}</td>
+<td>{{$item.Peraturan.InstansiPembuat}}</td>
+<td>{{$item.Kategori}}</td>
+<td>{{$item.Peraturan.Status}}</td>
+<td>{{if $item.Missing}}File tidak tersedia{{else if $item.ZipName}}<a href="{{$item.ZipName}}">{{$item.Peraturan.NamaFile}}</a>{{else}}-{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// bundleLimits membaca BUNDLE_MAX_FILES dan BUNDLE_MAX_SIZE_MB
+func bundleLimits() (int, int64) {
+    maxFiles, maxSize := defaultBundleMaxFiles, int64(defaultBundleMaxSize)
+    if n, err := strconv.Atoi(os.Getenv("BUNDLE_MAX_FILES")); err == nil && n > 0 {
+        maxFiles = n
+    }
+    if mb, err := strconv.Atoi(os.Getenv("BUNDLE_MAX_SIZE_MB")); err == nil && mb > 0 {
+        maxSize = int64(mb) << 20
+    }
+    return maxFiles, maxSize
+}
+
+// parseBundleIDs membaca ?ids=1,2,3 (urutan dipertahankan, duplikat dibuang)
+func parseBundleIDs(raw string) ([]uint, error) {
+    var ids []uint
+    seen := map[uint]bool{}
+    for _, part := range strings.Split(raw, ",") {
+        part = strings.TrimSpace(part)
+        if part == "" {
+            continue
+        }
+        id, err := strconv.ParseUint(part, 10, 32)
+        if err != nil {
+            return nil, fmt.Errorf("invalid id %q", part)
+        }
+        if !seen[uint(id)] {
+            seen[uint(id)] = true
+            ids = append(ids, uint(id))
+        }
+    }
+    return ids, nil
+}
+
+// DownloadBundle - Mengunduh beberapa peraturan sekaligus sebagai ZIP, dipilih dengan ?ids=1,2,3
+// atau filter yang sama seperti GetPeraturanWithFilters. ZIP berisi file peraturan dan daftar
+// isi (index.csv + index.html). File dibaca dari storage satu per satu dan langsung ditulis ke response.
+func (h *PeraturanHandler) DownloadBundle(c *gin.Context) {
+    maxFiles, maxSize := bundleLimits()
+
+    var peraturans []models.Peraturan
+    if raw := c.Query("ids"); raw != "" {
+        ids, err := parseBundleIDs(raw)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        if len(ids) > maxFiles {
+            c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Bundle is limited to %d peraturan", maxFiles)})
+            return
+        }
+        // Peraturan yang tidak boleh dilihat diperlakukan sama dengan yang tidak ada
+        var found []models.Peraturan
+        if err := scopeVisible(h.DB, h.DB.Model(&models.Peraturan{}), viewerFromContext(c)).
+            Preload("KategoriItems").Where("id IN ?", ids).Find(&found).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
+            return
+        }
+        byID := make(map[uint]models.Peraturan, len(found))
+        for _, p := range found {
+            byID[p.ID] = p
+        }
+        for _, id := range ids {
+            p, ok := byID[id]
+            if !ok {
+                c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Peraturan %d not found", id)})
+                return
+            }
+            peraturans = append(peraturans, p)
+        }
+    } else {
+        filter, err := parsePeraturanFilter(c, h.DB)
+        if err != nil {
+            filterError(c, err)
+            return
+        }
+        // Ambil satu lebih dari batas untuk mendeteksi hasil filter yang terlalu banyak
+        query := applySort(c, filter.apply(h.DB, h.DB.Model(&models.Peraturan{}), ""))
+        if err := query.Preload("KategoriItems").Limit(maxFiles + 1).Find(&peraturans).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
+            return
+        }
+        if len(peraturans) > maxFiles {
+            c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Filter matches more than %d peraturan, narrow the filter", maxFiles)})
+            return
+        }
+    }
+    if len(peraturans) == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "No peraturan matched"})
+        return
+    }
+
+    // Ukuran dicek dari metadata sebelum response dimulai, karena setelah itu status tidak bisa diubah
+    var total int64
+    items := make([]bundleItem, len(peraturans))
+    width := len(strconv.Itoa(len(peraturans)))
+    for i, p := range peraturans {
+        items[i].Peraturan = p
+        if p.PathFile == "" {
+            continue
+        }
+        total += p.FileSize
+        items[i].ZipName = fmt.Sprintf("files/%0*d_%s", width, i+1, path.Base(strings.ReplaceAll(p.NamaFile, "\\", "/")))
+    }
+    if total > maxSize {
+        c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Bundle size exceeds %d MB", maxSize>>20)})
+        return
+    }
+    // Salinan ber-watermark harus atas nama user yang login
+    if _, ok := c.Get("user"); !ok {
+        for _, p := range peraturans {
+            if p.Watermark && p.PathFile != "" {
+                c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("Login required to download peraturan %d", p.ID)})
+                return
+            }
+        }
+    }
+
+    filename := "peraturan-" + time.Now().Format("20060102-150405") + ".zip"
+    c.Header("Content-Type", "application/zip")
+    c.Header("Content-Disposition", contentDisposition("attachment", filename))
+    c.Header("Cache-Control", "no-store")
+    c.Status(http.StatusOK)
+
+    // Sisa kuota tetap dijaga saat streaming karena file_size record lama bisa kosong atau tidak akurat
+    remaining := maxSize
+    zw := zip.NewWriter(c.Writer)
+    for i := range items {
+        item := &items[i]
+        if item.ZipName == "" {
+            continue
+        }
+        if err := h.writeBundleFile(c, zw, item, &remaining); err != nil {
+            c.Error(err) // Header sudah terkirim, ZIP dibiarkan terpotong
+            return
+        }
+        h.recordAkses(c, &item.Peraturan, models.AksesDownload)
+    }
+
+    if err := writeBundleIndex(zw, items, publicBaseURL()); err != nil {
+        c.Error(err)
+        return
+    }
+    if err := zw.Close(); err != nil {
+        c.Error(err)
+    }
+}
+
+// writeBundleFile menyalin satu file dari storage ke ZIP (file ber-watermark dibubuhi identitas
+// user lebih dulu). File yang hilang dari storage tidak menggagalkan paket, hanya ditandai di daftar isi.
+func (h *PeraturanHandler) writeBundleFile(c *gin.Context, zw *zip.Writer, item *bundleItem, remaining *int64) error {
+    var reader io.Reader
+    modified := time.Now()
+    if item.Peraturan.Watermark {
+        data, err := h.watermarkFile(c, &item.Peraturan)
+        if errors.Is(err, storage.ErrNotFound) {
+            item.Missing = true
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        reader = bytes.NewReader(data)
+    } else {
+        rc, info, err := h.Storage.Get(c.Request.Context(), item.Peraturan.PathFile)
+        if errors.Is(err, storage.ErrNotFound) {
+            item.Missing = true
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        defer rc.Close()
+        reader, modified = rc, info.ModTime
+    }
+
+    // PDF/DOCX sudah terkompresi, jadi disimpan apa adanya (Store) agar hemat CPU
+    w, err := zw.CreateHeader(&zip.FileHeader{
+        Name:     item.ZipName,
+        Method:   zip.Store,
+        Modified: modified,
+    })
+    if err != nil {
+        return err
+    }
+    n, err := io.CopyN(w, reader, *remaining+1)
+    *remaining -= n
+    if err == io.EOF {
+        return nil
+    }
+    if err == nil {
+        return errors.New("bundle size limit exceeded while streaming")
+    }
+    return err
+}
+
+// writeBundleIndex menulis index.csv (kolom sama dengan ekspor katalog) dan index.html
+func writeBundleIndex(zw *zip.Writer, items []bundleItem, baseURL string) error {
+    w, err := zw.Create("index.csv")
+    if err != nil {
+        return err
+    }
+    w.Write([]byte("\xef\xbb\xbf")) // BOM agar Excel membaca UTF-8
+    cw := csv.NewWriter(w)
+    cw.Write(append(append([]string{}, exportColumns...), "File di ZIP"))
+    for i := range items {
+        zipName := items[i].ZipName
+        if items[i].Missing {
+            zipName = "File tidak tersedia"
+        }
+        cw.Write(csvSafe(append(exportRow(&items[i].Peraturan, baseURL), zipName)))
+    }
+    cw.Flush()
+    if err := cw.Error(); err != nil {
+        return err
+    }
+
+    type indexRow struct {
+        bundleItem
+        Jenis    string
+        Kategori string
+    }
+    rows := make([]indexRow, len(items))
+    for i, item := range items {
+        kategori, _ := parseKategoriNames(item.Peraturan.Kategori)
+        rows[i] = indexRow{bundleItem: item, Jenis: jenisNama(item.Peraturan.JenisPeraturan).Nama, Kategori: strings.Join(kategori, ", ")}
+    }
+    w, err = zw.Create("index.html")
+    if err != nil {
+        return err
+    }
+    return bundleIndexTemplate.Execute(w, gin.H{
+        "Generated": time.Now().Format("02-01-2006 15:04"),
+        "Items":     rows,
+    })
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Alasan peraturan dianggap duplikat
+const (
+    DuplikatNomor = "nomor" // Nomor, jenis, dan tahun sama setelah dinormalisasi
+    DuplikatFile  = "file"  // Isi file identik (hash SHA-256 sama)
+    DuplikatJudul = "judul" // Judul hampir sama pada jenis yang sama
+)
+
+// judulMiripThreshold adalah skor kemiripan judul (0-1) minimal untuk dianggap duplikat
+const judulMiripThreshold = 0.9
+
+// maxDuplicateMatches membatasi jumlah peraturan yang dikembalikan di response konflik
+const maxDuplicateMatches = 20
+
+// DuplicateMatch adalah peraturan yang sudah ada dan kemungkinan sama dengan yang akan dibuat
+type DuplicateMatch struct {
+    Alasan    []string         `json:"alasan"`
+    Kemiripan float64          `json:"kemiripan,omitempty"` // Skor kemiripan judul jika alasan termasuk judul
+    Peraturan models.Peraturan `json:"peraturan"`
+}
+
+// duplicateCandidate adalah data peraturan baru yang dicek
+type duplicateCandidate struct {
+    Nomor    string
+    Jenis    string
+    Tahun    int
+    Judul    string
+    FileHash string
+}
+
+// jenisVariants mengembalikan penulisan yang setara untuk satu jenis (kode, nama, singkatan)
+func jenisVariants(jenis string) []string {
+    jenis = strings.ToLower(strings.TrimSpace(jenis))
+    variants := map[string]bool{jenis: true}
+    for kode, j := range jenisPeraturan {
+        names := []string{kode, strings.ToLower(j.Nama), strings.ToLower(j.Singkatan)}
+        for _, name := range names {
+            if name == jenis {
+                for _, v := range names {
+                    if v != "" {
+                        variants[v] = true
+                    }
+                }
+                break
+            }
+        }
+    }
+    result := make([]string, 0, len(variants))
+    for v := range variants {
+        result = append(result, v)
+    }
+    sort.Strings(result)
+    return result
+}
+
+// canonicalJenis menyamakan kode, nama lengkap, dan singkatan jenis menjadi satu nilai
+func canonicalJenis(jenis string) string {
+    return jenisVariants(jenis)[0]
+}
+
+func isNotAlnum(r rune) bool {
+    return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// normalizeNomor menyamakan penulisan nomor peraturan: "Nomor 11 Tahun 2017", "11/2017",
+// dan "PP No. 011" (tahun 2017, jenis PP) semuanya menjadi "11"
+func normalizeNomor(nomor, jenis string, tahun int) string {
+    skip := map[string]bool{"nomor": true, "no": true, "tahun": true}
+    for _, v := range jenisVariants(jenis) {
+        skip[v] = true
+    }
+
+    var tokens []string
+    for _, token := range strings.FieldsFunc(strings.ToLower(nomor), isNotAlnum) {
+        if skip[token] {
+            continue
+        }
+        if n, err := strconv.Atoi(token); err == nil {
+            token = strconv.Itoa(n) // "011" -> "11"
+        }
+        tokens = append(tokens, token)
+    }
+    // Tahun di akhir nomor sama dengan tahun penetapan, jadi tidak ikut dibandingkan
+    if len(tokens) > 1 && tokens[len(tokens)-1] == strconv.Itoa(tahun) {
+        tokens = tokens[:len(tokens)-1]
+    }
+    return strings.Join(tokens, "/")
+}
+
+// judulTrigrams memecah judul yang sudah dinormalisasi menjadi trigram karakter
+func judulTrigrams(judul string) map[string]bool {
+    runes := []rune(" " + strings.Join(strings.FieldsFunc(strings.ToLower(judul), isNotAlnum), " ") + " ")
+    trigrams := map[string]bool{}
+    for i := 0; i+3 <= len(runes); i++ {
+        trigrams[string(runes[i:i+3])] = true
+    }
+    return trigrams
+}
+
+// judulSimilarity menghitung koefisien Dice dari trigram dua judul (1 = identik)
+func judulSimilarity(a, b map[string]bool) float64 {
+    if len(a) == 0 || len(b) == 0 {
+        return 0
+    }
+    common := 0
+    for t := range a {
+        if b[t] {
+            common++
+        }
+    }
+    return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// findDuplicates mencari peraturan yang kemungkinan sama berdasarkan nomor+jenis+tahun,
+// hash file, dan judul yang hampir sama. Hasil diurutkan dari yang paling banyak alasannya.
+func findDuplicates(db *gorm.DB, cand duplicateCandidate) ([]DuplicateMatch, error) {
+    matches := map[uint]*DuplicateMatch{}
+    add := func(id uint, alasan string, kemiripan float64) {
+        m, ok := matches[id]
+        if !ok {
+            m = &DuplicateMatch{}
+            matches[id] = m
+        }
+        m.Alasan = append(m.Alasan, alasan)
+        if kemiripan > 0 {
+            m.Kemiripan = kemiripan
+        }
+    }
+
+    // Nomor dan judul dibandingkan di Go karena normalisasinya tidak bisa dilakukan di SQL;
+    // kandidat dipersempit ke jenis yang sama
+    var sameJenis []struct {
+        ID    uint
+        Nomor string
+        Judul string
+        Tahun int
+    }
+    if err := db.Model(&models.Peraturan{}).
+        Select("id, nomor, judul, CAST(EXTRACT(YEAR FROM tanggal_ditetapkan) AS INTEGER) AS tahun").
+        Where("LOWER(TRIM(jenis_peraturan)) IN ?", jenisVariants(cand.Jenis)).
+        Scan(&sameJenis).Error; err != nil {
+        return nil, err
+    }
+    nomor := normalizeNomor(cand.Nomor, cand.Jenis, cand.Tahun)
+    judul := judulTrigrams(cand.Judul)
+    for _, p := range sameJenis {
+        if nomor != "" && p.Tahun == cand.Tahun && normalizeNomor(p.Nomor, cand.Jenis, p.Tahun) == nomor {
+            add(p.ID, DuplikatNomor, 0)
+        }
+        if score := judulSimilarity(judul, judulTrigrams(p.Judul)); score >= judulMiripThreshold {
+            add(p.ID, DuplikatJudul, float64(int(score*1000))/1000)
+        }
+    }
+
+    if cand.FileHash != "" {
+        var ids []uint
+        if err := db.Model(&models.Peraturan{}).Where("file_hash = ?", cand.FileHash).Pluck("id", &ids).Error; err != nil {
+            return nil, err
+        }
+        for _, id := range ids {
+            add(id, DuplikatFile, 0)
+        }
+    }
+
+    if len(matches) == 0 {
+        return nil, nil
+    }
+    ids := make([]uint, 0, len(matches))
+    for id := range matches {
+        ids = append(ids, id)
+    }
+    var peraturans []models.Peraturan
+    if err := db.Preload("KategoriItems").Where("id IN ?", ids).Find(&peraturans).Error; err != nil {
+        return nil, err
+    }
+
+    result := make([]DuplicateMatch, 0, len(peraturans))
+    for _, p := range peraturans {
+        m := matches[p.ID]
+        m.Peraturan = p
+        result = append(result, *m)
+    }
+    sort.Slice(result, func(i, j int) bool {
+        if len(result[i].Alasan) != len(result[j].Alasan) {
+            return len(result[i].Alasan) > len(result[j].Alasan)
+        }
+        return result[i].Peraturan.ID < result[j].Peraturan.ID
+    })
+    if len(result) > maxDuplicateMatches {
+        result = result[:maxDuplicateMatches]
+    }
+    return result, nil
+}
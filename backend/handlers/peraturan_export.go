@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/xlsx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JenisPeraturan adalah nama lengkap dan singkatan untuk kode jenis yang dipakai frontend
+type JenisPeraturan struct {
+    Nama      string
+    Singkatan string
+}
+
+var jenisPeraturan = map[string]JenisPeraturan{
+    "uu":       {"Undang-Undang", "UU"},
+    "pp":       {"Peraturan Pemerintah", "PP"},
+    "perpres":  {"Peraturan Presiden", "PERPRES"},
+    "permen":   {"Peraturan Menteri", "PERMEN"},
+    "perda":    {"Peraturan Daerah", "PERDA"},
+    "perban":   {"Peraturan Badan", "PERBAN"},
+    "perka":    {"Peraturan Kepala", "PERKA"},
+    "kepka":    {"Keputusan Kepala", "KEPKA"},
+    "persesma": {"Peraturan Sekretaris Utama", "PERSESMA"},
+    "se":       {"Surat Edaran", "SE"},
+    "lainnya":  {"Lainnya", ""},
+}
+
+// jenisNama mengembalikan nama lengkap jenis; nilai di luar daftar dikembalikan apa adanya
+func jenisNama(kode string) JenisPeraturan {
+    if jenis, ok := jenisPeraturan[strings.ToLower(kode)]; ok {
+        return jenis
+    }
+    return JenisPeraturan{Nama: kode, Singkatan: strings.ToUpper(kode)}
+}
+
+// JDIHDokumen adalah metadata satu dokumen sesuai skema integrasi JDIH Nasional
+type JDIHDokumen struct {
+    IDData             string `json:"idData"`
+    TipeDokumen        string `json:"tipeDokumen"`
+    Judul              string `json:"judul"`
+    TEUBadan           string `json:"teuBadan"`
+    NoPeraturan        string `json:"noPeraturan"`
+    Jenis              string `json:"jenis"`
+    SingkatanJenis     string `json:"singkatanJenis"`
+    Tahun              string `json:"tahun"`
+    TempatPenetapan    string `json:"tempatPenetapan"`
+    TanggalPenetapan   string `json:"tanggalPenetapan"`
+    Penerbit           string `json:"penerbit"`
+    Subjek             string `json:"subjek"`
+    Status             string `json:"status"`
+    KeteranganStatus   string `json:"keteranganStatus"`
+    Bahasa             string `json:"bahasa"`
+    FileDownload       string `json:"fileDownload"`
+    URLDownload        string `json:"urlDownload"`
+    URLDetailPeraturan string `json:"urlDetailPeraturan"`
+}
+
+// exportColumns adalah header kolom ekspor CSV/XLSX
+var exportColumns = []string{
+    "ID", "Nomor", "Jenis Peraturan", "Judul", "Tanggal Ditetapkan", "Tahun", "Instansi Pembuat",
+    "Kategori", "Status", "Keterangan", "Nama File", "Ukuran File (byte)", "URL File", "Dibuat",
+}
+
+// publicBaseURL adalah URL dasar untuk tautan absolut dari PUBLIC_BASE_URL. Header Host dan
+// X-Forwarded-Proto tidak dipakai karena bisa dipalsukan client; tanpa PUBLIC_BASE_URL tautan
+// menjadi path relatif (feed dan sitemap butuh PUBLIC_BASE_URL agar tautannya absolut).
+func publicBaseURL() string {
+    return strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+}
+
+// csvSafe mencegah formula injection: sel yang diawali =, +, -, @, tab, atau CR diberi awalan '
+// agar spreadsheet membacanya sebagai teks
+func csvSafe(row []string) []string {
+    for i, v := range row {
+        if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+            row[i] = "'" + v
+        }
+    }
+    return row
+}
+
+// jdihTempatPenetapan dibaca dari JDIH_TEMPAT_PENETAPAN, default Jakarta
+func jdihTempatPenetapan() string {
+    if tempat := os.Getenv("JDIH_TEMPAT_PENETAPAN"); tempat != "" {
+        return tempat
+    }
+    return "Jakarta"
+}
+
+// toJDIH memetakan models.Peraturan ke skema JDIH. T.E.U. badan mengikuti pola
+// "Indonesia. <instansi>" dan status dicabut dilaporkan sebagai "Tidak Berlaku".
+func toJDIH(p *models.Peraturan, baseURL string) JDIHDokumen {
+    jenis := jenisNama(p.JenisPeraturan)
+    kategori, _ := parseKategoriNames(p.Kategori)
+
+    status, keterangan := "Berlaku", ""
+    switch p.Status {
+    case models.StatusDiubah:
+        keterangan = "Diubah"
+    case models.StatusDicabut:
+        status, keterangan = "Tidak Berlaku", "Dicabut"
+    }
+
+    doc := JDIHDokumen{
+        IDData:             strconv.FormatUint(uint64(p.ID), 10),
+        TipeDokumen:        "Peraturan Perundang-undangan",
+        Judul:              p.Judul,
+        TEUBadan:           "Indonesia. " + p.InstansiPembuat,
+        NoPeraturan:        p.Nomor,
+        Jenis:              jenis.Nama,
+        SingkatanJenis:     jenis.Singkatan,
+        Tahun:              strconv.Itoa(p.TanggalDitetapkan.Year()),
+        TempatPenetapan:    jdihTempatPenetapan(),
+        TanggalPenetapan:   p.TanggalDitetapkan.Format("2006-01-02"),
+        Penerbit:           p.InstansiPembuat,
+        Subjek:             strings.Join(kategori, ", "),
+        Status:             status,
+        KeteranganStatus:   keterangan,
+        Bahasa:             "Indonesia",
+        URLDetailPeraturan: fmt.Sprintf("%s/api/peraturan/%d", baseURL, p.ID),
+    }
+    if p.PathFile != "" {
+        doc.FileDownload = p.NamaFile
+        doc.URLDownload = fmt.Sprintf("%s/api/peraturan/download/%d", baseURL, p.ID)
+    }
+    return doc
+}
+
+func exportRow(p *models.Peraturan, baseURL string) []string {
+    kategori, _ := parseKategoriNames(p.Kategori)
+    fileURL := ""
+    if p.PathFile != "" {
+        fileURL = fmt.Sprintf("%s/api/peraturan/download/%d", baseURL, p.ID)
+    }
+    return []string{
+        strconv.FormatUint(uint64(p.ID), 10),
+        p.Nomor,
+        jenisNama(p.JenisPeraturan).Nama,
+        p.Judul,
+        p.TanggalDitetapkan.Format("2006-01-02"),
+        strconv.Itoa(p.TanggalDitetapkan.Year()),
+        p.InstansiPembuat,
+        strings.Join(kategori, "; "),
+        p.Status,
+        p.Keterangan,
+        p.NamaFile,
+        strconv.FormatInt(p.FileSize, 10),
+        fileURL,
+        p.CreatedAt.Format("2006-01-02 15:04:05"),
+    }
+}
+
+// ExportPeraturan - Ekspor katalog peraturan (?format=csv|xlsx|jdih) dengan filter yang sama
+// seperti GetPeraturanWithFilters. Data dibaca per baris dari database dan langsung ditulis ke response.
+func (h *PeraturanHandler) ExportPeraturan(c *gin.Context) {
+    format := c.DefaultQuery("format", "csv")
+    if format != "csv" && format != "xlsx" && format != "jdih" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv, xlsx or jdih"})
+        return
+    }
+
+    filter, err := parsePeraturanFilter(c, h.DB)
+    if err != nil {
+        filterError(c, err)
+        return
+    }
+    query := applySort(c, filter.apply(h.DB, h.DB.Model(&models.Peraturan{}), ""))
+    kategoris, err := kategoriByPeraturan(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kategori"})
+        return
+    }
+
+    rows, err := query.Rows()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
+        return
+    }
+    defer rows.Close()
+
+    baseURL := publicBaseURL()
+    filename := "peraturan-" + time.Now().Format("20060102")
+    var writeRow func(p *models.Peraturan) error
+    var finish func() error
+
+    c.Status(http.StatusOK)
+    switch format {
+    case "csv":
+        c.Header("Content-Type", "text/csv; charset=utf-8")
+        c.Header("Content-Disposition", contentDisposition("attachment", filename+".csv"))
+        c.Writer.WriteString("\xef\xbb\xbf") // BOM agar Excel membaca UTF-8
+        w := csv.NewWriter(c.Writer)
+        w.Write(exportColumns)
+        writeRow = func(p *models.Peraturan) error { return w.Write(csvSafe(exportRow(p, baseURL))) }
+        finish = func() error { w.Flush(); return w.Error() }
+    case "xlsx":
+        c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+        c.Header("Content-Disposition", contentDisposition("attachment", filename+".xlsx"))
+        w, err := xlsx.NewWriter(c.Writer, "Peraturan")
+        if err != nil {
+            c.Error(err)
+            return
+        }
+        w.WriteRow(exportColumns)
+        writeRow = func(p *models.Peraturan) error { return w.WriteRow(exportRow(p, baseURL)) }
+        finish = w.Close
+    case "jdih":
+        // Array JSON ditulis elemen per elemen agar tidak menampung seluruh katalog
+        c.Header("Content-Type", "application/json; charset=utf-8")
+        c.Writer.WriteString("[")
+        first := true
+        writeRow = func(p *models.Peraturan) error {
+            data, err := json.Marshal(toJDIH(p, baseURL))
+            if err != nil {
+                return err
+            }
+            if !first {
+                c.Writer.WriteString(",")
+            }
+            first = false
+            _, err = c.Writer.Write(data)
+            return err
+        }
+        finish = func() error { _, err := c.Writer.WriteString("]"); return err }
+    }
+
+    for rows.Next() {
+        var p models.Peraturan
+        if err := h.DB.ScanRows(rows, &p); err != nil {
+            c.Error(err)
+            return
+        }
+        p.SetKategori(kategoris[p.ID])
+        if err := writeRow(&p); err != nil {
+            c.Error(err) // Header sudah terkirim, hanya bisa dicatat
+            return
+        }
+    }
+    if err := finish(); err != nil {
+        c.Error(err)
+    }
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+    feedTitle        = "SIKep - Peraturan Terbaru"
+    feedDefaultLimit = 50
+    // sitemapLimit adalah jumlah URL maksimum per file sitemap menurut protokol sitemaps.org
+    sitemapLimit = 50000
+)
+
+// feedAksi adalah aksi revisi yang dimuat di feed: peraturan baru dan perubahannya
+var feedAksi = []string{models.RevisiCreate, models.RevisiBaseline, models.RevisiUpdate, models.RevisiRollback, models.RevisiStatus}
+
+// feedEntry adalah satu item feed yang dipakai bersama oleh Atom dan RSS
+type feedEntry struct {
+    ID         string
+    Title      string
+    Link       string
+    Summary    string
+    Categories []string
+    Updated    time.Time
+}
+
+type atomFeed struct {
+    XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+    Title   string      `xml:"title"`
+    ID      string      `xml:"id"`
+    Updated string      `xml:"updated"`
+    Links   []atomLink  `xml:"link"`
+    Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+    Href string `xml:"href,attr"`
+    Rel  string `xml:"rel,attr,omitempty"`
+    Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+    Title      string         `xml:"title"`
+    ID         string         `xml:"id"`
+    Updated    string         `xml:"updated"`
+    Link       atomLink       `xml:"link"`
+    Summary    string         `xml:"summary"`
+    Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+    Term string `xml:"term,attr"`
+}
+
+type rssFeed struct {
+    XMLName xml.Name   `xml:"rss"`
+    Version string     `xml:"version,attr"`
+    Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+    Title         string    `xml:"title"`
+    Link          string    `xml:"link"`
+    Description   string    `xml:"description"`
+    Language      string    `xml:"language"`
+    LastBuildDate string    `xml:"lastBuildDate"`
+    Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+    Title       string   `xml:"title"`
+    Link        string   `xml:"link"`
+    GUID        rssGUID  `xml:"guid"`
+    PubDate     string   `xml:"pubDate"`
+    Description string   `xml:"description"`
+    Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+    Value       string `xml:",chardata"`
+    IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type sitemapURLSet struct {
+    XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+    URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+    Loc     string `xml:"loc"`
+    LastMod string `xml:"lastmod"`
+}
+
+type sitemapIndex struct {
+    XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+    Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// peraturanLabel menghasilkan label singkat seperti "PERMEN Nomor 1 Tahun 2023"
+func peraturanLabel(jenis, nomor string, tanggal time.Time) string {
+    label := fmt.Sprintf("Nomor %s Tahun %d", nomor, tanggal.Year())
+    if singkatan := jenisNama(jenis).Singkatan; singkatan != "" {
+        label = singkatan + " " + label
+    }
+    return label
+}
+
+// revisiFeedEntry menyusun item feed dari snapshot revisi, sehingga isi item sesuai
+// keadaan peraturan saat perubahan itu terjadi
+func revisiFeedEntry(r *models.PeraturanRevisi, baseURL string) feedEntry {
+    label := peraturanLabel(r.JenisPeraturan, r.Nomor, r.TanggalDitetapkan)
+    title := "Peraturan baru: " + label
+    switch r.Aksi {
+    case models.RevisiUpdate, models.RevisiRollback:
+        title = "Peraturan diperbarui: " + label
+    case models.RevisiStatus:
+        title = fmt.Sprintf("Status %s menjadi %s", label, r.Status)
+    }
+
+    summary := []string{r.Judul, "Instansi: " + r.InstansiPembuat, "Ditetapkan: " + r.TanggalDitetapkan.Format("02-01-2006")}
+    if r.Catatan != "" {
+        summary = append(summary, "Catatan: "+r.Catatan)
+    }
+    if r.FileChanged && r.Aksi != models.RevisiCreate && r.Aksi != models.RevisiBaseline {
+        summary = append(summary, "File peraturan diganti dengan versi baru.")
+    }
+
+    categories, _ := parseKategoriNames(r.Kategori)
+    categories = append([]string{jenisNama(r.JenisPeraturan).Nama}, categories...)
+
+    return feedEntry{
+        ID:         fmt.Sprintf("%s/api/peraturan/%d#revisi-%d", baseURL, r.PeraturanID, r.Revisi),
+        Title:      title,
+        Link:       fmt.Sprintf("%s/api/peraturan/file/%d", baseURL, r.PeraturanID),
+        Summary:    strings.Join(summary, "\n"),
+        Categories: categories,
+        Updated:    r.CreatedAt,
+    }
+}
+
+// GetPeraturanFeed - Feed Atom (default) atau RSS (?format=rss) berisi peraturan baru dan
+// perubahannya, terbaru dulu. Mendukung filter kategori/jenis/instansi seperti GetPeraturanWithFilters.
+func (h *PeraturanHandler) GetPeraturanFeed(c *gin.Context) {
+    format := c.DefaultQuery("format", "atom")
+    if format != "atom" && format != "rss" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "format must be atom or rss"})
+        return
+    }
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(feedDefaultLimit)))
+    if err != nil || limit < 1 {
+        limit = feedDefaultLimit
+    }
+    if limit > maxPageLimit {
+        limit = maxPageLimit
+    }
+
+    filter, err := parsePeraturanFilter(c, h.DB)
+    if err != nil {
+        filterError(c, err)
+        return
+    }
+    // Feed dibaca tanpa login dan bisa di-cache, jadi hanya berisi peraturan publik
+    filter.viewer = nil
+    peraturanIDs := filter.apply(h.DB, h.DB.Model(&models.Peraturan{}), "").Select("peraturans.id")
+
+    var revisions []models.PeraturanRevisi
+    if err := h.DB.Where("peraturan_id IN (?) AND aksi IN ?", peraturanIDs, feedAksi).
+        Order("created_at desc").Order("id desc").Limit(limit).
+        Find(&revisions).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturan feed"})
+        return
+    }
+
+    updated := time.Unix(0, 0).UTC()
+    if len(revisions) > 0 {
+        updated = revisions[0].CreatedAt
+    }
+    // Feed reader biasanya polling berkala; 304 jika tidak ada perubahan sejak polling terakhir
+    if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !updated.Truncate(time.Second).After(since) {
+        c.Status(http.StatusNotModified)
+        return
+    }
+
+    baseURL := publicBaseURL()
+    selfURL := baseURL + c.Request.URL.RequestURI()
+    entries := make([]feedEntry, 0, len(revisions))
+    for i := range revisions {
+        entries = append(entries, revisiFeedEntry(&revisions[i], baseURL))
+    }
+
+    c.Header("Last-Modified", updated.UTC().Format(http.TimeFormat))
+    c.Header("Cache-Control", "public, max-age=300")
+
+    var doc interface{}
+    contentType := "application/atom+xml; charset=utf-8"
+    if format == "rss" {
+        contentType = "application/rss+xml; charset=utf-8"
+        channel := rssChannel{
+            Title:         feedTitle,
+            Link:          baseURL + "/api/peraturan",
+            Description:   "Peraturan baru dan perubahan peraturan",
+            Language:      "id",
+            LastBuildDate: updated.Format(time.RFC1123Z),
+        }
+        for _, e := range entries {
+            channel.Items = append(channel.Items, rssItem{
+                Title:       e.Title,
+                Link:        e.Link,
+                GUID:        rssGUID{Value: e.ID},
+                PubDate:     e.Updated.Format(time.RFC1123Z),
+                Description: e.Summary,
+                Categories:  e.Categories,
+            })
+        }
+        doc = rssFeed{Version: "2.0", Channel: channel}
+    } else {
+        feed := atomFeed{
+            Title:   feedTitle,
+            ID:      selfURL,
+            Updated: updated.Format(time.RFC3339),
+            Links: []atomLink{
+                {Href: selfURL, Rel: "self", Type: "application/atom+xml"},
+                {Href: baseURL + "/api/peraturan", Rel: "alternate", Type: "application/json"},
+            },
+        }
+        for _, e := range entries {
+            entry := atomEntry{
+                Title:   e.Title,
+                ID:      e.ID,
+                Updated: e.Updated.Format(time.RFC3339),
+                Link:    atomLink{Href: e.Link, Rel: "alternate"},
+                Summary: e.Summary,
+            }
+            for _, term := range e.Categories {
+                entry.Categories = append(entry.Categories, atomCategory{Term: term})
+            }
+            feed.Entries = append(feed.Entries, entry)
+        }
+        doc = feed
+    }
+
+    writeXML(c, contentType, doc)
+}
+
+// GetSitemap - Sitemap XML berisi halaman dokumen peraturan yang memiliki file. Jika lebih dari
+// sitemapLimit URL, tanpa ?page dikembalikan sitemap index yang menunjuk ke tiap halaman.
+// Hanya peraturan publik yang dicantumkan.
+func (h *PeraturanHandler) GetSitemap(c *gin.Context) {
+    query := scopeVisible(h.DB, h.DB.Model(&models.Peraturan{}), nil).Where("peraturans.path_file <> ''")
+
+    var total int64
+    if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count peraturans"})
+        return
+    }
+
+    baseURL := publicBaseURL()
+    page, _ := strconv.Atoi(c.Query("page"))
+    if page < 1 && total > sitemapLimit {
+        index := sitemapIndex{}
+        for i := int64(1); (i-1)*sitemapLimit < total; i++ {
+            index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: fmt.Sprintf("%s/api/peraturan/sitemap.xml?page=%d", baseURL, i)})
+        }
+        writeXML(c, "application/xml; charset=utf-8", index)
+        return
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    // lastmod = waktu revisi terakhir (perubahan metadata, file, atau status)
+    type sitemapRow struct {
+        ID        uint
+        UpdatedAt time.Time
+    }
+    var rows []sitemapRow
+    if err := query.Select("peraturans.id, COALESCE((SELECT MAX(r.created_at) FROM peraturan_revisi r WHERE r.peraturan_id = peraturans.id), peraturans.created_at) AS updated_at").
+        Order("peraturans.id asc").Offset((page - 1) * sitemapLimit).Limit(sitemapLimit).
+        Scan(&rows).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
+        return
+    }
+
+    urlset := sitemapURLSet{URLs: make([]sitemapURL, 0, len(rows))}
+    for _, row := range rows {
+        urlset.URLs = append(urlset.URLs, sitemapURL{
+            Loc:     fmt.Sprintf("%s/api/peraturan/file/%d", baseURL, row.ID),
+            LastMod: row.UpdatedAt.Format("2006-01-02"),
+        })
+    }
+    c.Header("Cache-Control", "public, max-age=3600")
+    writeXML(c, "application/xml; charset=utf-8", urlset)
+}
+
+// writeXML menulis dokumen XML dengan deklarasi <?xml?>
+func writeXML(c *gin.Context, contentType string, doc interface{}) {
+    data, err := xml.MarshalIndent(doc, "", "  ")
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode XML"})
+        return
+    }
+    c.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"backend/models"
+	"backend/storage"
+	"backend/upload"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// acceptedFile adalah file upload yang lolos validasi dan sudah tersimpan di storage
+type acceptedFile struct {
+    storage.Object
+    Type     upload.FileType
+    NamaFile string
+}
+
+// infectedError dikembalikan jika scanner mendeteksi malware
+type infectedError struct {
+    Signature string
+}
+
+func (e *infectedError) Error() string {
+    return "malware detected: " + e.Signature
+}
+
+// scanUnavailableError dikembalikan jika scanner dikonfigurasi tapi tidak bisa dihubungi
+type scanUnavailableError struct {
+    err error
+}
+
+func (e *scanUnavailableError) Error() string {
+    return "virus scanner unavailable: " + e.err.Error()
+}
+
+// limitUploadBody membatasi ukuran body request sebelum multipart form di-parse
+func (h *PeraturanHandler) limitUploadBody(c *gin.Context) {
+    // Tambahan 1MB untuk field form lain dan overhead multipart
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxUploadSize+(1<<20))
+}
+
+// acceptUpload memvalidasi ukuran, tipe (magic bytes), dan malware sebelum file disimpan
+func (h *PeraturanHandler) acceptUpload(c *gin.Context, header *multipart.FileHeader) (acceptedFile, error) {
+    if header.Size > h.MaxUploadSize {
+        return acceptedFile{}, upload.ErrTooLarge
+    }
+
+    file, err := header.Open()
+    if err != nil {
+        return acceptedFile{}, err
+    }
+    defer file.Close()
+
+    fileType, err := upload.Sniff(file, header.Size)
+    if err != nil {
+        return acceptedFile{}, err
+    }
+    namaFile := upload.SanitizeFilename(header.Filename, fileType)
+
+    // Scan sambil menghitung hash untuk keperluan karantina
+    hasher := sha256.New()
+    result, err := h.Scanner.Scan(c.Request.Context(), io.TeeReader(io.NewSectionReader(file, 0, header.Size), hasher))
+    if err != nil {
+        return acceptedFile{}, &scanUnavailableError{err: err}
+    }
+    if result.Infected {
+        h.quarantine(c.Request.Context(), file, header.Size, hex.EncodeToString(hasher.Sum(nil)), namaFile, result.Signature, currentUserID(c))
+        return acceptedFile{}, &infectedError{Signature: result.Signature}
+    }
+
+    obj, err := storage.PutContent(c.Request.Context(), h.Storage, io.NewSectionReader(file, 0, header.Size), fileType.ContentType)
+    if err != nil {
+        return acceptedFile{}, err
+    }
+    return acceptedFile{Object: obj, Type: fileType, NamaFile: namaFile}, nil
+}
+
+// quarantine menyimpan file terinfeksi di luar area publik untuk ditinjau admin
+func (h *PeraturanHandler) quarantine(ctx context.Context, file io.ReaderAt, size int64, hash, namaFile, signature string, uploadedBy *int64) {
+    key := "quarantine/" + hash
+    if err := h.Storage.Put(ctx, key, io.NewSectionReader(file, 0, size), size, "application/octet-stream"); err != nil {
+        log.Printf("WARNING: Failed to store quarantined file %s: %v", namaFile, err)
+        key = ""
+    }
+
+    record := models.UploadQuarantine{
+        NamaFile:   namaFile,
+        FileHash:   hash,
+        FileSize:   size,
+        StorageKey: key,
+        Signature:  signature,
+        UploadedBy: uploadedBy,
+    }
+    if err := h.DB.Create(&record).Error; err != nil {
+        log.Printf("WARNING: Failed to record quarantined file %s: %v", namaFile, err)
+    }
+    log.Printf("WARNING: Upload %s quarantined (%s)", namaFile, signature)
+}
+
+// currentUserID mengembalikan ID user yang login (diset AuthMiddleware), nil jika anonim
+func currentUserID(c *gin.Context) *int64 {
+    if user, ok := c.Get("user"); ok {
+        if userModel, ok := user.(models.User); ok {
+            return &userModel.ID
+        }
+    }
+    return nil
+}
+
+// isBodyTooLarge mengecek apakah parsing form gagal karena melewati limitUploadBody
+func isBodyTooLarge(err error) bool {
+    var maxBytesErr *http.MaxBytesError
+    return errors.As(err, &maxBytesErr)
+}
+
+// respondUploadError memetakan error validasi upload ke status HTTP yang sesuai
+func respondUploadError(c *gin.Context, err error) {
+    var infected *infectedError
+    var unavailable *scanUnavailableError
+
+    switch {
+    case errors.Is(err, upload.ErrTooLarge), isBodyTooLarge(err):
+        c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": upload.ErrTooLarge.Error()})
+    case errors.Is(err, upload.ErrTypeNotAllowed):
+        c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+    case errors.As(err, &infected):
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "File rejected: malware detected", "signature": infected.Signature})
+    case errors.As(err, &unavailable):
+        c.JSON(http.StatusServiceUnavailable, gin.H{"error": "File could not be scanned, please try again later"})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file: " + err.Error()})
+    }
+}
+
+// errFileReleased: objek hasil deduplikasi dihapus releaseFile sebelum record yang merujuknya tersimpan
+var errFileReleased = errors.New("uploaded file was removed concurrently, please upload it again")
+
+// lockFile mengambil advisory lock per key storage sampai transaksi selesai, sehingga
+// pengecekan referensi di releaseFile dan penyimpanan record baru untuk key yang sama berurutan
+func lockFile(tx *gorm.DB, key string) error {
+    return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error
+}
+
+// claimFile dipanggil di transaksi yang menyimpan record dengan key storage baru: objek
+// harus masih ada setelah lock didapat, karena releaseFile lain bisa saja baru menghapusnya
+func (h *PeraturanHandler) claimFile(tx *gorm.DB, key string) error {
+    if err := lockFile(tx, key); err != nil {
+        return err
+    }
+    if _, err := h.Storage.Stat(context.Background(), key); err != nil {
+        if errors.Is(err, storage.ErrNotFound) {
+            return errFileReleased
+        }
+        return err
+    }
+    return nil
+}
+
+// releaseFile menghapus objek dari storage jika sudah tidak dirujuk peraturan maupun revisi mana pun.
+// Karena file dideduplikasi, satu objek bisa dipakai beberapa record; file versi lama tetap
+// disimpan selama riwayat revisinya ada. Pengecekan dan penghapusan berjalan di bawah lockFile.
+func (h *PeraturanHandler) releaseFile(key string) {
+    if key == "" {
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := lockFile(tx, key); err != nil {
+            return err
+        }
+        for _, model := range []interface{}{&models.Peraturan{}, &models.PeraturanRevisi{}} {
+            var refs int64
+            if err := tx.Model(model).Where("path_file = ?", key).Count(&refs).Error; err != nil {
+                return err
+            }
+            if refs > 0 {
+                return nil
+            }
+        }
+
+        if err := h.Storage.Delete(context.Background(), key); err != nil {
+            // Log error tapi tidak return error ke client karena database sudah berhasil diupdate
+            log.Printf("WARNING: Failed to delete file %s: %v", key, err)
+        } else {
+            log.Printf("INFO: File deleted successfully: %s", key)
+        }
+        return nil
+    })
+    if err != nil {
+        log.Printf("WARNING: Failed to check file references for %s: %v", key, err)
+    }
+}
+
+// contentDisposition membuat header Content-Disposition dengan nama file yang di-quote/encode
+// sesuai RFC 2231, sehingga nama berisi tanda kutip, CRLF, atau huruf non-ASCII tetap aman
+func contentDisposition(disposition, filename string) string {
+    if value := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); value != "" {
+        return value
+    }
+    return disposition
+}
+
+// fileCacheControl: browser boleh menyimpan file tetapi wajib revalidasi dengan ETag,
+// karena isi file untuk ID yang sama bisa berganti saat peraturan diupdate
+const fileCacheControl = "private, no-cache"
+
+// serveFile mengirim file peraturan dengan dukungan Range, ETag kuat dari hash isi,
+// dan conditional GET (If-None-Match / If-Modified-Since -> 304) lewat http.ServeContent
+func (h *PeraturanHandler) serveFile(c *gin.Context, peraturan *models.Peraturan, disposition, contentType string) {
+    reader, err := storage.Open(c.Request.Context(), h.Storage, peraturan.PathFile)
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "File not found on server"})
+        return
+    }
+    defer reader.Close()
+
+    if peraturan.FileHash != "" {
+        c.Header("ETag", strconv.Quote(peraturan.FileHash))
+    }
+    c.Header("Cache-Control", fileCacheControl)
+    c.Header("Content-Type", contentType)
+    c.Header("Content-Disposition", contentDisposition(disposition, peraturan.NamaFile))
+    c.Header("X-Content-Type-Options", "nosniff")
+
+    http.ServeContent(c.Writer, c.Request, peraturan.NamaFile, reader.Info().ModTime, reader)
+}
+
+// GetQuarantine - Daftar upload yang dikarantina karena malware
+func (h *PeraturanHandler) GetQuarantine(c *gin.Context) {
+    var records []models.UploadQuarantine
+    if err := h.DB.Order("created_at desc").Find(&records).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quarantine"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": records})
+}
+
+// DeleteQuarantine - Menghapus file karantina secara permanen
+func (h *PeraturanHandler) DeleteQuarantine(c *gin.Context) {
+    var record models.UploadQuarantine
+    if err := h.DB.First(&record, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Quarantine record not found"})
+        return
+    }
+
+    if record.StorageKey != "" {
+        if err := h.Storage.Delete(c.Request.Context(), record.StorageKey); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quarantined file: " + err.Error()})
+            return
+        }
+    }
+    if err := h.DB.Delete(&record).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete quarantine record"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Quarantined file deleted successfully"})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/models"
+	"backend/search"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Nama facet sekaligus nama filter yang dilewati saat facet tersebut dihitung
+const (
+    facetJenis    = "jenis"
+    facetKategori = "kategori"
+    facetInstansi = "instansi"
+    facetTahun    = "tahun"
+)
+
+const maxPageLimit = 100
+
+// sortColumns adalah kolom yang boleh dipakai untuk sort dari query param
+var sortColumns = map[string]string{
+    "tanggal_ditetapkan": "tanggal_ditetapkan",
+    "nomor":              "nomor",
+    "judul":              "judul",
+    "created_at":         "created_at",
+}
+
+// peraturanFilter menampung parameter yang sama dengan GetPeraturanWithFilters
+// agar bisa dipakai ulang oleh endpoint lain (listing, facet, export, dll)
+type peraturanFilter struct {
+    Search      string
+    Jenis       string
+    Kategori    string
+    Instansi    string
+    Tahun       int // 0 = semua tahun
+    Status      string
+    HideRevoked bool
+
+    kategoriIDs []uint
+    viewer      *models.User // Hasil selalu dibatasi pada peraturan yang boleh dilihat viewer
+}
+
+type FacetCount struct {
+    ID    *uint  `json:"id,omitempty"`
+    Value string `json:"value"`
+    Count int64  `json:"count"`
+}
+
+type Pagination struct {
+    Page       int   `json:"page"`
+    Limit      int   `json:"limit"`
+    Total      int64 `json:"total"`
+    TotalPages int   `json:"total_pages"`
+}
+
+var errInvalidTahun = errors.New("tahun must be a year, e.g. 2024")
+
+// filterError mengirim response untuk error dari parsePeraturanFilter
+func filterError(c *gin.Context, err error) {
+    if errors.Is(err, errInvalidTahun) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kategori"})
+}
+
+// parsePeraturanFilter membaca filter dari query string
+func parsePeraturanFilter(c *gin.Context, db *gorm.DB) (*peraturanFilter, error) {
+    f := &peraturanFilter{
+        Search:      c.Query("search"),
+        Jenis:       c.Query("jenis"),
+        Kategori:    c.Query("kategori"),
+        Instansi:    c.Query("instansi"),
+        Status:      c.Query("status"),
+        HideRevoked: c.Query("hide_revoked") == "true",
+        viewer:      viewerFromContext(c),
+    }
+
+    if tahun := strings.TrimSpace(c.Query("tahun")); tahun != "" {
+        year, err := strconv.Atoi(tahun)
+        if err != nil || year < 1 || year > 9999 {
+            return nil, errInvalidTahun
+        }
+        f.Tahun = year
+    }
+
+    // Kategori dicocokkan persis (nama atau id) termasuk sub-kategorinya
+    if f.Kategori != "" {
+        ids, err := kategoriSubtreeIDs(db, f.Kategori)
+        if err != nil {
+            return nil, err
+        }
+        f.kategoriIDs = ids
+    }
+    return f, nil
+}
+
+// apply menambahkan kondisi filter ke query. skip dipakai saat menghitung facet
+// supaya pilihan pada facet itu sendiri tidak menyembunyikan opsi lainnya.
+func (f *peraturanFilter) apply(db *gorm.DB, query *gorm.DB, skip string) *gorm.DB {
+    query = scopeVisible(db, query, f.viewer)
+
+    if f.Search != "" {
+        searchPattern := "%" + f.Search + "%"
+        query = query.Where(
+            "peraturans.judul LIKE ? OR peraturans.nomor LIKE ? OR peraturans.instansi_pembuat LIKE ? OR peraturans.id IN (?)",
+            searchPattern, searchPattern, searchPattern, search.MatchingIDs(db, f.Search),
+        )
+    }
+
+    if f.Jenis != "" && skip != facetJenis {
+        query = query.Where("peraturans.jenis_peraturan = ?", f.Jenis)
+    }
+
+    if f.Kategori != "" && skip != facetKategori {
+        query = query.Where("peraturans.id IN (?)", db.Table("peraturan_kategori").Select("peraturan_id").Where("kategori_id IN ?", f.kategoriIDs))
+    }
+
+    if f.Instansi != "" && skip != facetInstansi {
+        query = query.Where("peraturans.instansi_pembuat = ?", f.Instansi)
+    }
+
+    if f.Tahun != 0 && skip != facetTahun {
+        query = query.Where("EXTRACT(YEAR FROM peraturans.tanggal_ditetapkan) = ?", f.Tahun)
+    }
+
+    if f.Status != "" {
+        query = query.Where("peraturans.status = ?", f.Status)
+    }
+
+    // Sembunyikan peraturan yang sudah dicabut
+    if f.HideRevoked {
+        query = query.Where("peraturans.status <> ?", models.StatusDicabut)
+    }
+    return query
+}
+
+// facets menghitung jumlah peraturan per jenis, kategori, instansi, dan tahun
+func (f *peraturanFilter) facets(db *gorm.DB) (map[string][]FacetCount, error) {
+    result := map[string][]FacetCount{}
+
+    groupBy := map[string]string{
+        facetJenis:    "peraturans.jenis_peraturan",
+        facetInstansi: "peraturans.instansi_pembuat",
+        facetTahun:    "CAST(EXTRACT(YEAR FROM peraturans.tanggal_ditetapkan) AS INTEGER)",
+    }
+    for name, expr := range groupBy {
+        counts := []FacetCount{}
+        query := f.apply(db, db.Model(&models.Peraturan{}), name)
+        if err := query.Select(expr + " AS value, COUNT(*) AS count").
+            Group("value").
+            Order("count DESC, value ASC").
+            Scan(&counts).Error; err != nil {
+            return nil, err
+        }
+        result[name] = counts
+    }
+
+    kategoriCounts := []FacetCount{}
+    query := f.apply(db, db.Model(&models.Peraturan{}), facetKategori)
+    if err := query.Joins("JOIN peraturan_kategori pk ON pk.peraturan_id = peraturans.id").
+        Joins("JOIN kategori k ON k.id = pk.kategori_id").
+        Select("k.id AS id, k.nama AS value, COUNT(DISTINCT peraturans.id) AS count").
+        Group("k.id, k.nama").
+        Order("count DESC, value ASC").
+        Scan(&kategoriCounts).Error; err != nil {
+        return nil, err
+    }
+    result[facetKategori] = kategoriCounts
+
+    return result, nil
+}
+
+// paginate menerapkan sort dan offset pagination. Pagination hanya aktif jika client
+// mengirim page atau limit; tanpa itu seluruh data dikembalikan seperti sebelumnya.
+func paginate(c *gin.Context, query *gorm.DB) (*gorm.DB, *Pagination, error) {
+    var pagination *Pagination
+    if c.Query("page") != "" || c.Query("limit") != "" {
+        page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+        if err != nil || page < 1 {
+            page = 1
+        }
+        limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+        if err != nil || limit < 1 {
+            limit = 20
+        }
+        if limit > maxPageLimit {
+            limit = maxPageLimit
+        }
+
+        var total int64
+        if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+            return nil, nil, err
+        }
+        pagination = &Pagination{
+            Page:       page,
+            Limit:      limit,
+            Total:      total,
+            TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+        }
+        query = query.Offset((page - 1) * limit).Limit(limit)
+    }
+
+    return applySort(c, query), pagination, nil
+}
+
+// applySort menerapkan sort/order dari query string (whitelist kolom)
+func applySort(c *gin.Context, query *gorm.DB) *gorm.DB {
+    column, ok := sortColumns[c.DefaultQuery("sort", "tanggal_ditetapkan")]
+    if !ok {
+        column = "tanggal_ditetapkan"
+    }
+    direction := "DESC"
+    if c.Query("order") == "asc" {
+        direction = "ASC"
+    }
+    // id sebagai tie-breaker agar urutan antar halaman stabil
+    return query.Order("peraturans." + column + " " + direction).Order("peraturans.id " + direction)
+}
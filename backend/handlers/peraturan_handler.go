@@ -2,28 +2,43 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"backend/analytics"
 	"backend/models"
+	"backend/notify"
+	"backend/search"
+	"backend/sharelink"
+	"backend/storage"
+	"backend/upload"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type PeraturanHandler struct {
-    DB *gorm.DB
+    DB            *gorm.DB
+    Storage       storage.Storage
+    Scanner       upload.Scanner
+    MaxUploadSize int64
+    Analytics     *analytics.Recorder
+    Notifier      *notify.Notifier
+    ShareLinks    *sharelink.Signer
 }
 
 // CreatePeraturan - Handler untuk membuat peraturan baru
 func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
     // Parse multipart form
+    h.limitUploadBody(c)
     if err := c.Request.ParseMultipartForm(32 << 20); err != nil { // 32MB max memory
+        if isBodyTooLarge(err) {
+            respondUploadError(c, err)
+            return
+        }
         c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data: " + err.Error()})
         return
     }
@@ -36,6 +51,7 @@ func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
     jenisPeraturan := c.PostForm("jenis_peraturan")
     kategoriStr := c.PostForm("kategori")
     keterangan := c.PostForm("keterangan")
+    watermark := c.PostForm("watermark") == "true"
 
     // Parse date
     tanggalDitetapkan, err := time.Parse("2006-01-02", tanggalDitetapkanStr)
@@ -44,6 +60,13 @@ func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
         return
     }
 
+    // Visibilitas default publik; terbatas wajib menyertakan akses_role dan/atau akses_unit
+    visibilitas, izin, err := parseVisibilitas(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
     // Get uploaded file
     file, header, err := c.Request.FormFile("file")
     if err != nil {
@@ -52,29 +75,40 @@ func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
     }
     defer file.Close()
 
-    // Create directory if not exists
-    uploadDir := "./uploads/peraturan"
-    if err := os.MkdirAll(uploadDir, 0755); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory: " + err.Error()})
+    // Validasi (ukuran, magic bytes, malware) lalu simpan ke storage (key berdasarkan SHA-256 isi file)
+    stored, err := h.acceptUpload(c, header)
+    if err != nil {
+        respondUploadError(c, err)
         return
     }
-
-    // Create unique filename to avoid conflicts
-    timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-    filename := timestamp + "_" + header.Filename
-    filePath := filepath.Join(uploadDir, filename)
-    
-    // Get absolute path
-    absolutePath, err := filepath.Abs(filePath)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get absolute path: " + err.Error()})
+    if watermark && stored.Type != upload.TypePDF {
+        h.releaseFile(stored.Key)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Watermark is only supported for PDF files"})
         return
     }
 
-    // Save the uploaded file
-    if err := c.SaveUploadedFile(header, filePath); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file: " + err.Error()})
-        return
+    // Tolak kemungkinan peraturan ganda kecuali admin sengaja menyimpan (allow_duplicates=true)
+    if c.PostForm("allow_duplicates") != "true" {
+        duplicates, err := findDuplicates(h.DB, duplicateCandidate{
+            Nomor:    nomor,
+            Jenis:    jenisPeraturan,
+            Tahun:    tanggalDitetapkan.Year(),
+            Judul:    judul,
+            FileHash: stored.Hash,
+        })
+        if err != nil {
+            h.releaseFile(stored.Key)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check duplicates: " + err.Error()})
+            return
+        }
+        if len(duplicates) > 0 {
+            h.releaseFile(stored.Key)
+            c.JSON(http.StatusConflict, gin.H{
+                "error":      "Peraturan may already exist, resend with allow_duplicates=true to save anyway",
+                "duplicates": duplicates,
+            })
+            return
+        }
     }
 
     // Parse kategori (handle multiple kategori)
@@ -83,6 +117,7 @@ func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
         // Jika kategori adalah JSON string (dari frontend)
         if strings.HasPrefix(kategoriStr, "[") {
             if err := json.Unmarshal([]byte(kategoriStr), &kategoriArray); err != nil {
+                h.releaseFile(stored.Key)
                 c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kategori format: " + err.Error()})
                 return
             }
@@ -92,9 +127,10 @@ func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
         }
     }
 
-    // Convert kategori array to JSON string for storage
-    kategoriJSON, err := json.Marshal(kategoriArray)
+    // Cari atau buat kategori di tabel kategori
+    kategoriItems, err := resolveKategori(h.DB, kategoriArray)
     if err != nil {
+        h.releaseFile(stored.Key)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process kategori: " + err.Error()})
         return
     }
@@ -106,39 +142,66 @@ func (h *PeraturanHandler) CreatePeraturan(c *gin.Context) {
         Judul:           judul,
         InstansiPembuat: instansiPembuat,
         JenisPeraturan:  jenisPeraturan,
-        Kategori:        string(kategoriJSON),
-        NamaFile:        header.Filename,
-        PathFile:        absolutePath, // Simpan absolute path
+        NamaFile:        stored.NamaFile,
+        PathFile:        stored.Key, // Key storage, bukan path di disk
+        FileHash:        stored.Hash,
+        FileSize:        stored.Size,
+        ContentType:     stored.Type.ContentType,
         Keterangan:      keterangan,
+        Status:          models.StatusBerlaku,
+        Watermark:       watermark,
+        Visibilitas:     visibilitas,
+        Izin:            izin,
         CreatedAt:       time.Now(),
     }
+    peraturan.SetKategori(kategoriItems)
 
-    // Save to database
-    if err := h.DB.Create(&peraturan).Error; err != nil {
+    // Save to database beserta revisi pertama
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := h.claimFile(tx, stored.Key); err != nil {
+            return err
+        }
+        if err := tx.Create(&peraturan).Error; err != nil {
+            return err
+        }
+        _, err := recordRevisi(tx, &peraturan, models.RevisiCreate, "", currentUserID(c))
+        return err
+    })
+    if err != nil {
         // Hapus file yang sudah diupload jika database gagal
-        os.Remove(filePath)
+        h.releaseFile(stored.Key)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save peraturan to database: " + err.Error()})
         return
     }
 
+    h.indexInBackground(peraturan)
+    h.Notifier.PeraturanChanged(peraturan, models.NotifikasiBaru, true, currentUserID(c))
+
     c.JSON(http.StatusCreated, gin.H{
         "message": "Peraturan created successfully",
         "data":    peraturan,
     })
 }
 
-// GetPeraturan - Handler untuk mendapatkan semua peraturan
+// GetPeraturan - Handler untuk mendapatkan semua peraturan (opsional dengan page/limit/sort)
 func (h *PeraturanHandler) GetPeraturan(c *gin.Context) {
     var peraturans []models.Peraturan
+
+    query, pagination, err := paginate(c, scopeVisible(h.DB, h.DB.Model(&models.Peraturan{}), viewerFromContext(c)))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count peraturans"})
+        return
+    }
     
-    if err := h.DB.Find(&peraturans).Error; err != nil {
+    if err := query.Preload("KategoriItems").Find(&peraturans).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
         return
     }
     
     c.JSON(http.StatusOK, gin.H{
-        "message": "Peraturans fetched successfully",
-        "data":    peraturans,
+        "message":    "Peraturans fetched successfully",
+        "data":       peraturans,
+        "pagination": pagination,
     })
 }
 
@@ -152,36 +215,30 @@ func (h *PeraturanHandler) GetPeraturanFile(c *gin.Context) {
         return
     }
     
-    // Check if file exists
-    if _, err := os.Stat(peraturan.PathFile); os.IsNotExist(err) {
-        c.JSON(http.StatusNotFound, gin.H{"error": "File not found on server"})
-        return
-    }
-    
-    // Get file extension
+    // Gunakan content type hasil deteksi saat upload; record lama masih ditebak dari ekstensi
+    contentType := peraturan.ContentType
     ext := strings.ToLower(filepath.Ext(peraturan.NamaFile))
-    
-    // Set content type based on file extension
-    var contentType string
-    switch ext {
-    case ".pdf":
+    switch {
+    case contentType != "":
+    case ext == ".pdf":
         contentType = "application/pdf"
-    case ".doc":
+    case ext == ".doc":
         contentType = "application/msword"
-    case ".docx":
+    case ext == ".docx":
         contentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
     default:
         contentType = "application/octet-stream"
     }
     
-    // Set headers
-    c.Header("Content-Description", "File Transfer")
-    c.Header("Content-Transfer-Encoding", "binary")
-    c.Header("Content-Disposition", "inline; filename=\""+peraturan.NamaFile+"\"")
-    c.Header("Content-Type", contentType)
-    
-    // Serve the file
-    c.File(peraturan.PathFile)
+    if !h.authorizeFile(c, &peraturan) {
+        return
+    }
+    h.recordAkses(c, &peraturan, models.AksesView)
+    if peraturan.Watermark {
+        h.serveWatermarked(c, &peraturan, "inline")
+        return
+    }
+    h.serveFile(c, &peraturan, "inline", contentType)
 }
 
 // DownloadPeraturan - Handler untuk mendownload file peraturan
@@ -194,35 +251,36 @@ func (h *PeraturanHandler) DownloadPeraturan(c *gin.Context) {
         return
     }
     
-    // Check if file exists
-    if _, err := os.Stat(peraturan.PathFile); os.IsNotExist(err) {
-        c.JSON(http.StatusNotFound, gin.H{"error": "File not found on server"})
+    if !h.authorizeFile(c, &peraturan) {
         return
     }
-    
-    // Set headers for download
-    c.Header("Content-Description", "File Transfer")
-    c.Header("Content-Transfer-Encoding", "binary")
-    c.Header("Content-Disposition", "attachment; filename=\""+peraturan.NamaFile+"\"")
-    c.Header("Content-Type", "application/octet-stream")
-    
-    // Serve the file
-    c.File(peraturan.PathFile)
+    h.recordAkses(c, &peraturan, models.AksesDownload)
+    if peraturan.Watermark {
+        h.serveWatermarked(c, &peraturan, "attachment")
+        return
+    }
+    h.serveFile(c, &peraturan, "attachment", "application/octet-stream")
 }
 
 // GetPeraturanByID - Mendapatkan peraturan berdasarkan ID
 func (h *PeraturanHandler) GetPeraturanByID(c *gin.Context) {
-    id := c.Param("id")
-    
-    var peraturan models.Peraturan
-    if err := h.DB.First(&peraturan, id).Error; err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+    peraturan, ok := h.findVisible(c)
+    if !ok {
+        return
+    }
+
+    // Sertakan relasi dan rantai perubahan (dua arah), hanya yang boleh dilihat
+    relations, chain, err := h.loadRelasi(peraturan.ID, viewerFromContext(c))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch relasi: " + err.Error()})
         return
     }
     
     c.JSON(http.StatusOK, gin.H{
         "message": "Peraturan fetched successfully",
         "data": peraturan,
+        "relations": relations,
+        "amendment_chain": chain,
     })
 }
 
@@ -237,7 +295,12 @@ func (h *PeraturanHandler) UpdatePeraturan(c *gin.Context) {
     }
     
     // Parse multipart form
+    h.limitUploadBody(c)
     if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+        if isBodyTooLarge(err) {
+            respondUploadError(c, err)
+            return
+        }
         c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data"})
         return
     }
@@ -271,7 +334,7 @@ func (h *PeraturanHandler) UpdatePeraturan(c *gin.Context) {
         }
     }
     
-    kategoriJSON, err := json.Marshal(kategoriArray)
+    kategoriItems, err := resolveKategori(h.DB, kategoriArray)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process kategori"})
         return
@@ -283,41 +346,92 @@ func (h *PeraturanHandler) UpdatePeraturan(c *gin.Context) {
     peraturan.Judul = judul
     peraturan.InstansiPembuat = instansiPembuat
     peraturan.JenisPeraturan = jenisPeraturan
-    peraturan.Kategori = string(kategoriJSON)
     peraturan.Keterangan = keterangan
     
     // Handle file upload jika ada
+    fileReplaced := false
+    oldKey := peraturan.PathFile
     file, header, err := c.Request.FormFile("file")
     if err == nil {
         defer file.Close()
         
-        // Hapus file lama jika ada
-        if peraturan.PathFile != "" {
-            if err := os.Remove(peraturan.PathFile); err != nil {
-                log.Printf("Failed to delete old file: %v", err)
-            }
+        // Simpan file baru; file lama baru dilepas setelah database berhasil diupdate
+        stored, err := h.acceptUpload(c, header)
+        if err != nil {
+            respondUploadError(c, err)
+            return
         }
         
-        // Simpan file baru
-        uploadDir := "./uploads/peraturan"
-        timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-        filename := timestamp + "_" + header.Filename
-        filePath := filepath.Join(uploadDir, filename)
-        
-        if err := c.SaveUploadedFile(header, filePath); err != nil {
-            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save uploaded file"})
+        peraturan.NamaFile = stored.NamaFile
+        peraturan.PathFile = stored.Key
+        peraturan.FileHash = stored.Hash
+        peraturan.FileSize = stored.Size
+        peraturan.ContentType = stored.Type.ContentType
+        fileReplaced = oldKey != stored.Key
+    }
+    if v, ok := c.GetPostForm("watermark"); ok {
+        peraturan.Watermark = v == "true"
+    }
+    // Visibilitas dan izin hanya diganti jika dikirim
+    _, updateVisibilitas := c.GetPostForm("visibilitas")
+    var izin []models.PeraturanIzin
+    if updateVisibilitas {
+        peraturan.Visibilitas, izin, err = parseVisibilitas(c)
+        if err != nil {
+            if fileReplaced {
+                h.releaseFile(peraturan.PathFile)
+            }
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
             return
         }
-        
-        peraturan.NamaFile = header.Filename
-        peraturan.PathFile = filePath
+    }
+    if peraturan.Watermark && !isPDFPeraturan(&peraturan) {
+        if fileReplaced {
+            h.releaseFile(peraturan.PathFile)
+        }
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Watermark is only supported for PDF files"})
+        return
     }
     
-    // Save to database
-    if err := h.DB.Save(&peraturan).Error; err != nil {
+    // Save to database; setiap perubahan disimpan sebagai revisi sehingga file lama tetap bisa diambil
+    var revisi *models.PeraturanRevisi
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if fileReplaced {
+            if err := h.claimFile(tx, peraturan.PathFile); err != nil {
+                return err
+            }
+        }
+        if err := tx.Save(&peraturan).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&peraturan).Association("KategoriItems").Replace(kategoriItems); err != nil {
+            return err
+        }
+        peraturan.SetKategori(kategoriItems)
+        if updateVisibilitas {
+            if err := replaceIzin(tx, &peraturan, izin); err != nil {
+                return err
+            }
+        }
+        revisi, err = recordRevisi(tx, &peraturan, models.RevisiUpdate, c.PostForm("catatan_revisi"), currentUserID(c))
+        return err
+    })
+    if err != nil {
+        if fileReplaced {
+            h.releaseFile(peraturan.PathFile)
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update peraturan"})
         return
     }
+
+    if fileReplaced {
+        h.releaseFile(oldKey)
+        h.indexInBackground(peraturan)
+    }
+    // Simpan tanpa perubahan tidak perlu memberi tahu pelanggan
+    if revisi != nil {
+        h.Notifier.PeraturanChanged(peraturan, models.NotifikasiDiubah, fileReplaced, currentUserID(c))
+    }
     
     c.JSON(http.StatusOK, gin.H{
         "message": "Peraturan updated successfully",
@@ -325,7 +439,8 @@ func (h *PeraturanHandler) UpdatePeraturan(c *gin.Context) {
     })
 }
 
-// DeletePeraturan - Hapus peraturan dan file fisiknya
+// DeletePeraturan - Hapus peraturan beserta file fisiknya. Riwayat revisi tidak dihapus; penghapusan
+// dicatat sebagai revisi terakhir dan file yang masih dirujuk revisi tetap disimpan.
 func (h *PeraturanHandler) DeletePeraturan(c *gin.Context) {
     id := c.Param("id")
     
@@ -346,8 +461,8 @@ func (h *PeraturanHandler) DeletePeraturan(c *gin.Context) {
         return
     }
     
-    // Simpan path file untuk dihapus nanti
-    filePathToDelete := peraturan.PathFile
+    // Simpan key file untuk dihapus nanti
+    fileKeyToDelete := peraturan.PathFile
     
     // Mulai transaksi database
     tx := h.DB.Begin()
@@ -357,6 +472,61 @@ func (h *PeraturanHandler) DeletePeraturan(c *gin.Context) {
         }
     }()
     
+    // Snapshot dicatat sebelum kategori dan izin dilepas
+    if _, err := recordRevisi(tx, &peraturan, models.RevisiDelete, "", currentUserID(c)); err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record peraturan revision: " + err.Error()})
+        return
+    }
+
+    // Hapus indeks teks milik peraturan ini
+    if err := tx.Where("peraturan_id = ?", peraturan.ID).Delete(&models.PeraturanHalaman{}).Error; err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete peraturan index: " + err.Error()})
+        return
+    }
+    if err := search.DeletePasal(tx, peraturan.ID); err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete peraturan pasal: " + err.Error()})
+        return
+    }
+
+    // Hapus relasi; status peraturan yang sebelumnya diubah/dicabut oleh peraturan ini dihitung ulang
+    var terkaitIDs []uint
+    if err := tx.Model(&models.PeraturanRelasi{}).Where("peraturan_id = ?", peraturan.ID).Pluck("terkait_id", &terkaitIDs).Error; err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturan relasi: " + err.Error()})
+        return
+    }
+    if err := tx.Where("peraturan_id = ? OR terkait_id = ?", peraturan.ID, peraturan.ID).Delete(&models.PeraturanRelasi{}).Error; err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete peraturan relasi: " + err.Error()})
+        return
+    }
+    for _, terkaitID := range terkaitIDs {
+        if err := refreshStatus(tx, terkaitID, currentUserID(c)); err != nil {
+            tx.Rollback()
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update peraturan status: " + err.Error()})
+            return
+        }
+    }
+
+    // Lepas tag kategori
+    if err := tx.Model(&peraturan).Association("KategoriItems").Clear(); err != nil {
+        tx.Rollback()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete peraturan kategori: " + err.Error()})
+        return
+    }
+
+    // Keluarkan dari bookmark, daftar bacaan, riwayat baca, inbox notifikasi user, tautan berbagi, dan izin akses
+    for _, model := range []interface{}{&models.Bookmark{}, &models.ReadingListItem{}, &models.RiwayatBaca{}, &models.Notifikasi{}, &models.TautanBerbagi{}, &models.PeraturanIzin{}} {
+        if err := tx.Where("peraturan_id = ?", peraturan.ID).Delete(model).Error; err != nil {
+            tx.Rollback()
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete peraturan references: " + err.Error()})
+            return
+        }
+    }
+
     // Hapus record dari database
     if err := tx.Delete(&peraturan).Error; err != nil {
         tx.Rollback()
@@ -370,70 +540,59 @@ func (h *PeraturanHandler) DeletePeraturan(c *gin.Context) {
         return
     }
     
-    // Hapus file fisik setelah database commit berhasil
-    if filePathToDelete != "" {
-        if err := os.Remove(filePathToDelete); err != nil {
-            // Log error tapi tidak return error ke client karena database sudah berhasil dihapus
-            log.Printf("WARNING: Failed to delete file %s: %v", filePathToDelete, err)
-        } else {
-            log.Printf("INFO: File deleted successfully: %s", filePathToDelete)
-        }
-    }
+    // Hapus file dari storage setelah database commit berhasil (jika tidak dipakai peraturan lain
+    // atau revisi mana pun)
+    h.releaseFile(fileKeyToDelete)
     
     c.JSON(http.StatusOK, gin.H{
         "message": "Peraturan deleted successfully",
     })
 }
 
-// GetPeraturanWithFilters - Handler untuk mendapatkan peraturan dengan filter
+// GetPeraturanWithFilters - Handler untuk mendapatkan peraturan dengan filter, sort, pagination, dan facet
 func (h *PeraturanHandler) GetPeraturanWithFilters(c *gin.Context) {
     // Ambil parameter query
-    search := c.Query("search")
-    jenis := c.Query("jenis")
-    kategori := c.Query("kategori")
-    tahun := c.Query("tahun")
-    
-    // Buat query builder
-    query := h.DB.Model(&models.Peraturan{})
-    
-    // Tambahkan filter jika ada
-    if search != "" {
-        searchPattern := "%" + search + "%"
-        query = query.Where(
-            "judul LIKE ? OR nomor LIKE ? OR instansi_pembuat LIKE ?",
-            searchPattern, searchPattern, searchPattern,
-        )
-    }
-    
-    if jenis != "" {
-        query = query.Where("jenis_peraturan = ?", jenis)
-    }
-    
-    if kategori != "" {
-        query = query.Where("kategori LIKE ?", "%"+kategori+"%")
+    filter, err := parsePeraturanFilter(c, h.DB)
+    if err != nil {
+        filterError(c, err)
+        return
     }
     
-    if tahun != "" {
-        query = query.Where("EXTRACT(YEAR FROM tanggal_ditetapkan) = ?", tahun)
+    // Buat query builder
+    query := filter.apply(h.DB, h.DB.Model(&models.Peraturan{}), "")
+
+    query, pagination, err := paginate(c, query)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count peraturans"})
+        return
     }
     
     var peraturans []models.Peraturan
-    if err := query.Find(&peraturans).Error; err != nil {
+    if err := query.Preload("KategoriItems").Find(&peraturans).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch peraturans"})
         return
     }
+
+    // Jumlah per jenis/kategori/instansi/tahun untuk badge di sidebar filter
+    facets, err := filter.facets(h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch facets"})
+        return
+    }
     
     c.JSON(http.StatusOK, gin.H{
-        "message": "Peraturans fetched successfully",
-        "data":    peraturans,
+        "message":    "Peraturans fetched successfully",
+        "data":       peraturans,
+        "pagination": pagination,
+        "facets":     facets,
     })
 }
 
 
-// GetPeraturanCount mengembalikan jumlah peraturan
+// GetPeraturanCount mengembalikan jumlah peraturan yang boleh dilihat pemanggil
 func (h *PeraturanHandler) GetPeraturanCount(c *gin.Context) {
     var count int64
-    result := h.DB.Model(&models.Peraturan{}).Count(&count)
+    result := scopeVisible(h.DB, h.DB.Model(&models.Peraturan{}), viewerFromContext(c)).Count(&count)
     if result.Error != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
         return
@@ -0,0 +1,544 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/search"
+	"backend/storage"
+	"backend/upload"
+	"backend/xlsx"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Status per baris hasil import
+const (
+    ImportImported = "imported"
+    ImportValid    = "valid" // dry-run: baris lolos validasi dan akan diimport
+    ImportSkipped  = "skipped"
+    ImportFailed   = "error"
+)
+
+// importMaxArchiveSize membatasi ukuran ZIP yang diupload lewat endpoint import
+const importMaxArchiveSize = 2 << 30
+
+// importColumns memetakan nama kolom manifest (beserta alias) ke field
+var importColumns = map[string][]string{
+    "nomor":      {"nomor", "nomor_peraturan", "no"},
+    "tanggal":    {"tanggal", "tanggal_ditetapkan", "tanggal_penetapan"},
+    "judul":      {"judul", "judul_peraturan"},
+    "instansi":   {"instansi", "instansi_pembuat"},
+    "jenis":      {"jenis", "jenis_peraturan"},
+    "kategori":   {"kategori"},
+    "file":       {"file", "nama_file", "filename", "berkas"},
+    "keterangan": {"keterangan"},
+}
+
+var importRequiredColumns = []string{"nomor", "tanggal", "judul", "instansi", "jenis", "file"}
+
+// importDateLayouts adalah format tanggal yang diterima di manifest CSV
+var importDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006"}
+
+// ImportManifest adalah isi manifest CSV/XLSX: baris pertama header, sisanya data
+type ImportManifest struct {
+    Name string
+    Rows [][]string
+}
+
+type ImportOptions struct {
+    DryRun          bool
+    AllowDuplicates bool // Tetap import baris yang terdeteksi duplikat
+    UploadedBy      *int64
+}
+
+// ImportRow adalah hasil validasi/import satu baris manifest. Row mengikuti nomor baris
+// di spreadsheet (header = baris 1).
+type ImportRow struct {
+    Row            int              `json:"row"`
+    Nomor          string           `json:"nomor"`
+    JenisPeraturan string           `json:"jenis_peraturan"`
+    File           string           `json:"file"`
+    Status         string           `json:"status"`
+    Errors         []string         `json:"errors,omitempty"`
+    PeraturanID    uint             `json:"peraturan_id,omitempty"`
+    Duplicates     []DuplicateMatch `json:"duplicates,omitempty"` // Peraturan yang sudah ada dan kemungkinan sama
+}
+
+type ImportReport struct {
+    DryRun   bool        `json:"dry_run"`
+    Manifest string      `json:"manifest"`
+    Total    int         `json:"total"`
+    Imported int         `json:"imported"`
+    Valid    int         `json:"valid"`
+    Skipped  int         `json:"skipped"`
+    Failed   int         `json:"failed"`
+    Rows     []ImportRow `json:"rows"`
+}
+
+// importRecord adalah satu baris manifest yang sudah dipetakan ke nama field
+type importRecord struct {
+    row    int
+    values map[string]string
+}
+
+// importBatch melacak duplikat di dalam satu kali import
+type importBatch struct {
+    nomor  map[string]int // Key nomor+jenis+tahun yang sudah dinormalisasi
+    hashes map[string]int
+}
+
+// LoadImportManifest membaca manifest CSV (pemisah koma atau titik koma) atau XLSX
+func LoadImportManifest(name string, r io.ReaderAt, size int64) (*ImportManifest, error) {
+    switch strings.ToLower(path.Ext(name)) {
+    case ".xlsx":
+        rows, err := xlsx.ReadRows(r, size)
+        if err != nil {
+            return nil, err
+        }
+        return &ImportManifest{Name: name, Rows: rows}, nil
+    case ".csv":
+        data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+        if err != nil {
+            return nil, err
+        }
+        data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM dari Excel
+
+        reader := csv.NewReader(bytes.NewReader(data))
+        reader.FieldsPerRecord = -1
+        reader.TrimLeadingSpace = true
+        firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+        if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+            reader.Comma = ';' // Excel dengan locale Indonesia menyimpan CSV memakai titik koma
+        }
+        rows, err := reader.ReadAll()
+        if err != nil {
+            return nil, fmt.Errorf("invalid CSV manifest: %w", err)
+        }
+        return &ImportManifest{Name: name, Rows: rows}, nil
+    }
+    return nil, fmt.Errorf("manifest %s must be a .csv or .xlsx file", name)
+}
+
+// findImportManifest mencari manifest di dalam folder/ZIP: file bernama manifest.csv/.xlsx,
+// atau satu-satunya file CSV/XLSX yang ada
+func findImportManifest(fsys fs.FS) (*ImportManifest, error) {
+    var candidates []string
+    err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if d.IsDir() && strings.HasPrefix(d.Name(), "__MACOSX") {
+            return fs.SkipDir
+        }
+        ext := strings.ToLower(path.Ext(p))
+        if !d.IsDir() && (ext == ".csv" || ext == ".xlsx") && !strings.HasPrefix(d.Name(), "~$") {
+            candidates = append(candidates, p)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var chosen []string
+    for _, p := range candidates {
+        if strings.HasPrefix(strings.ToLower(path.Base(p)), "manifest.") {
+            chosen = append(chosen, p)
+        }
+    }
+    if len(chosen) == 0 {
+        chosen = candidates
+    }
+    switch len(chosen) {
+    case 0:
+        return nil, errors.New("no manifest (.csv or .xlsx) found in import source")
+    case 1:
+    default:
+        return nil, fmt.Errorf("multiple manifests found (%s), name one manifest.csv or manifest.xlsx", strings.Join(chosen, ", "))
+    }
+
+    data, err := fs.ReadFile(fsys, chosen[0])
+    if err != nil {
+        return nil, err
+    }
+    return LoadImportManifest(chosen[0], bytes.NewReader(data), int64(len(data)))
+}
+
+// records memetakan baris manifest ke nama field berdasarkan header
+func (m *ImportManifest) records() ([]importRecord, error) {
+    if len(m.Rows) == 0 {
+        return nil, errors.New("manifest is empty")
+    }
+
+    columns := map[string]int{}
+    for i, header := range m.Rows[0] {
+        normalized := strings.ToLower(strings.TrimSpace(header))
+        normalized = strings.NewReplacer(" ", "_", "-", "_").Replace(normalized)
+        for field, aliases := range importColumns {
+            for _, alias := range aliases {
+                if normalized == alias {
+                    if _, exists := columns[field]; !exists {
+                        columns[field] = i
+                    }
+                }
+            }
+        }
+    }
+    var missing []string
+    for _, field := range importRequiredColumns {
+        if _, ok := columns[field]; !ok {
+            missing = append(missing, field)
+        }
+    }
+    if len(missing) > 0 {
+        return nil, fmt.Errorf("manifest is missing column(s): %s", strings.Join(missing, ", "))
+    }
+
+    var records []importRecord
+    for i, row := range m.Rows[1:] {
+        values := map[string]string{}
+        empty := true
+        for field, col := range columns {
+            if col < len(row) {
+                values[field] = strings.TrimSpace(row[col])
+                if values[field] != "" {
+                    empty = false
+                }
+            }
+        }
+        if empty {
+            continue
+        }
+        records = append(records, importRecord{row: i + 2, values: values})
+    }
+    return records, nil
+}
+
+// parseImportDate menerima format tanggal umum dan serial tanggal Excel
+func parseImportDate(value string) (time.Time, error) {
+    for _, layout := range importDateLayouts {
+        if t, err := time.Parse(layout, value); err == nil {
+            return t, nil
+        }
+    }
+    if t, ok := xlsx.ParseDate(value); ok {
+        return t, nil
+    }
+    return time.Time{}, fmt.Errorf("invalid tanggal %q, use YYYY-MM-DD or DD/MM/YYYY", value)
+}
+
+// splitImportKategori memisahkan kategori dengan titik koma, atau koma jika tidak ada titik koma
+func splitImportKategori(value string) []string {
+    if value == "" {
+        return nil
+    }
+    if strings.HasPrefix(value, "[") {
+        if names, err := parseKategoriNames(value); err == nil {
+            return names
+        }
+    }
+    sep := ","
+    if strings.Contains(value, ";") {
+        sep = ";"
+    }
+    return strings.Split(value, sep)
+}
+
+func nomorKey(nomor, jenis string, tahun int) string {
+    return canonicalJenis(jenis) + "|" + strconv.Itoa(tahun) + "|" + normalizeNomor(nomor, jenis, tahun)
+}
+
+// RunImport memvalidasi setiap baris manifest dan (jika bukan dry-run) membuat peraturan
+// beserta filenya. Baris yang kemungkinan duplikat (nomor+jenis+tahun, hash file, atau judul
+// hampir sama) dengan peraturan yang ada atau baris sebelumnya dilewati, kecuali AllowDuplicates.
+// Error pada satu baris tidak menghentikan baris lain. Jika manifest nil, manifest dicari
+// di dalam fsys. Path file di manifest relatif terhadap folder manifest.
+func (h *PeraturanHandler) RunImport(ctx context.Context, fsys fs.FS, manifest *ImportManifest, opts ImportOptions) (*ImportReport, error) {
+    baseDir := "."
+    if manifest == nil {
+        found, err := findImportManifest(fsys)
+        if err != nil {
+            return nil, err
+        }
+        manifest = found
+        baseDir = path.Dir(found.Name)
+    }
+
+    records, err := manifest.records()
+    if err != nil {
+        return nil, err
+    }
+
+    report := &ImportReport{DryRun: opts.DryRun, Manifest: manifest.Name, Total: len(records), Rows: []ImportRow{}}
+    batch := &importBatch{nomor: map[string]int{}, hashes: map[string]int{}}
+
+    for _, record := range records {
+        result := h.importRow(ctx, fsys, baseDir, record, batch, opts)
+        switch result.Status {
+        case ImportImported:
+            report.Imported++
+        case ImportValid:
+            report.Valid++
+        case ImportSkipped:
+            report.Skipped++
+        default:
+            report.Failed++
+        }
+        report.Rows = append(report.Rows, result)
+    }
+    return report, nil
+}
+
+func (h *PeraturanHandler) importRow(ctx context.Context, fsys fs.FS, baseDir string, record importRecord, batch *importBatch, opts ImportOptions) ImportRow {
+    v := record.values
+    result := ImportRow{Row: record.row, Nomor: v["nomor"], JenisPeraturan: v["jenis"], File: v["file"]}
+    fail := func(format string, args ...interface{}) ImportRow {
+        result.Status = ImportFailed
+        result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+        return result
+    }
+
+    // Validasi field
+    for _, field := range importRequiredColumns {
+        if v[field] == "" {
+            result.Errors = append(result.Errors, field+" is required")
+        }
+    }
+    tanggal, err := parseImportDate(v["tanggal"])
+    if v["tanggal"] != "" && err != nil {
+        result.Errors = append(result.Errors, err.Error())
+    }
+    filePath := path.Join(baseDir, strings.ReplaceAll(v["file"], "\\", "/"))
+    if v["file"] != "" && !fs.ValidPath(filePath) {
+        result.Errors = append(result.Errors, "invalid file path "+v["file"])
+    }
+    if len(result.Errors) > 0 {
+        result.Status = ImportFailed
+        return result
+    }
+
+    // Duplikat nomor + jenis + tahun di dalam manifest yang sama
+    key := nomorKey(v["nomor"], v["jenis"], tanggal.Year())
+    if row, ok := batch.nomor[key]; ok && !opts.AllowDuplicates {
+        result.Status = ImportSkipped
+        result.Errors = []string{fmt.Sprintf("duplicate nomor, jenis and tahun of row %d", row)}
+        return result
+    }
+    batch.nomor[key] = record.row
+
+    // Salin file ke file sementara: hash, deteksi tipe, dan scan butuh akses acak
+    tmp, size, hash, err := h.spoolImportFile(fsys, filePath)
+    if err != nil {
+        return fail("%v", err)
+    }
+    defer os.Remove(tmp.Name())
+    defer tmp.Close()
+
+    if row, ok := batch.hashes[hash]; ok && !opts.AllowDuplicates {
+        result.Status = ImportSkipped
+        result.Errors = []string{fmt.Sprintf("same file as row %d", row)}
+        return result
+    }
+    batch.hashes[hash] = record.row
+
+    // Duplikat terhadap peraturan yang sudah ada
+    if !opts.AllowDuplicates {
+        duplicates, err := findDuplicates(h.DB, duplicateCandidate{
+            Nomor:    v["nomor"],
+            Jenis:    v["jenis"],
+            Tahun:    tanggal.Year(),
+            Judul:    v["judul"],
+            FileHash: hash,
+        })
+        if err != nil {
+            return fail("failed to check duplicates: %v", err)
+        }
+        if len(duplicates) > 0 {
+            result.Status = ImportSkipped
+            result.PeraturanID = duplicates[0].Peraturan.ID
+            result.Duplicates = duplicates
+            for _, d := range duplicates {
+                result.Errors = append(result.Errors, fmt.Sprintf("possible duplicate of %s %s (id %d): same %s",
+                    d.Peraturan.JenisPeraturan, d.Peraturan.Nomor, d.Peraturan.ID, strings.Join(d.Alasan, ", ")))
+            }
+            return result
+        }
+    }
+
+    fileType, err := upload.Sniff(tmp, size)
+    if err != nil {
+        return fail("%s: %v", v["file"], err)
+    }
+    namaFile := upload.SanitizeFilename(path.Base(filePath), fileType)
+
+    scan, err := h.Scanner.Scan(ctx, io.NewSectionReader(tmp, 0, size))
+    if err != nil {
+        return fail("%v", &scanUnavailableError{err: err})
+    }
+    if scan.Infected {
+        if !opts.DryRun {
+            h.quarantine(ctx, tmp, size, hash, namaFile, scan.Signature, opts.UploadedBy)
+        }
+        return fail("%s: %v", v["file"], &infectedError{Signature: scan.Signature})
+    }
+
+    if opts.DryRun {
+        result.Status = ImportValid
+        return result
+    }
+
+    obj, err := storage.PutContent(ctx, h.Storage, io.NewSectionReader(tmp, 0, size), fileType.ContentType)
+    if err != nil {
+        return fail("failed to store file: %v", err)
+    }
+
+    peraturan := models.Peraturan{
+        Nomor:             v["nomor"],
+        TanggalDitetapkan: tanggal,
+        Judul:             v["judul"],
+        InstansiPembuat:   v["instansi"],
+        JenisPeraturan:    v["jenis"],
+        NamaFile:          namaFile,
+        PathFile:          obj.Key,
+        FileHash:          obj.Hash,
+        FileSize:          obj.Size,
+        ContentType:       fileType.ContentType,
+        Keterangan:        v["keterangan"],
+        Status:            models.StatusBerlaku,
+        CreatedAt:         time.Now(),
+    }
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := h.claimFile(tx, obj.Key); err != nil {
+            return err
+        }
+        kategoriItems, err := resolveKategori(tx, splitImportKategori(v["kategori"]))
+        if err != nil {
+            return err
+        }
+        peraturan.SetKategori(kategoriItems)
+        if err := tx.Create(&peraturan).Error; err != nil {
+            return err
+        }
+        _, err = recordRevisi(tx, &peraturan, models.RevisiCreate, fmt.Sprintf("Import massal, baris %d", record.row), opts.UploadedBy)
+        return err
+    })
+    if err != nil {
+        h.releaseFile(obj.Key)
+        return fail("failed to save peraturan: %v", err)
+    }
+
+    h.Notifier.PeraturanChanged(peraturan, models.NotifikasiBaru, true, opts.UploadedBy)
+
+    result.Status = ImportImported
+    result.PeraturanID = peraturan.ID
+    return result
+}
+
+// spoolImportFile menyalin file dari sumber import ke file sementara sambil menghitung SHA-256
+func (h *PeraturanHandler) spoolImportFile(fsys fs.FS, name string) (*os.File, int64, string, error) {
+    src, err := fsys.Open(name)
+    if errors.Is(err, fs.ErrNotExist) {
+        return nil, 0, "", fmt.Errorf("file %s not found in import source", name)
+    }
+    if err != nil {
+        return nil, 0, "", err
+    }
+    defer src.Close()
+
+    tmp, err := os.CreateTemp("", "import-*")
+    if err != nil {
+        return nil, 0, "", err
+    }
+    hasher := sha256.New()
+    size, err := io.Copy(io.MultiWriter(tmp, hasher), io.LimitReader(src, h.MaxUploadSize+1))
+    if err == nil && size > h.MaxUploadSize {
+        err = fmt.Errorf("%s: %w", name, upload.ErrTooLarge)
+    }
+    if err != nil {
+        tmp.Close()
+        os.Remove(tmp.Name())
+        return nil, 0, "", err
+    }
+    return tmp, size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ImportPeraturan - Import massal dari ZIP berisi file dan manifest CSV/XLSX.
+// Form: archive (ZIP, wajib), manifest (opsional jika sudah ada di dalam ZIP), dry_run=true,
+// allow_duplicates=true untuk tetap mengimport baris yang terdeteksi duplikat.
+func (h *PeraturanHandler) ImportPeraturan(c *gin.Context) {
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, importMaxArchiveSize)
+    if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+        if isBodyTooLarge(err) {
+            c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import archive is too large"})
+            return
+        }
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form data: " + err.Error()})
+        return
+    }
+    dryRun := c.PostForm("dry_run") == "true" || c.Query("dry_run") == "true"
+    allowDuplicates := c.PostForm("allow_duplicates") == "true" || c.Query("allow_duplicates") == "true"
+
+    archive, archiveHeader, err := c.Request.FormFile("archive")
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "ZIP archive is required in field 'archive'"})
+        return
+    }
+    defer archive.Close()
+
+    zr, err := zip.NewReader(archive, archiveHeader.Size)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ZIP archive: " + err.Error()})
+        return
+    }
+
+    var manifest *ImportManifest
+    if file, header, err := c.Request.FormFile("manifest"); err == nil {
+        defer file.Close()
+        manifest, err = LoadImportManifest(header.Filename, file, header.Size)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    report, err := h.RunImport(c.Request.Context(), zr, manifest, ImportOptions{DryRun: dryRun, AllowDuplicates: allowDuplicates, UploadedBy: currentUserID(c)})
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if report.Imported > 0 {
+        go func() {
+            if _, err := search.Reindex(h.DB, h.Storage, false); err != nil {
+                log.Printf("WARNING: Failed to index imported peraturan: %v", err)
+            }
+        }()
+    }
+
+    message := "Import finished"
+    if dryRun {
+        message = "Dry run finished, nothing was saved"
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "message": message,
+        "data":    report,
+    })
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// pasalViewerURL adalah URL file peraturan yang langsung membuka halaman tertentu di PDF viewer
+func pasalViewerURL(peraturanID uint, halaman int) string {
+    return fmt.Sprintf("/api/peraturan/file/%d#page=%d", peraturanID, halaman)
+}
+
+// normalizePasalNomor menyamakan penulisan nomor pasal ("12 a" -> "12A")
+func normalizePasalNomor(nomor string) string {
+    return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(nomor), " ", ""))
+}
+
+// GetPasalList - Daftar isi pasal sebuah peraturan (tanpa isi pasal)
+func (h *PeraturanHandler) GetPasalList(c *gin.Context) {
+    peraturan, ok := h.findVisible(c)
+    if !ok {
+        return
+    }
+
+    var pasals []models.PeraturanPasal
+    if err := h.DB.Select("id, peraturan_id, urutan, nomor, bab, judul_bab, halaman, halaman_akhir, \"offset\"").
+        Where("peraturan_id = ?", peraturan.ID).Order("urutan asc").Find(&pasals).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pasal"})
+        return
+    }
+
+    data := make([]gin.H, 0, len(pasals))
+    for _, p := range pasals {
+        data = append(data, gin.H{
+            "nomor":         p.Nomor,
+            "bab":           p.Bab,
+            "judul_bab":     p.JudulBab,
+            "halaman":       p.Halaman,
+            "halaman_akhir": p.HalamanAkhir,
+            "url":           fmt.Sprintf("/api/peraturan/%d/pasal/%s", peraturan.ID, p.Nomor),
+            "viewer_url":    pasalViewerURL(peraturan.ID, p.Halaman),
+        })
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "data":    data,
+        "indexed": peraturan.IndexedAt != nil,
+    })
+}
+
+// GetPasal - Satu pasal berdasarkan nomor (?ayat=N untuk menunjuk ayat tertentu).
+// Jika dibuka langsung dari browser (Accept: text/html) atau dengan ?redirect=true,
+// request dialihkan ke halaman pasal di PDF viewer sehingga URL ini bisa dipakai sebagai tautan tetap.
+func (h *PeraturanHandler) GetPasal(c *gin.Context) {
+    peraturan, ok := h.findVisible(c)
+    if !ok {
+        return
+    }
+    nomor := normalizePasalNomor(c.Param("nomor"))
+
+    var pasal models.PeraturanPasal
+    err := h.DB.Preload("Ayat", func(db *gorm.DB) *gorm.DB { return db.Order("nomor asc") }).
+        Where("peraturan_id = ? AND nomor = ?", peraturan.ID, nomor).First(&pasal).Error
+    if err == gorm.ErrRecordNotFound {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Pasal " + nomor + " not found"})
+        return
+    } else if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pasal"})
+        return
+    }
+
+    halaman := pasal.Halaman
+    var ayat *models.PeraturanAyat
+    if s := c.Query("ayat"); s != "" {
+        n, err := strconv.Atoi(s)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ayat"})
+            return
+        }
+        for i := range pasal.Ayat {
+            if pasal.Ayat[i].Nomor == n {
+                ayat = &pasal.Ayat[i]
+            }
+        }
+        if ayat == nil {
+            c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Ayat (%d) not found in Pasal %s", n, nomor)})
+            return
+        }
+        halaman = ayat.Halaman
+    }
+
+    viewerURL := pasalViewerURL(pasal.PeraturanID, halaman)
+    if c.Query("redirect") == "true" || c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+        c.Redirect(http.StatusFound, viewerURL)
+        return
+    }
+
+    // Pasal sebelum/sesudah untuk navigasi
+    var prev, next models.PeraturanPasal
+    h.DB.Select("nomor").Where("peraturan_id = ? AND urutan < ?", pasal.PeraturanID, pasal.Urutan).Order("urutan desc").Limit(1).Find(&prev)
+    h.DB.Select("nomor").Where("peraturan_id = ? AND urutan > ?", pasal.PeraturanID, pasal.Urutan).Order("urutan asc").Limit(1).Find(&next)
+
+    c.JSON(http.StatusOK, gin.H{
+        "data":       pasal,
+        "ayat":       ayat,
+        "halaman":    halaman,
+        "viewer_url": viewerURL,
+        "prev":       prev.Nomor,
+        "next":       next.Nomor,
+    })
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxChainSize membatasi penelusuran rantai perubahan agar data yang salah tidak membuat loop panjang
+const maxChainSize = 100
+
+type CreateRelasiRequest struct {
+    TerkaitID  uint   `json:"terkait_id" binding:"required"`
+    Jenis      string `json:"jenis" binding:"required"`
+    Keterangan string `json:"keterangan"`
+}
+
+// AmendmentChain berisi seluruh peraturan yang terhubung lewat relasi amends/revokes (dua arah)
+type AmendmentChain struct {
+    Peraturan []models.Peraturan       `json:"peraturan"`
+    Relasi    []models.PeraturanRelasi `json:"relasi"`
+}
+
+// refreshStatus menghitung ulang status peraturan dari relasi yang mengarah kepadanya.
+// Perubahan status dicatat sebagai revisi.
+func refreshStatus(tx *gorm.DB, peraturanID uint, userID *int64) error {
+    var jenis []string
+    if err := tx.Model(&models.PeraturanRelasi{}).
+        Where("terkait_id = ? AND jenis IN ?", peraturanID, []string{models.RelasiAmends, models.RelasiRevokes}).
+        Pluck("jenis", &jenis).Error; err != nil {
+        return err
+    }
+
+    status := models.StatusBerlaku
+    for _, j := range jenis {
+        if j == models.RelasiRevokes {
+            status = models.StatusDicabut
+            break
+        }
+        status = models.StatusDiubah
+    }
+
+    var peraturan models.Peraturan
+    if err := tx.First(&peraturan, peraturanID).Error; err != nil {
+        return err
+    }
+    if peraturan.Status == status {
+        return nil
+    }
+    if err := tx.Model(&models.Peraturan{}).Where("id = ?", peraturanID).UpdateColumn("status", status).Error; err != nil {
+        return err
+    }
+    peraturan.Status = status
+    _, err := recordRevisi(tx, &peraturan, models.RevisiStatus, "", userID)
+    return err
+}
+
+// CreateRelasi - Menambahkan relasi dari peraturan :id ke peraturan lain
+func (h *PeraturanHandler) CreateRelasi(c *gin.Context) {
+    peraturanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+        return
+    }
+
+    var req CreateRelasiRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !models.IsValidRelasi(req.Jenis) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis must be one of: amends, revokes, implements, references"})
+        return
+    }
+    if uint(peraturanID) == req.TerkaitID {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Peraturan cannot relate to itself"})
+        return
+    }
+
+    var count int64
+    if err := h.DB.Model(&models.Peraturan{}).Where("id IN ?", []uint{uint(peraturanID), req.TerkaitID}).Count(&count).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+        return
+    }
+    if count != 2 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return
+    }
+
+    relasi := models.PeraturanRelasi{
+        PeraturanID: uint(peraturanID),
+        TerkaitID:   req.TerkaitID,
+        Jenis:       req.Jenis,
+        Keterangan:  req.Keterangan,
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&relasi).Error; err != nil {
+            return err
+        }
+        return refreshStatus(tx, relasi.TerkaitID, currentUserID(c))
+    })
+    if isDuplicateKey(h.DB, err) {
+        c.JSON(http.StatusConflict, gin.H{"error": "Relasi already exists"})
+        return
+    }
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create relasi: " + err.Error()})
+        return
+    }
+
+    h.DB.Preload("Terkait.KategoriItems").First(&relasi, relasi.ID)
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Relasi created successfully",
+        "data":    relasi,
+    })
+}
+
+// DeleteRelasi - Menghapus relasi dan menghitung ulang status peraturan terkait
+func (h *PeraturanHandler) DeleteRelasi(c *gin.Context) {
+    var relasi models.PeraturanRelasi
+    if err := h.DB.Where("id = ? AND peraturan_id = ?", c.Param("relasiId"), c.Param("id")).First(&relasi).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Relasi not found"})
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Delete(&relasi).Error; err != nil {
+            return err
+        }
+        return refreshStatus(tx, relasi.TerkaitID, currentUserID(c))
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete relasi: " + err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Relasi deleted successfully"})
+}
+
+// GetPeraturanRelasi - Relasi langsung dan rantai perubahan sebuah peraturan
+func (h *PeraturanHandler) GetPeraturanRelasi(c *gin.Context) {
+    peraturan, ok := h.findVisible(c)
+    if !ok {
+        return
+    }
+
+    relations, chain, err := h.loadRelasi(peraturan.ID, viewerFromContext(c))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch relasi: " + err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":         "Relasi fetched successfully",
+        "relations":       relations,
+        "amendment_chain": chain,
+    })
+}
+
+// isDuplicateKey mengecek err adalah pelanggaran unique constraint (kode Postgres 23505)
+func isDuplicateKey(db *gorm.DB, err error) bool {
+    if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+        err = translator.Translate(err)
+    }
+    return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// loadRelasi mengambil relasi keluar/masuk serta rantai perubahan lengkap. Relasi ke peraturan
+// yang tidak boleh dilihat user tidak disertakan.
+func (h *PeraturanHandler) loadRelasi(peraturanID uint, user *models.User) (gin.H, *AmendmentChain, error) {
+    visible := func(db *gorm.DB) *gorm.DB { return scopeVisible(h.DB, db, user) }
+
+    var outgoing, incoming []models.PeraturanRelasi
+    if err := h.DB.Preload("Terkait", visible).Preload("Terkait.KategoriItems").Where("peraturan_id = ?", peraturanID).Find(&outgoing).Error; err != nil {
+        return nil, nil, err
+    }
+    if err := h.DB.Preload("Peraturan", visible).Preload("Peraturan.KategoriItems").Where("terkait_id = ?", peraturanID).Find(&incoming).Error; err != nil {
+        return nil, nil, err
+    }
+    outgoing = filterRelasi(outgoing, func(r models.PeraturanRelasi) bool { return r.Terkait != nil })
+    incoming = filterRelasi(incoming, func(r models.PeraturanRelasi) bool { return r.Peraturan != nil })
+
+    chain, err := h.amendmentChain(peraturanID, user)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return gin.H{"outgoing": outgoing, "incoming": incoming}, chain, nil
+}
+
+func filterRelasi(relasi []models.PeraturanRelasi, keep func(models.PeraturanRelasi) bool) []models.PeraturanRelasi {
+    result := make([]models.PeraturanRelasi, 0, len(relasi))
+    for _, r := range relasi {
+        if keep(r) {
+            result = append(result, r)
+        }
+    }
+    return result
+}
+
+// amendmentChain menelusuri relasi amends/revokes ke dua arah: peraturan yang diubah/dicabut
+// oleh peraturan ini (ke belakang) dan peraturan yang mengubah/mencabutnya (ke depan).
+// Peraturan yang tidak boleh dilihat user beserta relasinya dibuang dari hasil.
+func (h *PeraturanHandler) amendmentChain(peraturanID uint, user *models.User) (*AmendmentChain, error) {
+    jenis := []string{models.RelasiAmends, models.RelasiRevokes}
+    visited := map[uint]bool{peraturanID: true}
+    seenRelasi := map[uint]bool{}
+    queue := []uint{peraturanID}
+    chain := &AmendmentChain{Relasi: []models.PeraturanRelasi{}}
+
+    for len(queue) > 0 && len(visited) < maxChainSize {
+        var edges []models.PeraturanRelasi
+        if err := h.DB.Where("(peraturan_id IN ? OR terkait_id IN ?) AND jenis IN ?", queue, queue, jenis).
+            Find(&edges).Error; err != nil {
+            return nil, err
+        }
+
+        queue = nil
+        for _, e := range edges {
+            if seenRelasi[e.ID] {
+                continue
+            }
+            seenRelasi[e.ID] = true
+            chain.Relasi = append(chain.Relasi, e)
+            for _, id := range []uint{e.PeraturanID, e.TerkaitID} {
+                if !visited[id] {
+                    visited[id] = true
+                    queue = append(queue, id)
+                }
+            }
+        }
+    }
+
+    ids := make([]uint, 0, len(visited))
+    for id := range visited {
+        ids = append(ids, id)
+    }
+    if err := scopeVisible(h.DB, h.DB.Model(&models.Peraturan{}), user).
+        Preload("KategoriItems").Where("id IN ?", ids).Order("tanggal_ditetapkan asc").Find(&chain.Peraturan).Error; err != nil {
+        return nil, err
+    }
+    shown := make(map[uint]bool, len(chain.Peraturan))
+    for _, p := range chain.Peraturan {
+        shown[p.ID] = true
+    }
+    chain.Relasi = filterRelasi(chain.Relasi, func(r models.PeraturanRelasi) bool {
+        return shown[r.PeraturanID] && shown[r.TerkaitID]
+    })
+    sort.Slice(chain.Relasi, func(i, j int) bool { return chain.Relasi[i].ID < chain.Relasi[j].ID })
+    return chain, nil
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestIsDuplicateKey(t *testing.T) {
+    db := &gorm.DB{Config: &gorm.Config{Dialector: postgres.Dialector{}}}
+    cases := []struct {
+        name string
+        err  error
+        want bool
+    }{
+        {"unique violation", &pgconn.PgError{Code: "23505"}, true},
+        {"foreign key violation", &pgconn.PgError{Code: "23503"}, false},
+        {"connection error", errors.New("dial tcp: connection refused"), false},
+        {"wrapped gorm error", fmt.Errorf("create: %w", gorm.ErrDuplicatedKey), true},
+        {"nil", nil, false},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            if got := isDuplicateKey(db, tc.err); got != tc.want {
+                t.Fatalf("isDuplicateKey(%v) = %v, want %v", tc.err, got, tc.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"backend/models"
+	"backend/storage"
+	"backend/textdiff"
+	"backend/textextract"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// diffContext adalah jumlah kalimat sama yang ditampilkan di sekitar perubahan teks
+const diffContext = 2
+
+// FieldChange adalah perubahan satu field metadata antara dua revisi
+type FieldChange struct {
+    Field string      `json:"field"`
+    From  interface{} `json:"from"`
+    To    interface{} `json:"to"`
+}
+
+// TextChange adalah satu langkah diff teks beserta halaman asalnya di file lama/baru
+type TextChange struct {
+    textdiff.Op
+    HalamanLama int `json:"halaman_lama,omitempty"`
+    HalamanBaru int `json:"halaman_baru,omitempty"`
+}
+
+type RollbackRequest struct {
+    Catatan string `json:"catatan"`
+}
+
+// revisiFields adalah field metadata yang dibandingkan antar revisi
+var revisiFields = []struct {
+    name  string
+    value func(r *models.PeraturanRevisi) interface{}
+}{
+    {"nomor", func(r *models.PeraturanRevisi) interface{} { return r.Nomor }},
+    {"tanggal_ditetapkan", func(r *models.PeraturanRevisi) interface{} { return r.TanggalDitetapkan.Format("2006-01-02") }},
+    {"judul", func(r *models.PeraturanRevisi) interface{} { return r.Judul }},
+    {"instansi_pembuat", func(r *models.PeraturanRevisi) interface{} { return r.InstansiPembuat }},
+    {"jenis_peraturan", func(r *models.PeraturanRevisi) interface{} { return r.JenisPeraturan }},
+    {"kategori", func(r *models.PeraturanRevisi) interface{} {
+        names, err := parseKategoriNames(r.Kategori)
+        if err != nil || names == nil {
+            return []string{}
+        }
+        sort.Strings(names) // Revisi lama menyimpan urutan input
+        return names
+    }},
+    {"keterangan", func(r *models.PeraturanRevisi) interface{} { return r.Keterangan }},
+    {"status", func(r *models.PeraturanRevisi) interface{} { return r.Status }},
+    {"nama_file", func(r *models.PeraturanRevisi) interface{} { return r.NamaFile }},
+    {"file_hash", func(r *models.PeraturanRevisi) interface{} { return r.FileHash }},
+    {"file_size", func(r *models.PeraturanRevisi) interface{} { return r.FileSize }},
+    {"content_type", func(r *models.PeraturanRevisi) interface{} { return r.ContentType }},
+    {"watermark", func(r *models.PeraturanRevisi) interface{} { return r.Watermark }},
+    {"visibilitas", func(r *models.PeraturanRevisi) interface{} { return r.Visibilitas }},
+    {"izin", func(r *models.PeraturanRevisi) interface{} {
+        izin, err := parseIzinText(r.Izin)
+        if err != nil {
+            return []models.PeraturanIzin{}
+        }
+        return izin
+    }},
+}
+
+// diffRevisi membandingkan metadata dua revisi per field
+func diffRevisi(from, to *models.PeraturanRevisi) []FieldChange {
+    changes := []FieldChange{}
+    for _, f := range revisiFields {
+        a, b := f.value(from), f.value(to)
+        if !reflect.DeepEqual(a, b) {
+            changes = append(changes, FieldChange{Field: f.name, From: a, To: b})
+        }
+    }
+    return changes
+}
+
+func snapshotPeraturan(p *models.Peraturan) models.PeraturanRevisi {
+    return models.PeraturanRevisi{
+        PeraturanID:       p.ID,
+        Nomor:             p.Nomor,
+        TanggalDitetapkan: p.TanggalDitetapkan,
+        Judul:             p.Judul,
+        InstansiPembuat:   p.InstansiPembuat,
+        JenisPeraturan:    p.JenisPeraturan,
+        Kategori:          models.KategoriNamesJSON(p.KategoriItems),
+        NamaFile:          p.NamaFile,
+        PathFile:          p.PathFile,
+        FileHash:          p.FileHash,
+        FileSize:          p.FileSize,
+        ContentType:       p.ContentType,
+        Keterangan:        p.Keterangan,
+        Status:            p.Status,
+        Watermark:         p.Watermark,
+        Visibilitas:       p.Visibilitas,
+        Izin:              izinText(p.Izin),
+    }
+}
+
+// recordRevisi menyimpan snapshot peraturan setelah perubahan. Update yang tidak
+// mengubah apa pun tidak menghasilkan revisi baru (hasilnya nil).
+func recordRevisi(tx *gorm.DB, p *models.Peraturan, aksi, catatan string, userID *int64) (*models.PeraturanRevisi, error) {
+    var last models.PeraturanRevisi
+    if err := tx.Where("peraturan_id = ?", p.ID).Order("revisi desc").Limit(1).Find(&last).Error; err != nil {
+        return nil, err
+    }
+    if p.KategoriItems == nil {
+        if err := tx.Model(p).Association("KategoriItems").Find(&p.KategoriItems); err != nil {
+            return nil, err
+        }
+    }
+    if p.Izin == nil {
+        if err := tx.Where("peraturan_id = ?", p.ID).Find(&p.Izin).Error; err != nil {
+            return nil, err
+        }
+    }
+
+    revisi := snapshotPeraturan(p)
+    if last.ID != 0 && aksi == models.RevisiUpdate && len(diffRevisi(&last, &revisi)) == 0 {
+        return nil, nil
+    }
+    revisi.Revisi = last.Revisi + 1
+    revisi.Aksi = aksi
+    revisi.Catatan = catatan
+    revisi.FileChanged = last.ID == 0 || last.FileHash != revisi.FileHash
+    revisi.UserID = userID
+    if err := tx.Create(&revisi).Error; err != nil {
+        return nil, err
+    }
+    return &revisi, nil
+}
+
+// MigrateRevisi membuat revisi baseline untuk peraturan yang belum punya riwayat
+// (data yang ada sebelum riwayat revisi dicatat)
+func MigrateRevisi(db *gorm.DB) error {
+    result := db.Exec(`
+        INSERT INTO peraturan_revisi (peraturan_id, revisi, aksi, catatan, file_changed, nomor, tanggal_ditetapkan,
+            judul, instansi_pembuat, jenis_peraturan, kategori, nama_file, path_file, file_hash, file_size,
+            content_type, keterangan, status, watermark, visibilitas, izin, created_at)
+        SELECT p.id, 1, ?, '', p.path_file <> '', p.nomor, p.tanggal_ditetapkan,
+            p.judul, p.instansi_pembuat, p.jenis_peraturan,
+            COALESCE((SELECT json_agg(k.nama ORDER BY k.nama) FROM peraturan_kategori pk
+                JOIN kategori k ON k.id = pk.kategori_id WHERE pk.peraturan_id = p.id)::text, '[]'),
+            p.nama_file, p.path_file, p.file_hash, p.file_size,
+            p.content_type, p.keterangan, p.status, p.watermark, p.visibilitas,
+            COALESCE((SELECT json_agg(json_build_object('tipe', i.tipe, 'nilai', i.nilai) ORDER BY i.tipe, i.nilai)
+                FROM peraturan_izin i WHERE i.peraturan_id = p.id)::text, '[]'),
+            p.created_at
+        FROM peraturans p
+        WHERE NOT EXISTS (SELECT 1 FROM peraturan_revisi r WHERE r.peraturan_id = p.id)`, models.RevisiBaseline)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected > 0 {
+        log.Printf("INFO: Created baseline revision for %d peraturan", result.RowsAffected)
+    }
+    return nil
+}
+
+// loadRevisi mengambil revisi :revisi milik peraturan :id
+func (h *PeraturanHandler) loadRevisi(c *gin.Context, nomor string) (*models.PeraturanRevisi, bool) {
+    var revisi models.PeraturanRevisi
+    if err := h.DB.Where("peraturan_id = ? AND revisi = ?", c.Param("id"), nomor).First(&revisi).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Revision " + nomor + " not found"})
+        return nil, false
+    }
+    return &revisi, true
+}
+
+// GetRevisions - Riwayat revisi peraturan, terbaru di atas
+func (h *PeraturanHandler) GetRevisions(c *gin.Context) {
+    var revisions []models.PeraturanRevisi
+    if err := h.DB.Preload("User").Where("peraturan_id = ?", c.Param("id")).Order("revisi desc").Find(&revisions).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+        return
+    }
+    if len(revisions) == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": revisions})
+}
+
+// GetRevisionFile - Mengunduh file peraturan sebagaimana pada revisi tertentu. Akses dan
+// watermark mengikuti peraturan induknya, sehingga file lama tidak bisa dipakai untuk
+// mendapatkan salinan tanpa watermark.
+func (h *PeraturanHandler) GetRevisionFile(c *gin.Context) {
+    peraturan, ok := h.findVisible(c)
+    if !ok {
+        return
+    }
+    revisi, ok := h.loadRevisi(c, c.Param("revisi"))
+    if !ok {
+        return
+    }
+    if revisi.PathFile == "" {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Revision has no file"})
+        return
+    }
+
+    file := *peraturan
+    file.NamaFile = revisi.NamaFile
+    file.PathFile = revisi.PathFile
+    file.FileHash = revisi.FileHash
+    file.FileSize = revisi.FileSize
+    file.ContentType = revisi.ContentType
+    if peraturan.Watermark || revisi.Watermark {
+        if !isPDFPeraturan(&file) {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Revision file is not a PDF and cannot be watermarked"})
+            return
+        }
+        h.serveWatermarked(c, &file, "attachment")
+        return
+    }
+    h.serveFile(c, &file, "attachment", "application/octet-stream")
+}
+
+// DiffRevisions - Perbedaan metadata per field dan perbedaan teks isi file antara dua revisi
+// (?from=N&to=M; default to = revisi terbaru, from = revisi sebelumnya). Diff teks hanya
+// dihitung jika file berbeda.
+func (h *PeraturanHandler) DiffRevisions(c *gin.Context) {
+    var latest models.PeraturanRevisi
+    if err := h.DB.Where("peraturan_id = ?", c.Param("id")).Order("revisi desc").First(&latest).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return
+    }
+
+    to := c.DefaultQuery("to", strconv.Itoa(latest.Revisi))
+    toRevisi, ok := h.loadRevisi(c, to)
+    if !ok {
+        return
+    }
+    from := c.DefaultQuery("from", strconv.Itoa(toRevisi.Revisi-1))
+    fromRevisi, ok := h.loadRevisi(c, from)
+    if !ok {
+        return
+    }
+
+    response := gin.H{
+        "from":   fromRevisi,
+        "to":     toRevisi,
+        "fields": diffRevisi(fromRevisi, toRevisi),
+    }
+
+    if fromRevisi.FileHash != toRevisi.FileHash {
+        changes, err := h.diffRevisiText(c, fromRevisi, toRevisi)
+        if err != nil {
+            response["text_error"] = err.Error()
+        } else {
+            response["text"] = changes
+        }
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// diffRevisiText mengekstrak teks file kedua revisi lalu membandingkannya per kalimat
+func (h *PeraturanHandler) diffRevisiText(c *gin.Context, from, to *models.PeraturanRevisi) ([]TextChange, error) {
+    lama, halamanLama, err := h.revisiSentences(c, from)
+    if err != nil {
+        return nil, fmt.Errorf("failed to extract revision %d: %v", from.Revisi, err)
+    }
+    baru, halamanBaru, err := h.revisiSentences(c, to)
+    if err != nil {
+        return nil, fmt.Errorf("failed to extract revision %d: %v", to.Revisi, err)
+    }
+
+    ops := textdiff.Compact(textdiff.Diff(lama, baru), diffContext)
+    changes := make([]TextChange, 0, len(ops))
+    for _, op := range ops {
+        change := TextChange{Op: op}
+        if op.A >= 0 && op.A < len(halamanLama) {
+            change.HalamanLama = halamanLama[op.A]
+        }
+        if op.B >= 0 && op.B < len(halamanBaru) {
+            change.HalamanBaru = halamanBaru[op.B]
+        }
+        changes = append(changes, change)
+    }
+    return changes, nil
+}
+
+// revisiSentences mengembalikan kalimat isi file revisi beserta nomor halaman tiap kalimat
+func (h *PeraturanHandler) revisiSentences(c *gin.Context, revisi *models.PeraturanRevisi) ([]string, []int, error) {
+    if revisi.PathFile == "" {
+        return nil, nil, nil
+    }
+    data, err := storage.ReadAll(c.Request.Context(), h.Storage, revisi.PathFile)
+    if err != nil {
+        return nil, nil, err
+    }
+    pages, err := textextract.Extract(data)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    var sentences []string
+    var halaman []int
+    for i, page := range pages {
+        for _, s := range textdiff.SplitSentences(page) {
+            sentences = append(sentences, s)
+            halaman = append(halaman, i+1)
+        }
+    }
+    return sentences, halaman, nil
+}
+
+// RollbackRevision - Mengembalikan metadata, file, watermark, dan visibilitas peraturan ke revisi
+// tertentu. Rollback dicatat sebagai revisi baru sehingga riwayat tidak pernah hilang. Status tidak
+// ikut dikembalikan karena dihitung dari relasi. Revisi lama yang belum mencatat visibilitas
+// membiarkan watermark, visibilitas, dan izin saat ini.
+func (h *PeraturanHandler) RollbackRevision(c *gin.Context) {
+    revisi, ok := h.loadRevisi(c, c.Param("revisi"))
+    if !ok {
+        return
+    }
+
+    var req RollbackRequest
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+    catatan := fmt.Sprintf("Rollback ke revisi %d", revisi.Revisi)
+    if req.Catatan != "" {
+        catatan += ": " + req.Catatan
+    }
+
+    var peraturan models.Peraturan
+    if err := h.DB.First(&peraturan, revisi.PeraturanID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return
+    }
+    names, err := parseKategoriNames(revisi.Kategori)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid kategori in revision"})
+        return
+    }
+    izin, err := parseIzinText(revisi.Izin)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid izin in revision"})
+        return
+    }
+    restoreAkses := revisi.Visibilitas != ""
+    fileChanged := peraturan.FileHash != revisi.FileHash
+
+    peraturan.Nomor = revisi.Nomor
+    peraturan.TanggalDitetapkan = revisi.TanggalDitetapkan
+    peraturan.Judul = revisi.Judul
+    peraturan.InstansiPembuat = revisi.InstansiPembuat
+    peraturan.JenisPeraturan = revisi.JenisPeraturan
+    peraturan.Keterangan = revisi.Keterangan
+    peraturan.NamaFile = revisi.NamaFile
+    peraturan.PathFile = revisi.PathFile
+    peraturan.FileHash = revisi.FileHash
+    peraturan.FileSize = revisi.FileSize
+    peraturan.ContentType = revisi.ContentType
+    if restoreAkses {
+        peraturan.Watermark = revisi.Watermark
+        peraturan.Visibilitas = revisi.Visibilitas
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        kategoriItems, err := resolveKategori(tx, names)
+        if err != nil {
+            return err
+        }
+        if err := tx.Omit("KategoriItems").Save(&peraturan).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&peraturan).Association("KategoriItems").Replace(kategoriItems); err != nil {
+            return err
+        }
+        peraturan.SetKategori(kategoriItems)
+        if restoreAkses {
+            if err := replaceIzin(tx, &peraturan, izin); err != nil {
+                return err
+            }
+        }
+        _, err = recordRevisi(tx, &peraturan, models.RevisiRollback, catatan, currentUserID(c))
+        return err
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back peraturan: " + err.Error()})
+        return
+    }
+
+    if fileChanged {
+        h.indexInBackground(peraturan)
+    }
+    h.Notifier.PeraturanChanged(peraturan, models.NotifikasiDiubah, fileChanged, currentUserID(c))
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": catatan,
+        "data":    peraturan,
+    })
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend/models"
+	"backend/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// indexInBackground mengekstrak teks file peraturan tanpa membuat upload menunggu
+func (h *PeraturanHandler) indexInBackground(peraturan models.Peraturan) {
+    go func() {
+        defer func() {
+            if r := recover(); r != nil {
+                log.Printf("WARNING: Indexing peraturan %d panicked: %v", peraturan.ID, r)
+            }
+        }()
+        if err := search.IndexPeraturan(h.DB, h.Storage, &peraturan); err != nil {
+            log.Printf("WARNING: Failed to index peraturan %d: %v", peraturan.ID, err)
+        }
+    }()
+}
+
+// SearchPeraturan - Pencarian full-text isi file peraturan dengan ranking dan cuplikan per halaman
+func (h *PeraturanHandler) SearchPeraturan(c *gin.Context) {
+    q := strings.TrimSpace(c.Query("q"))
+    if q == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+        return
+    }
+
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if err != nil || limit < 1 || limit > 100 {
+        limit = 20
+    }
+
+    results, err := search.Search(h.DB, q, limit, 3, visibleIDs(h.DB, viewerFromContext(c)))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search peraturans: " + err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Search completed successfully",
+        "query":   q,
+        "data":    results,
+    })
+}
+
+// ReindexPeraturan - Backfill indeks teks untuk file yang sudah ada (?all=true untuk mengindeks ulang semua)
+func (h *PeraturanHandler) ReindexPeraturan(c *gin.Context) {
+    all := c.Query("all") == "true"
+
+    go func() {
+        indexed, err := search.Reindex(h.DB, h.Storage, all)
+        if err != nil {
+            log.Printf("WARNING: Reindex failed: %v", err)
+            return
+        }
+        log.Printf("INFO: Reindex finished, %d peraturan indexed", indexed)
+    }()
+
+    c.JSON(http.StatusAccepted, gin.H{"message": "Reindex started"})
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/sharelink"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas masa berlaku tautan berbagi
+const (
+    defaultShareLinkHours = 72
+    maxShareLinkHours     = 30 * 24
+)
+
+// Izin lanjutan range request dikirim sebagai cookie setelah unduhan dihitung. Nama cookie
+// memuat id tautan agar membuka tautan lain tidak menimpa izin unduhan yang sedang berjalan.
+const (
+    shareDownloadCookiePrefix = "share_download_"
+    shareDownloadTTL          = 15 * time.Minute
+)
+
+func shareDownloadCookie(linkID uint) string {
+    return fmt.Sprintf("%s%d", shareDownloadCookiePrefix, linkID)
+}
+
+type ShareLinkRequest struct {
+    Penerima       string `json:"penerima" binding:"required"`
+    Catatan        string `json:"catatan"`
+    ExpiresInHours int    `json:"expires_in_hours"` // Default 72 jam, maksimal 30 hari
+    MaxDownloads   *int   `json:"max_downloads"`    // Kosong = tanpa batas
+}
+
+// ShareLinkResponse adalah tautan beserta URL bertanda tangan yang bisa dibagikan
+type ShareLinkResponse struct {
+    models.TautanBerbagi
+    Active  bool   `json:"active"`
+    URL     string `json:"url"`
+    ViewURL string `json:"view_url"`
+}
+
+func (h *PeraturanHandler) shareLinkResponse(c *gin.Context, link models.TautanBerbagi) ShareLinkResponse {
+    token := url.QueryEscape(h.ShareLinks.Sign(sharelink.Claims{
+        LinkID:      link.ID,
+        PeraturanID: link.PeraturanID,
+        ExpiresAt:   link.ExpiresAt,
+    }))
+    baseURL := publicBaseURL()
+    return ShareLinkResponse{
+        TautanBerbagi: link,
+        Active:        link.Active(time.Now()),
+        URL:           fmt.Sprintf("%s/api/peraturan/download/%d?share=%s", baseURL, link.PeraturanID, token),
+        ViewURL:       fmt.Sprintf("%s/api/peraturan/file/%d?share=%s", baseURL, link.PeraturanID, token),
+    }
+}
+
+// authorizeFile memeriksa akses ke file peraturan. Dengan ?share=<token>, tautan berbagi yang
+// valid memberi akses tanpa melihat visibilitas: satu unduhan dihitung dan tautannya disimpan di
+// context sebagai pengganti session. Tanpa token berlaku visibilitas peraturan seperti biasa.
+// Range request lanjutan tidak dihitung lagi hanya jika membawa cookie izin yang diterbitkan
+// server saat unduhan dihitung; header Range dari client saja tidak cukup.
+// Mengembalikan false jika response error sudah dikirim.
+func (h *PeraturanHandler) authorizeFile(c *gin.Context, peraturan *models.Peraturan) bool {
+    token := c.Query("share")
+    if token == "" {
+        if !canView(h.DB, peraturan, viewerFromContext(c)) {
+            c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+            return false
+        }
+        return true
+    }
+    claims, err := h.ShareLinks.Verify(token)
+    if err != nil || claims.PeraturanID != peraturan.ID {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Share link is invalid or expired"})
+        return false
+    }
+
+    now := time.Now()
+    query := h.DB.Model(&models.TautanBerbagi{}).
+        Where("id = ? AND peraturan_id = ? AND revoked_at IS NULL AND expires_at > ?", claims.LinkID, peraturan.ID, now)
+    var result *gorm.DB
+    continuation := h.downloadContinuation(c, peraturan, claims.LinkID)
+    if continuation >= 0 {
+        // Lanjutan unduhan yang sudah dihitung: pastikan tautan belum dicabut dan belum ada
+        // unduhan baru sejak izin diterbitkan
+        var count int64
+        result = query.Where("download_count = ? AND (max_downloads IS NULL OR download_count <= max_downloads)", continuation).Count(&count)
+        result.RowsAffected = count
+    } else {
+        // Kondisi batas dicek di UPDATE agar unduhan bersamaan tidak melewati max_downloads
+        result = query.Where("max_downloads IS NULL OR download_count < max_downloads").
+            Updates(map[string]interface{}{
+                "download_count": gorm.Expr("download_count + 1"),
+                "last_used_at":   now,
+            })
+    }
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check share link"})
+        return false
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Share link has been revoked or its download limit was reached"})
+        return false
+    }
+
+    var link models.TautanBerbagi
+    if err := h.DB.First(&link, claims.LinkID).Error; err != nil {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Share link is invalid or expired"})
+        return false
+    }
+    c.Set("share_link", link)
+
+    // File ber-watermark tidak mendukung Range, jadi tidak perlu izin lanjutan
+    if continuation < 0 && !peraturan.Watermark {
+        grant := h.ShareLinks.SignDownload(link.ID, int64(link.DownloadCount), now.Add(shareDownloadTTL))
+        c.SetCookie(shareDownloadCookie(link.ID), grant, int(shareDownloadTTL.Seconds()), "/api/peraturan/", "", false, true)
+    }
+    return true
+}
+
+// downloadContinuation mengembalikan nomor unduhan yang dilanjutkan jika request adalah range
+// request lanjutan dengan cookie izin yang valid untuk tautan ini, atau -1 jika request harus
+// dihitung sebagai unduhan baru. Respons ber-watermark selalu berisi file utuh sehingga selalu dihitung.
+func (h *PeraturanHandler) downloadContinuation(c *gin.Context, peraturan *models.Peraturan, linkID uint) int64 {
+    if peraturan.Watermark || !isRangeContinuation(c) {
+        return -1
+    }
+    grant, err := c.Cookie(shareDownloadCookie(linkID))
+    if err != nil {
+        return -1
+    }
+    seq, err := h.ShareLinks.VerifyDownload(grant, linkID)
+    if err != nil {
+        return -1
+    }
+    return seq
+}
+
+// CreateShareLink - Membuat tautan berbagi bertanda tangan untuk file peraturan
+func (h *PeraturanHandler) CreateShareLink(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    // Hanya peraturan yang boleh dilihat pembuat tautan yang bisa dibagikan
+    peraturan, ok := h.findVisible(c)
+    if !ok {
+        return
+    }
+    if peraturan.PathFile == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Peraturan has no file"})
+        return
+    }
+
+    var req ShareLinkRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.ExpiresInHours == 0 {
+        req.ExpiresInHours = defaultShareLinkHours
+    }
+    if req.ExpiresInHours < 1 || req.ExpiresInHours > maxShareLinkHours {
+        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("expires_in_hours must be between 1 and %d", maxShareLinkHours)})
+        return
+    }
+    if req.MaxDownloads != nil && *req.MaxDownloads < 1 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "max_downloads must be at least 1"})
+        return
+    }
+
+    now := time.Now()
+    link := models.TautanBerbagi{
+        PeraturanID:  peraturan.ID,
+        Penerima:     strings.TrimSpace(req.Penerima),
+        Catatan:      req.Catatan,
+        ExpiresAt:    now.Add(time.Duration(req.ExpiresInHours) * time.Hour).Truncate(time.Second),
+        MaxDownloads: req.MaxDownloads,
+        CreatedBy:    &user.ID,
+        CreatedAt:    now,
+    }
+    if err := h.DB.Create(&link).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Share link created successfully",
+        "data":    h.shareLinkResponse(c, link),
+    })
+}
+
+// GetMyShareLinks - Tautan berbagi yang dibuat user yang login
+func (h *PeraturanHandler) GetMyShareLinks(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+    h.listShareLinks(c, h.DB.Where("created_by = ?", user.ID))
+}
+
+// GetShareLinks - Daftar tautan berbagi untuk admin; default hanya yang masih aktif (?all=true untuk semua)
+func (h *PeraturanHandler) GetShareLinks(c *gin.Context) {
+    query := h.DB.Model(&models.TautanBerbagi{})
+    if c.Query("all") != "true" {
+        query = query.Where("revoked_at IS NULL AND expires_at > ? AND (max_downloads IS NULL OR download_count < max_downloads)", time.Now())
+    }
+    if id := c.Query("peraturan_id"); id != "" {
+        query = query.Where("peraturan_id = ?", id)
+    }
+    h.listShareLinks(c, query)
+}
+
+func (h *PeraturanHandler) listShareLinks(c *gin.Context, query *gorm.DB) {
+    var links []models.TautanBerbagi
+    if err := query.Preload("Peraturan.KategoriItems").Order("created_at desc").Find(&links).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch share links"})
+        return
+    }
+    data := make([]ShareLinkResponse, 0, len(links))
+    for _, link := range links {
+        data = append(data, h.shareLinkResponse(c, link))
+    }
+    c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RevokeShareLink - Mencabut tautan berbagi (pembuat tautan atau pemilik permission share_link.manage)
+func (h *PeraturanHandler) RevokeShareLink(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var link models.TautanBerbagi
+    if err := h.DB.First(&link, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+        return
+    }
+    if !user.Can(models.PermShareLinkManage) && (link.CreatedBy == nil || *link.CreatedBy != user.ID) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Only the creator or an admin can revoke this link"})
+        return
+    }
+
+    if link.RevokedAt == nil {
+        now := time.Now()
+        if err := h.DB.Model(&link).Update("revoked_at", now).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+            return
+        }
+        link.RevokedAt = &now
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Share link revoked",
+        "data":    h.shareLinkResponse(c, link),
+    })
+}
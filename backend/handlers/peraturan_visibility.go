@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// viewerFromContext mengembalikan user yang login, nil jika anonim. Route publik perlu
+// OptionalAuthMiddleware agar user yang login bisa melihat peraturan non-publik.
+func viewerFromContext(c *gin.Context) *models.User {
+    if value, ok := c.Get("user"); ok {
+        if user, ok := value.(models.User); ok {
+            return &user
+        }
+    }
+    return nil
+}
+
+// scopeVisible menyaring query peraturan menjadi yang boleh dilihat user (nil = anonim).
+// Kondisi dipasang di SQL sehingga total, pagination, dan facet tidak ikut menghitung
+// peraturan yang tersembunyi. Harus sejalan dengan models.Peraturan.VisibleTo.
+func scopeVisible(db *gorm.DB, query *gorm.DB, user *models.User) *gorm.DB {
+    switch {
+    case user == nil:
+        return query.Where("peraturans.visibilitas = ?", models.VisibilitasPublik)
+    case user.Can(models.PermPeraturanReadAll):
+        return query
+    }
+
+    izin := db.Table("peraturan_izin").Select("peraturan_id").
+        Where("tipe = ? AND nilai = ?", models.IzinRole, user.Role)
+    if user.Unit != "" {
+        izin = izin.Or("tipe = ? AND nilai = ?", models.IzinUnit, user.Unit)
+    }
+    return query.Where("peraturans.visibilitas IN ? OR (peraturans.visibilitas = ? AND peraturans.id IN (?))",
+        []string{models.VisibilitasPublik, models.VisibilitasInternal}, models.VisibilitasTerbatas, izin)
+}
+
+// visibleIDs adalah subquery id peraturan yang boleh dilihat user
+func visibleIDs(db *gorm.DB, user *models.User) *gorm.DB {
+    return scopeVisible(db, db.Model(&models.Peraturan{}), user).Select("peraturans.id")
+}
+
+// canView memeriksa satu peraturan (izin dimuat jika perlu)
+func canView(db *gorm.DB, peraturan *models.Peraturan, user *models.User) bool {
+    if peraturan.Visibilitas == models.VisibilitasTerbatas && peraturan.Izin == nil {
+        if err := db.Where("peraturan_id = ?", peraturan.ID).Find(&peraturan.Izin).Error; err != nil {
+            return false
+        }
+    }
+    return peraturan.VisibleTo(user)
+}
+
+// findVisible memuat peraturan dari :id dan mengirim 404 jika tidak ada atau tidak boleh
+// dilihat, supaya keberadaan peraturan tersembunyi tidak bocor
+func (h *PeraturanHandler) findVisible(c *gin.Context) (*models.Peraturan, bool) {
+    var peraturan models.Peraturan
+    if err := h.DB.Preload("KategoriItems").First(&peraturan, c.Param("id")).Error; err != nil || !canView(h.DB, &peraturan, viewerFromContext(c)) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return nil, false
+    }
+    return &peraturan, true
+}
+
+// izinEntry adalah bentuk izin di snapshot revisi (tanpa id)
+type izinEntry struct {
+    Tipe  string `json:"tipe"`
+    Nilai string `json:"nilai"`
+}
+
+// izinText menyimpan izin sebagai JSON terurut agar dua snapshot dengan izin sama identik
+func izinText(izin []models.PeraturanIzin) string {
+    entries := make([]izinEntry, 0, len(izin))
+    for _, i := range izin {
+        entries = append(entries, izinEntry{Tipe: i.Tipe, Nilai: i.Nilai})
+    }
+    sort.Slice(entries, func(a, b int) bool {
+        if entries[a].Tipe != entries[b].Tipe {
+            return entries[a].Tipe < entries[b].Tipe
+        }
+        return entries[a].Nilai < entries[b].Nilai
+    })
+    data, _ := json.Marshal(entries)
+    return string(data)
+}
+
+// parseIzinText membaca kembali izin dari snapshot revisi
+func parseIzinText(raw string) ([]models.PeraturanIzin, error) {
+    var entries []izinEntry
+    if strings.TrimSpace(raw) != "" {
+        if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+            return nil, err
+        }
+    }
+    izin := make([]models.PeraturanIzin, 0, len(entries))
+    for _, e := range entries {
+        izin = append(izin, models.PeraturanIzin{Tipe: e.Tipe, Nilai: e.Nilai})
+    }
+    return izin, nil
+}
+
+// replaceIzin mengganti seluruh izin peraturan dengan izin baru
+func replaceIzin(tx *gorm.DB, peraturan *models.Peraturan, izin []models.PeraturanIzin) error {
+    if err := tx.Where("peraturan_id = ?", peraturan.ID).Delete(&models.PeraturanIzin{}).Error; err != nil {
+        return err
+    }
+    for i := range izin {
+        izin[i].ID = 0
+        izin[i].PeraturanID = peraturan.ID
+    }
+    if len(izin) > 0 {
+        if err := tx.Create(&izin).Error; err != nil {
+            return err
+        }
+    }
+    peraturan.Izin = izin
+    return nil
+}
+
+// parseIzinList membaca daftar role/unit dari form: JSON array (dari frontend) atau dipisah koma
+func parseIzinList(raw string) ([]string, error) {
+    raw = strings.TrimSpace(raw)
+    var values []string
+    if strings.HasPrefix(raw, "[") {
+        if err := json.Unmarshal([]byte(raw), &values); err != nil {
+            return nil, err
+        }
+    } else if raw != "" {
+        values = strings.Split(raw, ",")
+    }
+
+    seen := map[string]bool{}
+    result := []string{}
+    for _, v := range values {
+        v = strings.TrimSpace(v)
+        if v != "" && !seen[v] {
+            seen[v] = true
+            result = append(result, v)
+        }
+    }
+    return result, nil
+}
+
+// parseVisibilitas membaca visibilitas, akses_role, dan akses_unit dari form create/update.
+// Izin hanya dikembalikan untuk visibilitas terbatas dan minimal harus ada satu role atau unit.
+func parseVisibilitas(c *gin.Context) (string, []models.PeraturanIzin, error) {
+    visibilitas := strings.TrimSpace(c.DefaultPostForm("visibilitas", models.VisibilitasPublik))
+    if !models.IsValidVisibilitas(visibilitas) {
+        return "", nil, fmt.Errorf("visibilitas must be one of: %s, %s, %s",
+            models.VisibilitasPublik, models.VisibilitasInternal, models.VisibilitasTerbatas)
+    }
+    if visibilitas != models.VisibilitasTerbatas {
+        return visibilitas, nil, nil
+    }
+
+    var izin []models.PeraturanIzin
+    for _, field := range []struct{ tipe, name string }{{models.IzinRole, "akses_role"}, {models.IzinUnit, "akses_unit"}} {
+        values, err := parseIzinList(c.PostForm(field.name))
+        if err != nil {
+            return "", nil, fmt.Errorf("invalid %s format: %v", field.name, err)
+        }
+        for _, v := range values {
+            izin = append(izin, models.PeraturanIzin{Tipe: field.tipe, Nilai: v})
+        }
+    }
+    if len(izin) == 0 {
+        return "", nil, fmt.Errorf("restricted peraturan needs at least one akses_role or akses_unit")
+    }
+    return visibilitas, izin, nil
+}
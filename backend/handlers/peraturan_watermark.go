@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/storage"
+	"backend/textextract"
+	"backend/upload"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errWatermarkLogin dikembalikan jika file ber-watermark diminta tanpa identitas pengunduh
+var errWatermarkLogin = errors.New("login required to access this document")
+
+// watermarkAlphabet tanpa karakter yang mirip (0/O, 1/I) agar kode mudah dibaca dari cetakan
+const watermarkAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newWatermarkCode membuat kode verifikasi acak berformat XXXXX-XXXXX
+func newWatermarkCode() (string, error) {
+    raw := make([]byte, 10)
+    if _, err := rand.Read(raw); err != nil {
+        return "", err
+    }
+    code := make([]byte, 0, 11)
+    for i, b := range raw {
+        if i == 5 {
+            code = append(code, '-')
+        }
+        code = append(code, watermarkAlphabet[int(b)%len(watermarkAlphabet)])
+    }
+    return string(code), nil
+}
+
+// normalizeWatermarkCode menerima kode dengan/tanpa tanda hubung dan huruf kecil
+func normalizeWatermarkCode(code string) string {
+    code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+    if len(code) == 10 {
+        code = code[:5] + "-" + code[5:]
+    }
+    return code
+}
+
+// downloaderIdentity mengembalikan nama dan NIP yang dicetak pada watermark; data pegawai
+// dipakai jika akun sudah terhubung dan diverifikasi
+func downloaderIdentity(user models.User) (string, string) {
+    if employee := user.VerifiedEmployee(); employee != nil {
+        return employee.Nama, employee.NIP
+    }
+    nama := user.FullName
+    if nama == "" {
+        nama = user.Username
+    }
+    return nama, ""
+}
+
+// watermarkFile membubuhkan nama/NIP pengunduh, waktu, dan kode verifikasi pada setiap halaman
+// PDF, lalu mencatat salinan tersebut agar bisa dilacak jika tersebar. Tanpa login, penerima
+// tautan berbagi (jika ada) dipakai sebagai identitas pengunduh.
+func (h *PeraturanHandler) watermarkFile(c *gin.Context, peraturan *models.Peraturan) ([]byte, error) {
+    var userID *int64
+    var tautanID *uint
+    var nama, nip, akun string
+    if value, ok := c.Get("share_link"); ok {
+        link := value.(models.TautanBerbagi)
+        tautanID = &link.ID
+        nama, akun = link.Penerima, fmt.Sprintf("tautan berbagi #%d", link.ID)
+    }
+    if value, ok := c.Get("user"); ok {
+        user := value.(models.User)
+        userID = &user.ID
+        nama, nip = downloaderIdentity(user)
+        akun = user.Username
+    } else if tautanID == nil {
+        return nil, errWatermarkLogin
+    }
+
+    data, err := storage.ReadAll(c.Request.Context(), h.Storage, peraturan.PathFile)
+    if err != nil {
+        return nil, err
+    }
+    kode, err := newWatermarkCode()
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    identity := nama
+    if nip != "" {
+        identity += " - NIP " + nip
+    }
+    stamped, err := textextract.StampPDF(data, textextract.Stamp{
+        Diagonal: identity,
+        Footer: []string{
+            fmt.Sprintf("Diunduh oleh %s (%s) pada %s", identity, akun, now.Format("02-01-2006 15:04:05 MST")),
+            "Kode verifikasi: " + kode + " - Dokumen terbatas, dilarang menyebarluaskan tanpa izin",
+        },
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if err := h.DB.Create(&models.UnduhanWatermark{
+        Kode:        kode,
+        PeraturanID: peraturan.ID,
+        UserID:      userID,
+        TautanID:    tautanID,
+        Nama:        nama,
+        NIP:         nip,
+        FileHash:    peraturan.FileHash,
+        IPAddress:   c.ClientIP(),
+        UserAgent:   truncateString(c.Request.UserAgent(), 255),
+        CreatedAt:   now,
+    }).Error; err != nil {
+        return nil, err
+    }
+    return stamped, nil
+}
+
+// serveWatermarked mengirim salinan ber-watermark. Range request tidak didukung karena setiap
+// salinan unik; viewer PDF akan mengunduh file utuh.
+func (h *PeraturanHandler) serveWatermarked(c *gin.Context, peraturan *models.Peraturan, disposition string) {
+    data, err := h.watermarkFile(c, peraturan)
+    switch {
+    case errors.Is(err, errWatermarkLogin):
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Login required to access this document"})
+        return
+    case errors.Is(err, storage.ErrNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "File not found on server"})
+        return
+    case err != nil:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to watermark document: " + err.Error()})
+        return
+    }
+
+    c.Header("Cache-Control", "private, no-store")
+    c.Header("Accept-Ranges", "none")
+    c.Header("Content-Disposition", contentDisposition(disposition, peraturan.NamaFile))
+    c.Header("X-Content-Type-Options", "nosniff")
+    c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// isPDFPeraturan mengecek file peraturan adalah PDF; record lama tanpa content type dilihat dari ekstensinya
+func isPDFPeraturan(p *models.Peraturan) bool {
+    if p.ContentType != "" {
+        return p.ContentType == upload.TypePDF.ContentType
+    }
+    return strings.EqualFold(filepath.Ext(p.NamaFile), upload.TypePDF.Ext)
+}
+
+// truncateString memotong s menjadi maksimal n byte
+func truncateString(s string, n int) string {
+    if len(s) <= n {
+        return s
+    }
+    return s[:n]
+}
+
+// VerifyWatermark - Melacak salinan dari kode verifikasi yang tercetak di dokumen
+func (h *PeraturanHandler) VerifyWatermark(c *gin.Context) {
+    var record models.UnduhanWatermark
+    if err := h.DB.Where("kode = ?", normalizeWatermarkCode(c.Param("kode"))).First(&record).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Verification code not found"})
+        return
+    }
+
+    // Peraturan atau user bisa sudah dihapus; catatan unduhan tetap dikembalikan
+    response := gin.H{"data": record}
+    var peraturan models.Peraturan
+    if h.DB.Preload("KategoriItems").Limit(1).Find(&peraturan, record.PeraturanID).RowsAffected > 0 {
+        response["peraturan"] = peraturan
+    }
+    if record.UserID != nil {
+        var user models.User
+        if h.DB.Limit(1).Find(&user, *record.UserID).RowsAffected > 0 {
+            response["user"] = user
+        }
+    }
+    c.JSON(http.StatusOK, response)
+}
+
+// GetWatermarkLog - Riwayat salinan ber-watermark untuk satu peraturan
+func (h *PeraturanHandler) GetWatermarkLog(c *gin.Context) {
+    var records []models.UnduhanWatermark
+    if err := h.DB.Where("peraturan_id = ?", c.Param("id")).
+        Order("created_at desc").Limit(500).Find(&records).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watermark log"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"data": records})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReadingListHandler struct {
+    DB *gorm.DB
+}
+
+type ReadingListRequest struct {
+    Nama      string `json:"nama" binding:"required"`
+    Deskripsi string `json:"deskripsi"`
+}
+
+type ReadingListItemRequest struct {
+    PeraturanID uint   `json:"peraturan_id" binding:"required"`
+    Catatan     string `json:"catatan"`
+}
+
+type ReadingListShareRequest struct {
+    Shares []struct {
+        UserID   int64  `json:"user_id"`
+        Username string `json:"username"`
+        CanEdit  bool   `json:"can_edit"`
+    } `json:"shares"`
+}
+
+// readingListAccess adalah hak akses user terhadap satu daftar bacaan
+type readingListAccess int
+
+const (
+    accessNone readingListAccess = iota
+    accessRead
+    accessEdit
+    accessOwner
+)
+
+// loadReadingList mengambil daftar bacaan dan hak akses user terhadapnya.
+// Daftar yang tidak bisa diakses dilaporkan sebagai tidak ditemukan.
+func (h *ReadingListHandler) loadReadingList(c *gin.Context, minimum readingListAccess) (*models.ReadingList, readingListAccess, bool) {
+    user := c.MustGet("user").(models.User)
+
+    var list models.ReadingList
+    if err := h.DB.First(&list, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Reading list not found"})
+        return nil, accessNone, false
+    }
+
+    access := accessNone
+    if list.UserID == user.ID {
+        access = accessOwner
+    } else {
+        var share models.ReadingListShare
+        if err := h.DB.Where("reading_list_id = ? AND user_id = ?", list.ID, user.ID).First(&share).Error; err == nil {
+            access = accessRead
+            if share.CanEdit {
+                access = accessEdit
+            }
+        }
+    }
+
+    if access == accessNone {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Reading list not found"})
+        return nil, access, false
+    }
+    if access < minimum {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to modify this reading list"})
+        return nil, access, false
+    }
+    return &list, access, true
+}
+
+// GetReadingLists - Daftar bacaan milik user dan yang dibagikan kepadanya
+func (h *ReadingListHandler) GetReadingLists(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var owned, shared []models.ReadingList
+    if err := h.DB.Where("user_id = ?", user.ID).Order("nama asc").Find(&owned).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading lists"})
+        return
+    }
+    if err := h.DB.Preload("Owner").
+        Where("id IN (?)", h.DB.Model(&models.ReadingListShare{}).Select("reading_list_id").Where("user_id = ?", user.ID)).
+        Order("nama asc").Find(&shared).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading lists"})
+        return
+    }
+
+    // Jumlah item per daftar untuk badge di sidebar
+    ids := []uint{}
+    for _, list := range append(owned, shared...) {
+        ids = append(ids, list.ID)
+    }
+    var counts []struct {
+        ReadingListID uint
+        Jumlah        int64
+    }
+    h.DB.Model(&models.ReadingListItem{}).Select("reading_list_id, COUNT(*) AS jumlah").
+        Where("reading_list_id IN ? AND peraturan_id IN (?)", ids, visibleIDs(h.DB, &user)).
+        Group("reading_list_id").Scan(&counts)
+    jumlah := map[uint]int64{}
+    for _, row := range counts {
+        jumlah[row.ReadingListID] = row.Jumlah
+    }
+
+    summarize := func(lists []models.ReadingList) []gin.H {
+        result := make([]gin.H, 0, len(lists))
+        for _, list := range lists {
+            result = append(result, gin.H{
+                "id":          list.ID,
+                "nama":        list.Nama,
+                "deskripsi":   list.Deskripsi,
+                "owner":       list.Owner,
+                "jumlah_item": jumlah[list.ID],
+                "updated_at":  list.UpdatedAt,
+            })
+        }
+        return result
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "data": gin.H{
+            "owned":  summarize(owned),
+            "shared": summarize(shared),
+        },
+    })
+}
+
+// GetReadingList - Detail daftar bacaan beserta item dan anggota yang dibagi
+func (h *ReadingListHandler) GetReadingList(c *gin.Context) {
+    list, access, ok := h.loadReadingList(c, accessRead)
+    if !ok {
+        return
+    }
+    user := c.MustGet("user").(models.User)
+
+    // Item yang peraturannya tidak boleh dilihat user ini (mis. daftar dibagi antar unit) disembunyikan
+    if err := h.DB.
+        Preload("Items", func(db *gorm.DB) *gorm.DB {
+            return db.Where("peraturan_id IN (?)", visibleIDs(h.DB, &user)).Order("urutan asc, id asc")
+        }).
+        Preload("Items.Peraturan.KategoriItems").
+        Preload("Shares.User").
+        Preload("Owner").
+        First(list, list.ID).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading list"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "data":     list,
+        "is_owner": access == accessOwner,
+        "can_edit": access >= accessEdit,
+    })
+}
+
+// CreateReadingList - Membuat daftar bacaan baru
+func (h *ReadingListHandler) CreateReadingList(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var req ReadingListRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    list := models.ReadingList{UserID: user.ID, Nama: strings.TrimSpace(req.Nama), Deskripsi: req.Deskripsi}
+    if err := h.DB.Create(&list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reading list"})
+        return
+    }
+    c.JSON(http.StatusCreated, gin.H{"message": "Reading list created", "data": list})
+}
+
+// UpdateReadingList - Mengubah nama/deskripsi (hanya pemilik)
+func (h *ReadingListHandler) UpdateReadingList(c *gin.Context) {
+    list, _, ok := h.loadReadingList(c, accessOwner)
+    if !ok {
+        return
+    }
+
+    var req ReadingListRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    list.Nama = strings.TrimSpace(req.Nama)
+    list.Deskripsi = req.Deskripsi
+    if err := h.DB.Save(list).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reading list"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Reading list updated", "data": list})
+}
+
+// DeleteReadingList - Menghapus daftar bacaan beserta item dan share (hanya pemilik)
+func (h *ReadingListHandler) DeleteReadingList(c *gin.Context) {
+    list, _, ok := h.loadReadingList(c, accessOwner)
+    if !ok {
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("reading_list_id = ?", list.ID).Delete(&models.ReadingListItem{}).Error; err != nil {
+            return err
+        }
+        if err := tx.Where("reading_list_id = ?", list.ID).Delete(&models.ReadingListShare{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(list).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reading list"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Reading list deleted"})
+}
+
+// AddReadingListItem - Menambahkan peraturan ke daftar (pemilik atau anggota dengan can_edit)
+func (h *ReadingListHandler) AddReadingListItem(c *gin.Context) {
+    list, _, ok := h.loadReadingList(c, accessEdit)
+    if !ok {
+        return
+    }
+
+    var req ReadingListItemRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    user := c.MustGet("user").(models.User)
+    var peraturan models.Peraturan
+    if err := h.DB.Preload("KategoriItems").First(&peraturan, req.PeraturanID).Error; err != nil || !canView(h.DB, &peraturan, &user) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Peraturan not found"})
+        return
+    }
+
+    // Item baru ditaruh di akhir daftar
+    var last int
+    h.DB.Model(&models.ReadingListItem{}).Select("COALESCE(MAX(urutan), 0)").Where("reading_list_id = ?", list.ID).Scan(&last)
+
+    item := models.ReadingListItem{ReadingListID: list.ID, PeraturanID: peraturan.ID, Urutan: last + 1, Catatan: req.Catatan}
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Clauses(clause.OnConflict{
+            Columns:   []clause.Column{{Name: "reading_list_id"}, {Name: "peraturan_id"}},
+            DoUpdates: clause.AssignmentColumns([]string{"catatan"}),
+        }).Create(&item).Error; err != nil {
+            return err
+        }
+        return tx.Model(list).UpdateColumn("updated_at", time.Now()).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item"})
+        return
+    }
+
+    h.DB.Where("reading_list_id = ? AND peraturan_id = ?", list.ID, peraturan.ID).First(&item)
+    item.Peraturan = &peraturan
+    c.JSON(http.StatusOK, gin.H{"message": "Item added", "data": item})
+}
+
+// RemoveReadingListItem - Menghapus peraturan dari daftar
+func (h *ReadingListHandler) RemoveReadingListItem(c *gin.Context) {
+    list, _, ok := h.loadReadingList(c, accessEdit)
+    if !ok {
+        return
+    }
+
+    result := h.DB.Where("reading_list_id = ? AND peraturan_id = ?", list.ID, c.Param("peraturanId")).Delete(&models.ReadingListItem{})
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove item"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+        return
+    }
+    h.DB.Model(list).UpdateColumn("updated_at", time.Now())
+    c.JSON(http.StatusOK, gin.H{"message": "Item removed"})
+}
+
+// ShareReadingList - Mengganti daftar anggota tim yang bisa melihat daftar bacaan (hanya pemilik).
+// Anggota bisa ditentukan dengan user_id atau username.
+func (h *ReadingListHandler) ShareReadingList(c *gin.Context) {
+    list, _, ok := h.loadReadingList(c, accessOwner)
+    if !ok {
+        return
+    }
+
+    var req ReadingListShareRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    shares := []models.ReadingListShare{}
+    seen := map[int64]bool{}
+    for _, s := range req.Shares {
+        var user models.User
+        query := h.DB.Where("id = ?", s.UserID)
+        if s.Username != "" {
+            query = h.DB.Where("username = ?", s.Username)
+        }
+        if err := query.First(&user).Error; err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("User not found: %s (id %d)", s.Username, s.UserID)})
+            return
+        }
+        if user.ID == list.UserID || seen[user.ID] {
+            continue
+        }
+        seen[user.ID] = true
+        shares = append(shares, models.ReadingListShare{ReadingListID: list.ID, UserID: user.ID, CanEdit: s.CanEdit, CreatedAt: time.Now()})
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("reading_list_id = ?", list.ID).Delete(&models.ReadingListShare{}).Error; err != nil {
+            return err
+        }
+        if len(shares) == 0 {
+            return nil
+        }
+        return tx.Create(&shares).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share reading list"})
+        return
+    }
+
+    h.DB.Preload("User").Where("reading_list_id = ?", list.ID).Find(&shares)
+    c.JSON(http.StatusOK, gin.H{"message": "Reading list shared", "data": shares})
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"backend/access"
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RoleHandler struct {
+    DB *gorm.DB
+}
+
+// roleNamaPattern membatasi nama role agar aman dipakai di users.role dan izin peraturan
+var roleNamaPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+type RoleRequest struct {
+    Nama        string   `json:"nama"` // Hanya saat membuat role; tidak bisa diubah
+    Label       string   `json:"label" binding:"required"`
+    Deskripsi   string   `json:"deskripsi"`
+    Permissions []string `json:"permissions"`
+}
+
+// RoleResponse adalah role beserta daftar permission dan jumlah user yang memakainya
+type RoleResponse struct {
+    models.Role
+    Permissions []string `json:"permissions"`
+    UserCount   int64    `json:"user_count"`
+}
+
+func (h *RoleHandler) roleResponse(role models.Role) RoleResponse {
+    permissions := []string{}
+    if role.Nama == models.RoleAdmin {
+        permissions = models.PermissionCodes()
+    } else {
+        for _, p := range role.Permissions {
+            permissions = append(permissions, p.Permission)
+        }
+        sort.Strings(permissions)
+    }
+    var count int64
+    h.DB.Model(&models.User{}).Where("role = ?", role.Nama).Count(&count)
+    return RoleResponse{Role: role, Permissions: permissions, UserCount: count}
+}
+
+// missingPermissions mengembalikan permission dalam codes yang tidak dimiliki user
+func missingPermissions(user models.User, codes []string) []string {
+    missing := []string{}
+    for _, code := range codes {
+        if !user.Can(code) {
+            missing = append(missing, code)
+        }
+    }
+    return missing
+}
+
+// missingRolePermissions mengembalikan permission role nama yang tidak dimiliki user. Dipakai
+// agar user tidak bisa memberikan (atau mencabut) hak yang lebih besar dari miliknya sendiri.
+func missingRolePermissions(db *gorm.DB, user models.User, nama string) ([]string, error) {
+    role := models.User{Role: nama}
+    if err := access.Load(db, &role); err != nil {
+        return nil, err
+    }
+    return missingPermissions(user, role.Permissions), nil
+}
+
+// validatePermissions membuang duplikat dan menolak kode yang tidak ada di katalog
+func validatePermissions(codes []string) ([]models.RolePermission, error) {
+    seen := map[string]bool{}
+    var result []models.RolePermission
+    for _, code := range codes {
+        code = strings.TrimSpace(code)
+        if !models.IsValidPermission(code) {
+            return nil, fmt.Errorf("unknown permission: %s", code)
+        }
+        if !seen[code] {
+            seen[code] = true
+            result = append(result, models.RolePermission{Permission: code})
+        }
+    }
+    return result, nil
+}
+
+// checkGrant menolak permission yang tidak dimiliki pemanggil. Mengembalikan false jika
+// response error sudah dikirim.
+func (h *RoleHandler) checkGrant(c *gin.Context, codes []string) bool {
+    caller := c.MustGet("user").(models.User)
+    if missing := missingPermissions(caller, codes); len(missing) > 0 {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You cannot grant permissions you do not hold", "permissions": missing})
+        return false
+    }
+    return true
+}
+
+// checkRoleEditable menolak perubahan pada role pemanggil sendiri dan pada role yang memiliki
+// permission di luar hak pemanggil. Mengembalikan false jika response error sudah dikirim.
+func (h *RoleHandler) checkRoleEditable(c *gin.Context, role models.Role) bool {
+    caller := c.MustGet("user").(models.User)
+    if role.Nama == caller.Role {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You cannot modify your own role"})
+        return false
+    }
+    missing, err := missingRolePermissions(h.DB, caller, role.Nama)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load role permissions"})
+        return false
+    }
+    if len(missing) > 0 {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Role has permissions you do not hold", "permissions": missing})
+        return false
+    }
+    return true
+}
+
+// GetPermissions - Katalog permission yang bisa diberikan ke role
+func (h *RoleHandler) GetPermissions(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{"data": models.AllPermissions})
+}
+
+// GetRoles - Daftar role beserta permission-nya
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+    var roles []models.Role
+    if err := h.DB.Preload("Permissions").Order("system desc, nama asc").Find(&roles).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+        return
+    }
+    data := make([]RoleResponse, 0, len(roles))
+    for _, role := range roles {
+        data = append(data, h.roleResponse(role))
+    }
+    c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// CreateRole - Membuat role baru
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+    var req RoleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    req.Nama = strings.TrimSpace(req.Nama)
+    if !roleNamaPattern.MatchString(req.Nama) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "nama must be 2-50 lowercase letters, digits or underscores, starting with a letter"})
+        return
+    }
+    permissions, err := validatePermissions(req.Permissions)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    if !h.checkGrant(c, req.Permissions) {
+        return
+    }
+
+    var count int64
+    if err := h.DB.Model(&models.Role{}).Where("nama = ?", req.Nama).Count(&count).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check role"})
+        return
+    }
+    if count > 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "Role already exists"})
+        return
+    }
+
+    role := models.Role{
+        Nama:        req.Nama,
+        Label:       strings.TrimSpace(req.Label),
+        Deskripsi:   req.Deskripsi,
+        Permissions: permissions,
+    }
+    if err := h.DB.Create(&role).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create role"})
+        return
+    }
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Role created successfully",
+        "data":    h.roleResponse(role),
+    })
+}
+
+// UpdateRole - Mengubah label, deskripsi, dan permission role. Permission admin tidak bisa diubah.
+// Perubahan langsung berlaku karena permission dimuat ulang di setiap request.
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+    var role models.Role
+    if err := h.DB.First(&role, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+        return
+    }
+
+    var req RoleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.Nama != "" && req.Nama != role.Nama {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Role nama cannot be changed"})
+        return
+    }
+    permissions, err := validatePermissions(req.Permissions)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !h.checkRoleEditable(c, role) || !h.checkGrant(c, req.Permissions) {
+        return
+    }
+
+    err = h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&role).Updates(map[string]interface{}{
+            "label":     strings.TrimSpace(req.Label),
+            "deskripsi": req.Deskripsi,
+        }).Error; err != nil {
+            return err
+        }
+        if role.Nama == models.RoleAdmin {
+            return nil
+        }
+        if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+            return err
+        }
+        for i := range permissions {
+            permissions[i].RoleID = role.ID
+        }
+        if len(permissions) > 0 {
+            return tx.Create(&permissions).Error
+        }
+        return nil
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+        return
+    }
+
+    h.DB.Preload("Permissions").First(&role, role.ID)
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Role updated successfully",
+        "data":    h.roleResponse(role),
+    })
+}
+
+// DeleteRole - Menghapus role yang bukan bawaan dan tidak sedang dipakai user
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+    var role models.Role
+    if err := h.DB.First(&role, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+        return
+    }
+    if role.System {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Built-in roles cannot be deleted"})
+        return
+    }
+    if !h.checkRoleEditable(c, role) {
+        return
+    }
+    var count int64
+    if err := h.DB.Model(&models.User{}).Where("role = ?", role.Nama).Count(&count).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count role users"})
+        return
+    }
+    if count > 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Role is still assigned to %d users", count)})
+        return
+    }
+
+    err := h.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermission{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&role).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete role"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
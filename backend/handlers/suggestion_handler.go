@@ -30,12 +30,40 @@ func (h *SuggestionHandler) GetSuggestions(c *gin.Context) {
     c.JSON(http.StatusOK, suggestions)
 }
 
+// suggestionRequest sama dengan models.Suggestion tanpa validasi required, karena identitas
+// pegawai yang login diisi otomatis dari data kepegawaian
+type suggestionRequest struct {
+    Nama   string `json:"nama"`
+    NIP    string `json:"nip"`
+    Unit   string `json:"unit"`
+    Bidang string `json:"bidang"`
+    Saran  string `json:"saran" binding:"required"`
+}
+
 func (h *SuggestionHandler) CreateSuggestion(c *gin.Context) {
-    var suggestion models.Suggestion
-    if err := c.ShouldBindJSON(&suggestion); err != nil {
+    var req suggestionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
+    suggestion := models.Suggestion{Nama: req.Nama, NIP: req.NIP, Unit: req.Unit, Bidang: req.Bidang, Saran: req.Saran}
+
+    // Pegawai yang login dan sudah terverifikasi tidak perlu mengisi identitasnya
+    if user := viewerFromContext(c); user != nil {
+        if employee := user.VerifiedEmployee(); employee != nil {
+            suggestion.Nama = employee.Nama
+            suggestion.NIP = employee.NIP
+            suggestion.Bidang = employee.Bidang
+            suggestion.Unit = user.Unit
+            if suggestion.Unit == "" {
+                suggestion.Unit = employee.Bidang
+            }
+        }
+    }
+    if suggestion.Nama == "" || suggestion.Unit == "" || suggestion.Bidang == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "nama, unit and bidang are required"})
+        return
+    }
 
      // Debug: Log data yang diterima
     log.Printf("Received data: %+v", suggestion)
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Keputusan atas klaim NIP yang belum diverifikasi saat pendaftaran disetujui
+const (
+    EmployeeClaimVerify = "verify" // Klaim diterima, sama seperti POST /admin/users/:id/employee/verify
+    EmployeeClaimDrop   = "drop"   // Klaim dibuang, user aktif tanpa data pegawai
+)
+
+type UserStatusRequest struct {
+    Alasan   string `json:"alasan"`
+    Employee string `json:"employee"` // verify / drop; wajib saat menyetujui akun dengan klaim NIP tertunda
+}
+
+// loginStatusError mengembalikan pesan jika status akun tidak boleh login
+func loginStatusError(user models.User) string {
+    switch user.Status {
+    case models.UserActive:
+        return ""
+    case models.UserPending:
+        return "Account is waiting for admin approval"
+    case models.UserRejected:
+        return "Account registration was rejected"
+    default:
+        return "Account has been suspended"
+    }
+}
+
+// GetPendingUsers - Pendaftar yang menunggu persetujuan, paling lama di atas
+func (h *UserHandler) GetPendingUsers(c *gin.Context) {
+    var users []models.User
+    if err := h.DB.Preload("Employee").Where("status = ?", models.UserPending).Order("created_at asc").Find(&users).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pending users"})
+        return
+    }
+    c.JSON(http.StatusOK, users)
+}
+
+// ApproveUser - Menyetujui pendaftaran, atau mengaktifkan kembali akun yang ditolak/ditangguhkan.
+// Jika user masih punya klaim NIP yang belum diverifikasi, admin harus memilih employee
+// "verify" atau "drop" agar klaim tidak ikut aktif tanpa diperiksa.
+func (h *UserHandler) ApproveUser(c *gin.Context) {
+    h.updateStatus(c, models.UserActive, []string{models.UserPending, models.UserRejected, models.UserSuspended}, false)
+}
+
+// RejectUser - Menolak pendaftaran yang masih menunggu persetujuan; klaim NIP-nya dilepas
+// sehingga NIP bisa diklaim akun lain
+func (h *UserHandler) RejectUser(c *gin.Context) {
+    h.updateStatus(c, models.UserRejected, []string{models.UserPending}, true)
+}
+
+// SuspendUser - Menonaktifkan akun aktif; semua session-nya langsung dicabut
+func (h *UserHandler) SuspendUser(c *gin.Context) {
+    h.updateStatus(c, models.UserSuspended, []string{models.UserActive}, true)
+}
+
+// updateStatus mengubah status akun jika status saat ini termasuk from, lalu memberi tahu
+// pemilik akun lewat email. withAlasan memakai alasan dari body JSON (boleh kosong).
+func (h *UserHandler) updateStatus(c *gin.Context, status string, from []string, withAlasan bool) {
+    admin := c.MustGet("user").(models.User)
+
+    var req UserStatusRequest
+    if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !withAlasan {
+        req.Alasan = ""
+    }
+
+    var user models.User
+    if err := h.DB.First(&user, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if user.ID == admin.ID {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change the status of your own account"})
+        return
+    }
+    if !h.checkManageable(c, user) {
+        return
+    }
+    allowed := false
+    for _, s := range from {
+        allowed = allowed || user.Status == s
+    }
+    if !allowed {
+        c.JSON(http.StatusConflict, gin.H{"error": "Cannot change account status from " + user.Status + " to " + status})
+        return
+    }
+
+    now := time.Now()
+    updates := map[string]interface{}{
+        "status":            status,
+        "status_alasan":     strings.TrimSpace(req.Alasan),
+        "status_updated_at": now,
+        "status_updated_by": admin.ID,
+    }
+    if !h.resolveEmployeeClaim(c, &user, status, req.Employee, updates) {
+        return
+    }
+    user.Status = status
+    user.StatusAlasan = strings.TrimSpace(req.Alasan)
+    user.StatusUpdatedAt = &now
+    user.StatusUpdatedBy = &admin.ID
+    // Kondisi status lama ikut di WHERE agar dua admin yang memproses bersamaan tidak saling menimpa
+    result := h.DB.Model(&models.User{}).Where("id = ? AND status IN ?", user.ID, from).Updates(updates)
+    if result.Error != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account status"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "Account status was changed by another request"})
+        return
+    }
+
+    if status != models.UserActive {
+        if err := session.RevokeUser(h.DB, user.ID, 0); err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke user sessions"})
+            return
+        }
+    }
+    h.Notifier.AccountStatusChanged(user)
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Account status updated to " + status,
+        "user":    user,
+    })
+}
+
+// resolveEmployeeClaim menambahkan perubahan klaim NIP yang belum diverifikasi ke updates:
+// klaim dilepas saat pendaftaran ditolak, dan harus diverifikasi atau dibuang saat disetujui.
+// Mengembalikan false jika response error sudah dikirim.
+func (h *UserHandler) resolveEmployeeClaim(c *gin.Context, user *models.User, status, decision string, updates map[string]interface{}) bool {
+    if user.EmployeeID == nil || user.EmployeeVerifiedAt != nil {
+        return true
+    }
+
+    drop := func() {
+        updates["employee_id"] = nil
+        updates["employee_verified_at"] = nil
+        updates["employee_verified_by"] = nil
+        user.EmployeeID = nil
+    }
+    switch {
+    case status == models.UserRejected:
+        drop()
+    case status != models.UserActive:
+        // Penangguhan tidak mengubah klaim; klaim tetap bisa diverifikasi lewat endpoint employee
+    case decision == EmployeeClaimDrop:
+        drop()
+    case decision == EmployeeClaimVerify:
+        var employee models.Employee
+        if err := h.DB.First(&employee, *user.EmployeeID).Error; err != nil {
+            c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+            return false
+        }
+        admin := c.MustGet("user").(models.User)
+        now := time.Now()
+        updates["employee_verified_at"] = now
+        updates["employee_verified_by"] = admin.ID
+        user.EmployeeVerifiedAt = &now
+        user.EmployeeVerifiedBy = &admin.ID
+        if employee.Bidang != "" {
+            updates["unit"] = employee.Bidang
+            user.Unit = employee.Bidang
+        }
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "User has a pending employee claim, set employee to \"" +
+            EmployeeClaimVerify + "\" or \"" + EmployeeClaimDrop + "\""})
+        return false
+    }
+    return true
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+    // Pesan sama untuk NIP tidak ada dan tanggal lahir salah agar NIP tidak bisa ditebak
+    errEmployeeNotFound = errors.New("NIP not found or tgl_lahir does not match")
+    errEmployeeTaken    = errors.New("This NIP is already linked to another account")
+)
+
+type EmployeeClaimRequest struct {
+    NIP      string `json:"nip" binding:"required"`
+    TglLahir string `json:"tgl_lahir" binding:"required"` // YYYY-MM-DD, harus sama dengan data pegawai
+}
+
+type EmployeeBindRequest struct {
+    NIP string `json:"nip" binding:"required"`
+}
+
+// findEmployeeForUser mencari pegawai dari NIP untuk dihubungkan ke userID. Klaim oleh user
+// sendiri harus menyertakan tanggal lahir yang cocok; admin memanggil dengan tglLahir kosong.
+func findEmployeeForUser(db *gorm.DB, nip, tglLahir string, userID int64) (*models.Employee, error) {
+    var employee models.Employee
+    if err := db.Where("nip = ?", strings.TrimSpace(nip)).First(&employee).Error; err != nil {
+        return nil, errEmployeeNotFound
+    }
+    if tglLahir != "" && employee.TglLahir.Format("2006-01-02") != strings.TrimSpace(tglLahir) {
+        return nil, errEmployeeNotFound
+    }
+
+    var taken int64
+    if err := db.Model(&models.User{}).Where("employee_id = ? AND id <> ?", employee.ID, userID).Count(&taken).Error; err != nil {
+        return nil, err
+    }
+    if taken > 0 {
+        return nil, errEmployeeTaken
+    }
+    return &employee, nil
+}
+
+// employeeError mengirim response untuk error dari findEmployeeForUser
+func employeeError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, errEmployeeNotFound):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, errEmployeeTaken):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find employee"})
+    }
+}
+
+// syncEmployeeUnit menyamakan unit user dengan bidang pegawai yang sudah diverifikasi.
+// Data pegawai diperbarui di luar aplikasi, jadi dipanggil lagi setiap login.
+func syncEmployeeUnit(db *gorm.DB, user *models.User) error {
+    employee := user.VerifiedEmployee()
+    if employee == nil || employee.Bidang == "" || user.Unit == employee.Bidang {
+        return nil
+    }
+    user.Unit = employee.Bidang
+    return db.Model(user).Update("unit", user.Unit).Error
+}
+
+// employeeProfile adalah data pegawai untuk /auth/me: status hubungan, profil, atasan, dan bidang
+func employeeProfile(c *gin.Context, db *gorm.DB, user models.User) gin.H {
+    switch {
+    case user.EmployeeID == nil:
+        return gin.H{"employee_status": "none"}
+    case user.EmployeeVerifiedAt == nil:
+        return gin.H{"employee_status": "pending"}
+    }
+
+    var employee models.Employee
+    if err := db.Preload("AtasanLangsung").First(&employee, *user.EmployeeID).Error; err != nil {
+        return gin.H{"employee_status": "none"}
+    }
+    redactEmployee(c, employee.AtasanLangsung)
+    return gin.H{
+        "employee_status": "verified",
+        "employee":        employee,
+        "nip":             employee.NIP,
+        "bidang":          employee.Bidang,
+        "atasan":          employee.AtasanLangsung,
+    }
+}
+
+// ClaimEmployee - User menghubungkan akunnya ke data pegawai dengan NIP dan tanggal lahir.
+// Hubungan baru dipakai setelah diverifikasi admin.
+func (h *AuthHandler) ClaimEmployee(c *gin.Context) {
+    user := c.MustGet("user").(models.User)
+
+    var req EmployeeClaimRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if user.EmployeeVerifiedAt != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": "Account is already linked to a verified employee, contact an admin to change it"})
+        return
+    }
+
+    employee, err := findEmployeeForUser(h.DB, req.NIP, req.TglLahir, user.ID)
+    if err != nil {
+        employeeError(c, err)
+        return
+    }
+    if err := h.DB.Model(&user).Updates(map[string]interface{}{
+        "employee_id":          employee.ID,
+        "employee_verified_at": nil,
+        "employee_verified_by": nil,
+    }).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link employee"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":         "Employee link submitted, waiting for admin verification",
+        "employee_status": "pending",
+    })
+}
+
+// BindEmployee - Admin menghubungkan user ke pegawai dari NIP; langsung terverifikasi
+func (h *UserHandler) BindEmployee(c *gin.Context) {
+    var req EmployeeBindRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := h.DB.First(&user, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if !h.checkManageable(c, user) {
+        return
+    }
+    employee, err := findEmployeeForUser(h.DB, req.NIP, "", user.ID)
+    if err != nil {
+        employeeError(c, err)
+        return
+    }
+    h.verifyEmployee(c, user, employee)
+}
+
+// VerifyEmployee - Admin menyetujui NIP yang diklaim user
+func (h *UserHandler) VerifyEmployee(c *gin.Context) {
+    var user models.User
+    if err := h.DB.First(&user, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if !h.checkManageable(c, user) {
+        return
+    }
+    if user.EmployeeID == nil || user.EmployeeVerifiedAt != nil {
+        c.JSON(http.StatusConflict, gin.H{"error": "User has no pending employee link"})
+        return
+    }
+    var employee models.Employee
+    if err := h.DB.First(&employee, *user.EmployeeID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+        return
+    }
+    h.verifyEmployee(c, user, &employee)
+}
+
+// verifyEmployee menyimpan hubungan terverifikasi dan menyamakan unit user dengan bidang pegawai.
+// Unit menentukan akses peraturan terbatas, jadi session user dirotasi.
+func (h *UserHandler) verifyEmployee(c *gin.Context, user models.User, employee *models.Employee) {
+    admin := c.MustGet("user").(models.User)
+    now := time.Now()
+    updates := map[string]interface{}{
+        "employee_id":          employee.ID,
+        "employee_verified_at": now,
+        "employee_verified_by": admin.ID,
+    }
+    if employee.Bidang != "" {
+        updates["unit"] = employee.Bidang
+        user.Unit = employee.Bidang
+    }
+    if err := h.DB.Model(&user).Updates(updates).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link employee"})
+        return
+    }
+    if err := h.rotateSessions(c, user.ID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate user sessions: " + err.Error()})
+        return
+    }
+
+    user.EmployeeID = &employee.ID
+    user.EmployeeVerifiedAt = &now
+    user.EmployeeVerifiedBy = &admin.ID
+    user.Employee = employee
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Employee linked successfully",
+        "user":    user,
+    })
+}
+
+// UnbindEmployee - Admin melepas hubungan user dengan pegawai (klaim maupun terverifikasi).
+// Unit user tidak diubah; atur lewat PUT /admin/users/:id/unit jika perlu.
+func (h *UserHandler) UnbindEmployee(c *gin.Context) {
+    var user models.User
+    if err := h.DB.First(&user, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if !h.checkManageable(c, user) {
+        return
+    }
+    if err := h.DB.Model(&user).Updates(map[string]interface{}{
+        "employee_id":          nil,
+        "employee_verified_at": nil,
+        "employee_verified_by": nil,
+    }).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink employee"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Employee unlinked successfully"})
+}
@@ -2,22 +2,33 @@ package handlers
 
 import (
 	"backend/models"
-	"fmt"
+	"backend/notify"
+	"backend/session"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-    DB *gorm.DB
+    DB       *gorm.DB
+    Notifier *notify.Notifier
 }
 
-// GetUsers mendapatkan semua pengguna (hanya untuk admin)
+// GetUsers mendapatkan semua pengguna (hanya untuk admin), bisa disaring dengan ?status=
+// dan ?employee=pending (klaim NIP yang menunggu verifikasi)
 func (h *UserHandler) GetUsers(c *gin.Context) {
+    query := h.DB.Preload("Employee")
+    if status := c.Query("status"); status != "" {
+        query = query.Where("status = ?", status)
+    }
+    if c.Query("employee") == "pending" {
+        query = query.Where("employee_id IS NOT NULL AND employee_verified_at IS NULL")
+    }
     var users []models.User
-    if err := h.DB.Find(&users).Error; err != nil {
+    if err := query.Find(&users).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
         return
     }
@@ -30,70 +41,156 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
     c.JSON(http.StatusOK, users)
 }
 
+// UpdateUserRole mengganti role user. Pemanggil tidak boleh mengubah role-nya sendiri, dan hanya
+// boleh memberi atau mencabut role yang seluruh permission-nya juga ia miliki.
 func (h *UserHandler) UpdateUserRole(c *gin.Context) {
-    fmt.Println("[DEBUG] UpdateUserRole handler called")
-    
-    // Ambil ID dari parameter
-    userIDStr := c.Param("id")
-    fmt.Printf("[DEBUG] User ID: %s\n", userIDStr)
-    
-    userID, err := strconv.ParseInt(userIDStr, 10, 64)
+    caller := c.MustGet("user").(models.User)
+
+    userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
     if err != nil {
-        fmt.Printf("[ERROR] Invalid user ID: %v\n", err)
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
         return
     }
-    
-    // Log request body
-    var body map[string]interface{}
-    if err := c.ShouldBindJSON(&body); err != nil {
-        fmt.Printf("[ERROR] Error binding request: %v\n", err)
+
+    var req struct {
+        Role string `json:"role" binding:"required"`
+    }
+    if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
-    fmt.Printf("[DEBUG] Request body: %+v\n", body)
-    
-    // Validasi role
-    role, ok := body["role"].(string)
-    if !ok || (role != "user" && role != "admin") {
-        fmt.Printf("[ERROR] Invalid role: %v\n", body["role"])
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be either 'user' or 'admin'"})
+    role := req.Role
+
+    // Validasi role: harus salah satu role yang terdaftar
+    var roleCount int64
+    if err := h.DB.Model(&models.Role{}).Where("nama = ?", role).Count(&roleCount).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check role"})
+        return
+    }
+    if roleCount == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found, see GET /api/admin/roles"})
         return
     }
-    
-    fmt.Printf("[DEBUG] New role: %s\n", role)
-    
-    // Cari user di database
+
     var user models.User
     if err := h.DB.First(&user, userID).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
-            fmt.Printf("[ERROR] User not found with ID: %d\n", userID)
             c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
         } else {
-            fmt.Printf("[ERROR] Database error: %v\n", err)
             c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
         }
         return
     }
-    
-    fmt.Printf("[DEBUG] Found user: %+v\n", user)
-    
-    // Update role
+    if user.ID == caller.ID {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change your own role"})
+        return
+    }
+
+    // Role lama dan role baru tidak boleh melebihi hak pemanggil
+    for _, nama := range []string{user.Role, role} {
+        missing, err := missingRolePermissions(h.DB, caller, nama)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load role permissions"})
+            return
+        }
+        if len(missing) > 0 {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Role " + nama + " has permissions you do not hold", "permissions": missing})
+            return
+        }
+    }
+
+    // Admin terakhir tidak boleh diturunkan agar pengelolaan role tetap bisa dilakukan
+    if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+        var admins int64
+        if err := h.DB.Model(&models.User{}).Where("role = ? AND status = ?", models.RoleAdmin, models.UserActive).Count(&admins).Error; err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count admins"})
+            return
+        }
+        if admins <= 1 {
+            c.JSON(http.StatusConflict, gin.H{"error": "Cannot change the role of the last admin"})
+            return
+        }
+    }
+
     user.Role = role
-    if err := h.DB.Save(&user).Error; err != nil {
-        fmt.Printf("[ERROR] Error updating user role: %v\n", err)
+    if err := h.DB.Model(&user).Update("role", role).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role: " + err.Error()})
         return
     }
-    
-    fmt.Printf("[DEBUG] Successfully updated user role\n")
-    
+    if err := h.rotateSessions(c, user.ID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate user sessions: " + err.Error()})
+        return
+    }
+
     // Hapus password dari response
     user.Password = ""
-    
+
     c.JSON(http.StatusOK, gin.H{
         "success": true,
         "message": "User role updated successfully",
         "user":    user,
     })
-}
\ No newline at end of file
+}
+
+// UpdateUserUnit mengatur unit kerja user, dipakai untuk izin peraturan terbatas per unit
+func (h *UserHandler) UpdateUserUnit(c *gin.Context) {
+    var req struct {
+        Unit string `json:"unit"`
+    }
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := h.DB.First(&user, c.Param("id")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+
+    if !h.checkManageable(c, user) {
+        return
+    }
+
+    user.Unit = strings.TrimSpace(req.Unit)
+    if err := h.DB.Model(&user).Update("unit", user.Unit).Error; err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user unit: " + err.Error()})
+        return
+    }
+    if err := h.rotateSessions(c, user.ID); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate user sessions: " + err.Error()})
+        return
+    }
+
+    user.Password = ""
+    c.JSON(http.StatusOK, gin.H{
+        "success": true,
+        "message": "User unit updated successfully",
+        "user":    user,
+    })
+}
+
+// checkManageable menolak perubahan pada user yang role-nya memiliki permission di luar hak
+// pemanggil. Mengembalikan false jika response error sudah dikirim.
+func (h *UserHandler) checkManageable(c *gin.Context, user models.User) bool {
+    caller := c.MustGet("user").(models.User)
+    missing, err := missingRolePermissions(h.DB, caller, user.Role)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load role permissions"})
+        return false
+    }
+    if len(missing) > 0 {
+        c.JSON(http.StatusForbidden, gin.H{"error": "User has permissions you do not hold", "permissions": missing})
+        return false
+    }
+    return true
+}
+
+// rotateSessions dipanggil setelah hak akses user berubah: semua session user tersebut dicabut.
+// Jika admin mengubah akunnya sendiri, session request ini diganti token baru agar tetap login.
+func (h *UserHandler) rotateSessions(c *gin.Context, userID int64) error {
+    if current, ok := c.Get("user"); ok && current.(models.User).ID == userID {
+        return session.Rotate(c, h.DB, userID)
+    }
+    return session.RevokeUser(h.DB, userID, 0)
+}
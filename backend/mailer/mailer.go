@@ -0,0 +1,219 @@
+// Package mailer mengirim email. Sender bisa diganti: SMTP untuk server sungguhan maupun
+// mail catcher lokal (MailHog/Mailpit di localhost:1025), atau LogSender jika SMTP belum diatur.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message adalah email teks biasa
+type Message struct {
+    To      []string
+    Subject string
+    Body    string
+}
+
+// Sender mengirim satu email
+type Sender interface {
+    Send(ctx context.Context, msg Message) error
+}
+
+// LogSender hanya menulis email ke log (dipakai jika SMTP_HOST kosong). Nilai parameter token
+// di tautan disamarkan agar log tidak bisa dipakai untuk mengambil alih akun lewat reset password.
+type LogSender struct{}
+
+// tokenParam mencocokkan parameter query token=... di isi email
+var tokenParam = regexp.MustCompile(`([?&]token=)[^\s&]+`)
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+    body := tokenParam.ReplaceAllString(msg.Body, "${1}[REDACTED]")
+    log.Printf("INFO: Email to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, body)
+    return nil
+}
+
+// Mode TLS koneksi SMTP
+const (
+    TLSStartTLS = "starttls" // STARTTLS jika didukung server (default)
+    TLSImplicit = "tls"      // TLS sejak awal koneksi, biasanya port 465
+    TLSNone     = "none"     // Tanpa TLS, untuk mail catcher lokal
+)
+
+// SMTP mengirim email lewat server SMTP
+type SMTP struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+    TLS      string
+    Timeout  time.Duration
+}
+
+// FromEnv membaca SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM dan SMTP_TLS
+// (starttls|tls|none). Jika SMTP_HOST kosong email hanya ditulis ke log.
+// Contoh mail catcher lokal: SMTP_HOST=localhost SMTP_PORT=1025 SMTP_TLS=none
+func FromEnv() (Sender, error) {
+    host := os.Getenv("SMTP_HOST")
+    if host == "" {
+        return LogSender{}, nil
+    }
+
+    s := &SMTP{
+        Host:     host,
+        Port:     os.Getenv("SMTP_PORT"),
+        Username: os.Getenv("SMTP_USERNAME"),
+        Password: os.Getenv("SMTP_PASSWORD"),
+        From:     os.Getenv("SMTP_FROM"),
+        TLS:      strings.ToLower(os.Getenv("SMTP_TLS")),
+        Timeout:  30 * time.Second,
+    }
+    if s.Port == "" {
+        s.Port = "587"
+    }
+    if s.TLS == "" {
+        s.TLS = TLSStartTLS
+    }
+    if s.TLS != TLSStartTLS && s.TLS != TLSImplicit && s.TLS != TLSNone {
+        return nil, fmt.Errorf("unsupported SMTP_TLS %q", s.TLS)
+    }
+    if _, err := mail.ParseAddress(s.From); err != nil {
+        return nil, fmt.Errorf("invalid SMTP_FROM %q: %v", s.From, err)
+    }
+    return s, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+    if len(msg.To) == 0 {
+        return errors.New("email has no recipient")
+    }
+    from, err := mail.ParseAddress(s.From)
+    if err != nil {
+        return fmt.Errorf("invalid sender: %v", err)
+    }
+    to := make([]*mail.Address, 0, len(msg.To))
+    for _, addr := range msg.To {
+        a, err := mail.ParseAddress(addr)
+        if err != nil {
+            return fmt.Errorf("invalid recipient %q: %v", addr, err)
+        }
+        to = append(to, a)
+    }
+
+    if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > s.Timeout {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+        defer cancel()
+    }
+
+    client, err := s.dial(ctx)
+    if err != nil {
+        return err
+    }
+    defer client.Close()
+
+    if s.Username != "" {
+        if ok, _ := client.Extension("AUTH"); !ok {
+            return errors.New("smtp server does not support AUTH")
+        }
+        if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+            return fmt.Errorf("smtp auth: %w", err)
+        }
+    }
+    if err := client.Mail(from.Address); err != nil {
+        return fmt.Errorf("smtp MAIL FROM: %w", err)
+    }
+    for _, a := range to {
+        if err := client.Rcpt(a.Address); err != nil {
+            return fmt.Errorf("smtp RCPT TO %s: %w", a.Address, err)
+        }
+    }
+
+    w, err := client.Data()
+    if err != nil {
+        return fmt.Errorf("smtp DATA: %w", err)
+    }
+    if _, err := w.Write(buildMessage(from, to, msg)); err != nil {
+        w.Close()
+        return fmt.Errorf("smtp write: %w", err)
+    }
+    if err := w.Close(); err != nil {
+        return fmt.Errorf("smtp DATA: %w", err)
+    }
+    return client.Quit()
+}
+
+// dial membuka koneksi SMTP sesuai mode TLS
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+    addr := net.JoinHostPort(s.Host, s.Port)
+    tlsConfig := &tls.Config{ServerName: s.Host}
+
+    var d net.Dialer
+    conn, err := d.DialContext(ctx, "tcp", addr)
+    if err != nil {
+        return nil, fmt.Errorf("smtp unavailable: %w", err)
+    }
+    if deadline, ok := ctx.Deadline(); ok {
+        conn.SetDeadline(deadline)
+    }
+    if s.TLS == TLSImplicit {
+        conn = tls.Client(conn, tlsConfig)
+    }
+
+    client, err := smtp.NewClient(conn, s.Host)
+    if err != nil {
+        conn.Close()
+        return nil, fmt.Errorf("smtp handshake: %w", err)
+    }
+    if s.TLS == TLSStartTLS {
+        if ok, _ := client.Extension("STARTTLS"); ok {
+            if err := client.StartTLS(tlsConfig); err != nil {
+                client.Close()
+                return nil, fmt.Errorf("smtp STARTTLS: %w", err)
+            }
+        }
+    }
+    return client, nil
+}
+
+// buildMessage menyusun email MIME teks UTF-8 (quoted-printable)
+func buildMessage(from *mail.Address, to []*mail.Address, msg Message) []byte {
+    recipients := make([]string, 0, len(to))
+    for _, a := range to {
+        recipients = append(recipients, a.String())
+    }
+
+    var buf bytes.Buffer
+    header := func(key, value string) {
+        // Buang CR/LF agar nilai header tidak bisa menyisipkan header lain
+        value = strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+        fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+    }
+    header("From", from.String())
+    header("To", strings.Join(recipients, ", "))
+    header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+    header("Date", time.Now().Format(time.RFC1123Z))
+    header("Message-ID", fmt.Sprintf("<%d.%s>", time.Now().UnixNano(), from.Address))
+    header("MIME-Version", "1.0")
+    header("Content-Type", "text/plain; charset=utf-8")
+    header("Content-Transfer-Encoding", "quoted-printable")
+    buf.WriteString("\r\n")
+
+    qp := quotedprintable.NewWriter(&buf)
+    qp.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+    qp.Close()
+    return buf.Bytes()
+}
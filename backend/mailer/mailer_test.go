@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogSenderRedactsTokens(t *testing.T) {
+    var buf bytes.Buffer
+    defer log.SetOutput(log.Writer())
+    log.SetOutput(&buf)
+
+    msg := Message{
+        To:      []string{"a@example.com"},
+        Subject: "Reset",
+        Body:    "Buka http://app/reset-password?token=abc123_-XYZ dalam 60 menit\nhttp://app/x?a=1&token=rahasia&b=2",
+    }
+    if err := (LogSender{}).Send(context.Background(), msg); err != nil {
+        t.Fatal(err)
+    }
+    out := buf.String()
+    for _, secret := range []string{"abc123_-XYZ", "rahasia"} {
+        if strings.Contains(out, secret) {
+            t.Errorf("log contains token %q:\n%s", secret, out)
+        }
+    }
+    for _, want := range []string{"?token=[REDACTED] dalam", "&token=[REDACTED]&b=2", "a@example.com"} {
+        if !strings.Contains(out, want) {
+            t.Errorf("log is missing %q:\n%s", want, out)
+        }
+    }
+}
@@ -1,9 +1,18 @@
 package main
 
 import (
+	"backend/access"
+	"backend/analytics"
 	"backend/handlers"
+	"backend/mailer"
 	"backend/middleware"
 	"backend/models"
+	"backend/notify"
+	"backend/search"
+	"backend/session"
+	"backend/sharelink"
+	"backend/storage"
+	"backend/upload"
 	"fmt"
 	"log"
 	"os"
@@ -51,27 +60,122 @@ func main() {
 		log.Fatal("❌ Failed to connect to database:", err)
 	}
 
+	// Session format lama harus dihapus sebelum kolom token_hash dibuat
+	if err := session.Migrate(db); err != nil {
+		log.Fatal("❌ Failed to migrate sessions:", err)
+	}
+
 	// Auto migrate
 	err = db.AutoMigrate(
 		&models.Peraturan{},
 		&models.FAQ{},
 		&models.Suggestion{},
 		&models.User{},
+		&models.Role{},
+		&models.RolePermission{},
 		&models.Session{},
+		&models.PasswordReset{},
 		&models.Employee{},
+		&models.PeraturanHalaman{},
+		&models.PeraturanPasal{},
+		&models.PeraturanAyat{},
+		&models.PeraturanRelasi{},
+		&models.PeraturanRevisi{},
+		&models.Kategori{},
+		&models.UploadQuarantine{},
+		&models.PeraturanAkses{},
+		&models.UnduhanWatermark{},
+		&models.TautanBerbagi{},
+		&models.PeraturanIzin{},
+		&models.Bookmark{},
+		&models.RiwayatBaca{},
+		&models.ReadingList{},
+		&models.ReadingListItem{},
+		&models.ReadingListShare{},
+		&models.Langganan{},
+		&models.PreferensiNotifikasi{},
+		&models.Notifikasi{},
+		&models.EmailOutbox{},
 	)
 	if err != nil {
 		log.Fatal("❌ Failed to migrate database:", err)
 	}
+	if err := search.Migrate(db); err != nil {
+		log.Fatal("❌ Failed to migrate search index:", err)
+	}
+	if err := handlers.MigrateKategori(db); err != nil {
+		log.Fatal("❌ Failed to migrate kategori:", err)
+	}
+	if err := handlers.MigrateRevisi(db); err != nil {
+		log.Fatal("❌ Failed to migrate revisi:", err)
+	}
+	if err := access.Migrate(db); err != nil {
+		log.Fatal("❌ Failed to migrate roles:", err)
+	}
+
+	// Setup storage file (STORAGE_DRIVER=local|s3)
+	store, err := storage.FromEnv()
+	if err != nil {
+		log.Fatal("❌ Failed to setup storage:", err)
+	}
+
+	// Setup malware scanner (CLAMD_ADDRESS kosong = scanning dinonaktifkan)
+	scanner, err := upload.ScannerFromEnv()
+	if err != nil {
+		log.Fatal("❌ Failed to setup virus scanner:", err)
+	}
+
+	// Jalankan perintah CLI jika ada argumen, contoh: go run . reindex --all
+	if len(os.Args) > 1 {
+		if err := runCommand(db, store, scanner, os.Args[1:]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		return
+	}
+
+	// Ingatkan jika masih ada file lama yang belum dipindahkan ke storage
+	var legacyFiles int64
+	db.Model(&models.Peraturan{}).Where("path_file <> '' AND path_file NOT LIKE 'sha256/%'").Count(&legacyFiles)
+	if legacyFiles > 0 {
+		log.Printf("⚠️ Warning: %d peraturan still use legacy file paths, run: go run . migrate-storage", legacyFiles)
+	}
+
+	// Setup pengirim email (SMTP_HOST kosong = email hanya ditulis ke log) dan worker notifikasi
+	sender, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("❌ Failed to setup mailer:", err)
+	}
+	notifier := notify.New(db, sender, allowedOrigin)
+	notifier.Start()
+
+	// Secret untuk menandatangani tautan berbagi file peraturan
+	shareLinks, err := sharelink.FromEnv()
+	if err != nil {
+		log.Fatal("❌ Failed to setup share links:", err)
+	}
 
 	// Setup handlers
-	peraturanHandler := handlers.PeraturanHandler{DB: db}
+	peraturanHandler := handlers.PeraturanHandler{
+		DB:            db,
+		Storage:       store,
+		Scanner:       scanner,
+		MaxUploadSize: upload.MaxSizeFromEnv(),
+		Analytics:     analytics.NewRecorder(db),
+		Notifier:      notifier,
+		ShareLinks:    shareLinks,
+	}
 	faqHandler := handlers.FAQHandler{DB: db}
 	suggestionHandler := &handlers.SuggestionHandler{DB: db}
-	authHandler := handlers.AuthHandler{DB: db}
+	authHandler := handlers.AuthHandler{DB: db, Mailer: sender, AppURL: allowedOrigin}
 	employeeHandler := handlers.EmployeeHandler{DB: db}
 	pejabatStrukturalHandler := handlers.PejabatStrukturalHandler{DB: db}
-	userHandler := handlers.UserHandler{DB: db}
+	userHandler := handlers.UserHandler{DB: db, Notifier: notifier}
+	kategoriHandler := handlers.KategoriHandler{DB: db}
+	analyticsHandler := handlers.AnalyticsHandler{DB: db}
+	bookmarkHandler := handlers.BookmarkHandler{DB: db}
+	readingListHandler := handlers.ReadingListHandler{DB: db}
+	notifikasiHandler := handlers.NotifikasiHandler{DB: db}
+	roleHandler := handlers.RoleHandler{DB: db}
 
 	// Setup router
 	gin.SetMode(gin.ReleaseMode)
@@ -92,56 +196,143 @@ func main() {
 		c.Next()
 	})
 
-	// Public routes
+	// Public routes. Route peraturan memakai optionalAuth agar user yang login juga melihat
+	// peraturan internal/terbatas sesuai haknya; anonim hanya melihat peraturan publik.
+	optionalAuth := middleware.OptionalAuthMiddleware(db)
 	r.POST("/api/register", authHandler.Register)
 	r.POST("/api/login", authHandler.Login)
-	r.GET("/api/peraturan", peraturanHandler.GetPeraturan)
-	r.GET("/api/peraturan/:id", peraturanHandler.GetPeraturanByID)
-	r.GET("/api/peraturan/file/:id", peraturanHandler.GetPeraturanFile)
-	r.GET("/api/peraturan/download/:id", peraturanHandler.DownloadPeraturan)
-	r.GET("/api/peraturan/filter", peraturanHandler.GetPeraturanWithFilters)
-	r.GET("/api/peraturan/count", peraturanHandler.GetPeraturanCount)
+	r.POST("/api/auth/forgot-password", authHandler.ForgotPassword)
+	r.POST("/api/auth/reset-password", authHandler.ResetPassword)
+	r.GET("/api/peraturan", optionalAuth, peraturanHandler.GetPeraturan)
+	r.GET("/api/peraturan/:id", optionalAuth, peraturanHandler.GetPeraturanByID)
+	r.GET("/api/peraturan/file/:id", optionalAuth, peraturanHandler.GetPeraturanFile)
+	r.GET("/api/peraturan/download/:id", optionalAuth, peraturanHandler.DownloadPeraturan)
+	r.GET("/api/peraturan/filter", optionalAuth, peraturanHandler.GetPeraturanWithFilters)
+	r.GET("/api/peraturan/count", optionalAuth, peraturanHandler.GetPeraturanCount)
+	r.GET("/api/peraturan/bundle", optionalAuth, peraturanHandler.DownloadBundle)
+	r.GET("/api/peraturan/feed", peraturanHandler.GetPeraturanFeed)
+	r.GET("/api/peraturan/sitemap.xml", peraturanHandler.GetSitemap)
+	r.GET("/api/peraturan/search", optionalAuth, peraturanHandler.SearchPeraturan)
+	r.GET("/api/peraturan/export", optionalAuth, peraturanHandler.ExportPeraturan)
+	r.GET("/api/peraturan/:id/relasi", optionalAuth, peraturanHandler.GetPeraturanRelasi)
+	r.GET("/api/peraturan/:id/pasal", optionalAuth, peraturanHandler.GetPasalList)
+	r.GET("/api/peraturan/:id/pasal/:nomor", optionalAuth, peraturanHandler.GetPasal)
+	r.GET("/api/kategori", optionalAuth, kategoriHandler.GetKategori)
+	r.GET("/api/kategori/counts", optionalAuth, kategoriHandler.GetKategoriCounts)
 	r.GET("/api/faq", faqHandler.GetFAQs)
 	r.GET("/api/faq/:id", faqHandler.GetFAQByID)
 	r.GET("/api/suggestions", suggestionHandler.GetSuggestions)
-	r.POST("/api/suggestions", suggestionHandler.CreateSuggestion)
+	r.POST("/api/suggestions", optionalAuth, suggestionHandler.CreateSuggestion)
 	r.PUT("/api/suggestions/:id/read", suggestionHandler.MarkAsRead)
 	r.GET("/api/our-tims", employeeHandler.GetEmployeesKepegawaian)
 
 	// Protected routes
+	can := middleware.RequirePermission
 	protected := r.Group("/api")
 	protected.Use(middleware.AuthMiddleware(db))
 	{
 		protected.GET("/auth/me", authHandler.GetCurrentUser)
 		protected.POST("/logout", authHandler.Logout)
+		protected.PUT("/auth/me/employee", authHandler.ClaimEmployee)
+		protected.POST("/auth/change-password", authHandler.ChangePassword)
+
+		protected.GET("/bookmarks", bookmarkHandler.GetBookmarks)
+		protected.PUT("/bookmarks/:peraturanId", bookmarkHandler.AddBookmark)
+		protected.DELETE("/bookmarks/:peraturanId", bookmarkHandler.RemoveBookmark)
+		protected.GET("/recently-viewed", bookmarkHandler.GetRecentlyViewed)
+		protected.DELETE("/recently-viewed", bookmarkHandler.ClearRecentlyViewed)
+
+		protected.GET("/reading-lists", readingListHandler.GetReadingLists)
+		protected.POST("/reading-lists", readingListHandler.CreateReadingList)
+		protected.GET("/reading-lists/:id", readingListHandler.GetReadingList)
+		protected.PUT("/reading-lists/:id", readingListHandler.UpdateReadingList)
+		protected.DELETE("/reading-lists/:id", readingListHandler.DeleteReadingList)
+		protected.POST("/reading-lists/:id/items", readingListHandler.AddReadingListItem)
+		protected.DELETE("/reading-lists/:id/items/:peraturanId", readingListHandler.RemoveReadingListItem)
+		protected.PUT("/reading-lists/:id/shares", readingListHandler.ShareReadingList)
+
+		protected.GET("/subscriptions", notifikasiHandler.GetSubscriptions)
+		protected.POST("/subscriptions", notifikasiHandler.CreateSubscription)
+		protected.DELETE("/subscriptions/:id", notifikasiHandler.DeleteSubscription)
+		protected.GET("/notification-preferences", notifikasiHandler.GetPreferences)
+		protected.PUT("/notification-preferences", notifikasiHandler.UpdatePreferences)
+		protected.GET("/notifications", notifikasiHandler.GetNotifications)
+		protected.GET("/notifications/unread-count", notifikasiHandler.GetUnreadCount)
+		protected.PUT("/notifications/read-all", notifikasiHandler.MarkAllNotificationsRead)
+		protected.PUT("/notifications/:id/read", notifikasiHandler.MarkNotificationRead)
 
+		protected.POST("/peraturan/:id/share-links", peraturanHandler.CreateShareLink)
+		protected.GET("/share-links", peraturanHandler.GetMyShareLinks)
+		protected.DELETE("/share-links/:id", peraturanHandler.RevokeShareLink)
+
+		// Persetujuan pendaftaran (dipakai halaman ApprovalPage)
+		users := protected.Group("/users")
+		users.Use(can(models.PermUserManage))
+		{
+			users.GET("/pending", userHandler.GetPendingUsers)
+			users.POST("/:id/approve", userHandler.ApproveUser)
+			users.POST("/:id/reject", userHandler.RejectUser)
+			users.POST("/:id/suspend", userHandler.SuspendUser)
+		}
+
+		// Route pengelolaan; setiap route memeriksa permission yang diperlukan
 		admin := protected.Group("/admin")
-		admin.Use(middleware.AdminMiddleware())
 		{
-			admin.POST("/peraturan", peraturanHandler.CreatePeraturan)
-			admin.PUT("/peraturan/:id", peraturanHandler.UpdatePeraturan)
-			admin.DELETE("/peraturan/:id", peraturanHandler.DeletePeraturan)
+			admin.POST("/peraturan", can(models.PermPeraturanWrite), peraturanHandler.CreatePeraturan)
+			admin.PUT("/peraturan/:id", can(models.PermPeraturanWrite), peraturanHandler.UpdatePeraturan)
+			admin.DELETE("/peraturan/:id", can(models.PermPeraturanWrite), peraturanHandler.DeletePeraturan)
+			admin.POST("/peraturan/reindex", can(models.PermPeraturanWrite), peraturanHandler.ReindexPeraturan)
+			admin.POST("/peraturan/import", can(models.PermPeraturanWrite), peraturanHandler.ImportPeraturan)
+			admin.GET("/quarantine", can(models.PermPeraturanWrite), peraturanHandler.GetQuarantine)
+			admin.DELETE("/quarantine/:id", can(models.PermPeraturanWrite), peraturanHandler.DeleteQuarantine)
+			admin.POST("/peraturan/:id/relasi", can(models.PermPeraturanWrite), peraturanHandler.CreateRelasi)
+			admin.DELETE("/peraturan/:id/relasi/:relasiId", can(models.PermPeraturanWrite), peraturanHandler.DeleteRelasi)
+			admin.GET("/peraturan/:id/revisions", can(models.PermPeraturanWrite), peraturanHandler.GetRevisions)
+			admin.GET("/peraturan/:id/revisions/diff", can(models.PermPeraturanWrite), peraturanHandler.DiffRevisions)
+			admin.GET("/peraturan/:id/revisions/:revisi/file", can(models.PermPeraturanWrite), peraturanHandler.GetRevisionFile)
+			admin.POST("/peraturan/:id/revisions/:revisi/rollback", can(models.PermPeraturanWrite), peraturanHandler.RollbackRevision)
+			admin.GET("/peraturan/:id/watermark-log", can(models.PermAuditRead), peraturanHandler.GetWatermarkLog)
+			admin.GET("/watermark/:kode", can(models.PermAuditRead), peraturanHandler.VerifyWatermark)
+			admin.GET("/share-links", can(models.PermShareLinkManage), peraturanHandler.GetShareLinks)
 
-			admin.POST("/faq", faqHandler.CreateFAQ)
-			admin.PUT("/faq/:id", faqHandler.UpdateFAQ)
-			admin.DELETE("/faq/:id", faqHandler.DeleteFAQ)
+			admin.GET("/analytics/summary", can(models.PermAnalyticsRead), analyticsHandler.GetAnalyticsSummary)
+			admin.GET("/analytics/top", can(models.PermAnalyticsRead), analyticsHandler.GetTopDokumen)
+			admin.GET("/analytics/trends", can(models.PermAnalyticsRead), analyticsHandler.GetKategoriTrends)
+			admin.GET("/analytics/never-opened", can(models.PermAnalyticsRead), analyticsHandler.GetNeverOpened)
 
-			admin.DELETE("/suggestions/:id", suggestionHandler.DeleteSuggestion)
+			admin.POST("/kategori", can(models.PermKategoriWrite), kategoriHandler.CreateKategori)
+			admin.PUT("/kategori/:id", can(models.PermKategoriWrite), kategoriHandler.UpdateKategori)
+			admin.DELETE("/kategori/:id", can(models.PermKategoriWrite), kategoriHandler.DeleteKategori)
+			admin.POST("/kategori/:id/merge", can(models.PermKategoriWrite), kategoriHandler.MergeKategori)
 
-			admin.GET("/pejabat-struktural", pejabatStrukturalHandler.GetPejabatStruktural)
-			admin.GET("/pejabat-struktural/available", pejabatStrukturalHandler.GetAvailableForStruktural)
-			admin.POST("/pejabat-struktural", pejabatStrukturalHandler.AddPejabatStruktural)
-			admin.PUT("/pejabat-struktural/:id", pejabatStrukturalHandler.UpdatePejabatStruktural)
-			admin.DELETE("/pejabat-struktural/:id", pejabatStrukturalHandler.RemovePejabatStruktural)
-			admin.GET("/pejabat-struktural/:id/bawahan", pejabatStrukturalHandler.GetBawahanByPejabat)
+			admin.POST("/faq", can(models.PermFAQWrite), faqHandler.CreateFAQ)
+			admin.PUT("/faq/:id", can(models.PermFAQWrite), faqHandler.UpdateFAQ)
+			admin.DELETE("/faq/:id", can(models.PermFAQWrite), faqHandler.DeleteFAQ)
 
-			admin.GET("/users", userHandler.GetUsers)
-			admin.PUT("/users/:id/role", userHandler.UpdateUserRole)
+			admin.DELETE("/suggestions/:id", can(models.PermSuggestionManage), suggestionHandler.DeleteSuggestion)
+
+			admin.GET("/pejabat-struktural", can(models.PermEmployeeRead), pejabatStrukturalHandler.GetPejabatStruktural)
+			admin.GET("/pejabat-struktural/available", can(models.PermEmployeeRead), pejabatStrukturalHandler.GetAvailableForStruktural)
+			admin.POST("/pejabat-struktural", can(models.PermEmployeeWrite), pejabatStrukturalHandler.AddPejabatStruktural)
+			admin.PUT("/pejabat-struktural/:id", can(models.PermEmployeeWrite), pejabatStrukturalHandler.UpdatePejabatStruktural)
+			admin.DELETE("/pejabat-struktural/:id", can(models.PermEmployeeWrite), pejabatStrukturalHandler.RemovePejabatStruktural)
+			admin.GET("/pejabat-struktural/:id/bawahan", can(models.PermEmployeeRead), pejabatStrukturalHandler.GetBawahanByPejabat)
+
+			admin.GET("/users", can(models.PermUserManage), userHandler.GetUsers)
+			admin.PUT("/users/:id/role", can(models.PermUserManage), userHandler.UpdateUserRole)
+			admin.PUT("/users/:id/unit", can(models.PermUserManage), userHandler.UpdateUserUnit)
+			admin.PUT("/users/:id/employee", can(models.PermUserManage), userHandler.BindEmployee)
+			admin.POST("/users/:id/employee/verify", can(models.PermUserManage), userHandler.VerifyEmployee)
+			admin.DELETE("/users/:id/employee", can(models.PermUserManage), userHandler.UnbindEmployee)
+
+			admin.GET("/permissions", can(models.PermRoleManage), roleHandler.GetPermissions)
+			admin.GET("/roles", can(models.PermRoleManage, models.PermUserManage), roleHandler.GetRoles)
+			admin.POST("/roles", can(models.PermRoleManage), roleHandler.CreateRole)
+			admin.PUT("/roles/:id", can(models.PermRoleManage), roleHandler.UpdateRole)
+			admin.DELETE("/roles/:id", can(models.PermRoleManage), roleHandler.DeleteRole)
 		}
 	}
 
-	r.Static("/uploads", "./uploads")
-
 	log.Printf("🚀 Server started on :%s\n", serverPort)
 	log.Fatal(r.Run(":" + serverPort))
 }
@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"backend/models"
+	"backend/session"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,36 +13,43 @@ import (
 func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
     return func(c *gin.Context) {
         // Get token from cookie
-        token, err := c.Cookie("token")
+        token, err := c.Cookie(session.CookieName)
         if err != nil {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "No token found"})
             c.Abort()
             return
         }
 
-        // Find session in database
-        var session models.Session
-        if err := db.Where("token = ? AND expires_at > NOW()", token).First(&session).Error; err != nil {
+        // Find session, user, and permissions in database; expiry diperpanjang selama session dipakai
+        user, err := session.Lookup(c, db, token)
+        if err != nil {
             c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
             c.Abort()
             return
         }
 
-        // Find user
-        var user models.User
-        if err := db.First(&user, session.UserID).Error; err != nil {
-            c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-            c.Abort()
-            return
-        }
-
         // Set user to context
         c.Set("user", user)
         c.Next()
     }
 }
 
-func AdminMiddleware() gin.HandlerFunc {
+// OptionalAuthMiddleware dipakai di route publik: user diset jika token valid,
+// request tanpa token atau dengan token kedaluwarsa tetap dilanjutkan sebagai anonim
+func OptionalAuthMiddleware(db *gorm.DB) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if token, err := c.Cookie(session.CookieName); err == nil {
+            if user, err := session.Lookup(c, db, token); err == nil {
+                c.Set("user", user)
+            }
+        }
+        c.Next()
+    }
+}
+
+// RequirePermission mengizinkan request jika user memiliki minimal satu permission yang disebut.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
     return func(c *gin.Context) {
         user, exists := c.Get("user")
         if !exists {
@@ -50,12 +58,14 @@ func AdminMiddleware() gin.HandlerFunc {
             return
         }
 
-        if userModel, ok := user.(models.User); ok && userModel.Role != "admin" {
-            c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-            c.Abort()
-            return
+        userModel := user.(models.User)
+        for _, permission := range permissions {
+            if userModel.Can(permission) {
+                c.Next()
+                return
+            }
         }
-
-        c.Next()
+        c.JSON(http.StatusForbidden, gin.H{"error": "Permission required", "permissions": permissions})
+        c.Abort()
     }
-}
\ No newline at end of file
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Bookmark adalah peraturan yang ditandai user di rak "peraturan saya"
+type Bookmark struct {
+    ID          uint       `json:"id" gorm:"primaryKey"`
+    UserID      int64      `json:"user_id" gorm:"not null;uniqueIndex:idx_bookmark_user_peraturan"`
+    PeraturanID uint       `json:"peraturan_id" gorm:"not null;uniqueIndex:idx_bookmark_user_peraturan;index"`
+    Catatan     string     `json:"catatan"`
+    CreatedAt   time.Time  `json:"created_at"`
+    Peraturan   *Peraturan `json:"peraturan,omitempty" gorm:"foreignKey:PeraturanID"`
+}
+
+func (Bookmark) TableName() string {
+    return "bookmark"
+}
+
+// RiwayatBaca menyimpan peraturan yang terakhir dibuka user, satu baris per peraturan
+type RiwayatBaca struct {
+    UserID      int64      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+    PeraturanID uint       `json:"peraturan_id" gorm:"primaryKey;autoIncrement:false;index"`
+    DibukaAt    time.Time  `json:"dibuka_at" gorm:"not null;index"`
+    Peraturan   *Peraturan `json:"peraturan,omitempty" gorm:"foreignKey:PeraturanID"`
+}
+
+func (RiwayatBaca) TableName() string {
+    return "riwayat_baca"
+}
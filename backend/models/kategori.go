@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Kategori adalah taksonomi hierarkis peraturan (mis. Kepegawaian > Cuti).
+// Relasi ke peraturan disimpan di join table peraturan_kategori.
+type Kategori struct {
+    ID          uint       `json:"id" gorm:"primaryKey"`
+    Nama        string     `json:"nama" gorm:"not null;index"`
+    ParentID    *uint      `json:"parent_id" gorm:"index"`
+    Children    []Kategori `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+    Jumlah      int64      `json:"jumlah" gorm:"-"`       // Jumlah peraturan yang ditandai langsung
+    JumlahTotal int64      `json:"jumlah_total" gorm:"-"` // Termasuk peraturan di sub-kategori
+    CreatedAt   time.Time  `json:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Kategori) TableName() string {
+    return "kategori"
+}
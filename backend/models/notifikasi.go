@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// Tipe langganan
+const (
+    LanggananKategori = "kategori"
+    LanggananJenis    = "jenis"
+    LanggananInstansi = "instansi"
+)
+
+// Frekuensi email notifikasi
+const (
+    FrekuensiLangsung = "immediate"
+    FrekuensiHarian   = "daily"
+)
+
+// Aksi peraturan yang memicu notifikasi
+const (
+    NotifikasiBaru   = "baru"
+    NotifikasiDiubah = "diubah"
+)
+
+// Status email di outbox
+const (
+    EmailPending = "pending"
+    EmailSending = "sending" // Sedang dikirim worker; diambil ulang jika worker mati sebelum selesai
+    EmailSent    = "sent"
+    EmailFailed  = "failed"
+)
+
+// Langganan adalah minat user terhadap peraturan dengan kategori, jenis, atau instansi tertentu.
+// Untuk tipe kategori yang dipakai KategoriID (termasuk sub-kategorinya), selain itu Nilai.
+type Langganan struct {
+    ID         uint      `json:"id" gorm:"primaryKey"`
+    UserID     int64     `json:"user_id" gorm:"not null;index"`
+    Tipe       string    `json:"tipe" gorm:"size:20;not null"`
+    KategoriID *uint     `json:"kategori_id" gorm:"index"`
+    Nilai      string    `json:"nilai"`
+    Kategori   *Kategori `json:"kategori,omitempty" gorm:"foreignKey:KategoriID"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Langganan) TableName() string {
+    return "langganan"
+}
+
+// PreferensiNotifikasi menyimpan pilihan pengiriman email user.
+// User tanpa record memakai default: email langsung.
+type PreferensiNotifikasi struct {
+    UserID    int64     `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+    Frekuensi string    `json:"frekuensi" gorm:"size:20;not null;default:'immediate'"`
+    Email     bool      `json:"email" gorm:"not null;default:true"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (PreferensiNotifikasi) TableName() string {
+    return "preferensi_notifikasi"
+}
+
+// Notifikasi adalah satu pesan di inbox aplikasi. EmailQueuedAt diisi saat notifikasi
+// sudah dimasukkan ke outbox (langsung atau lewat ringkasan harian).
+type Notifikasi struct {
+    ID            uint       `json:"id" gorm:"primaryKey"`
+    UserID        int64      `json:"user_id" gorm:"not null;index:idx_notifikasi_user"`
+    PeraturanID   uint       `json:"peraturan_id" gorm:"not null;index"`
+    Aksi          string     `json:"aksi" gorm:"size:20;not null"`
+    Judul         string     `json:"judul"`
+    Pesan         string     `json:"pesan" gorm:"type:text"`
+    Alasan        string     `json:"alasan"` // Langganan yang cocok, misal "Kategori: Pensiun"
+    DibacaAt      *time.Time `json:"dibaca_at"`
+    EmailQueuedAt *time.Time `json:"-" gorm:"index"`
+    CreatedAt     time.Time  `json:"created_at" gorm:"index:idx_notifikasi_user"`
+    Peraturan     *Peraturan `json:"peraturan,omitempty"`
+}
+
+func (Notifikasi) TableName() string {
+    return "notifikasi"
+}
+
+// EmailOutbox adalah antrean email yang dikirim oleh worker notifikasi dengan retry
+type EmailOutbox struct {
+    ID            uint       `json:"id" gorm:"primaryKey"`
+    UserID        *int64     `json:"user_id" gorm:"index"`
+    To            string     `json:"to" gorm:"not null"`
+    Subject       string     `json:"subject" gorm:"not null"`
+    Body          string     `json:"body" gorm:"type:text"`
+    Status        string     `json:"status" gorm:"size:20;not null;index:idx_email_outbox_pending"`
+    Attempts      int        `json:"attempts"`
+    LastError     string     `json:"last_error"`
+    NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index:idx_email_outbox_pending"`
+    SentAt        *time.Time `json:"sent_at"`
+    CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (EmailOutbox) TableName() string {
+    return "email_outbox"
+}
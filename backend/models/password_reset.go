@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PasswordReset adalah token lupa password. Yang disimpan hanya hash-nya; token sekali pakai
+// dan kedaluwarsa setelah ExpiresAt.
+type PasswordReset struct {
+    ID        int64      `json:"id" gorm:"primaryKey"`
+    UserID    int64      `json:"user_id" gorm:"index"`
+    TokenHash string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+    ExpiresAt time.Time  `json:"expires_at"`
+    UsedAt    *time.Time `json:"used_at"`
+    IPAddress string     `json:"ip_address" gorm:"size:45"`
+    CreatedAt time.Time  `json:"created_at"`
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Peraturan struct {
     ID              uint      `json:"id" gorm:"primaryKey"`
@@ -9,9 +15,42 @@ type Peraturan struct {
     Judul           string    `json:"judul" gorm:"not null"`
     InstansiPembuat string    `json:"instansi_pembuat" gorm:"not null"`
     JenisPeraturan  string    `json:"jenis_peraturan" gorm:"not null"`
-    Kategori        string    `json:"kategori" gorm:"type:text"` // Ubah menjadi tipe text untuk JSON
+    Kategori        string    `json:"kategori" gorm:"-"` // JSON array nama kategori (format lama API), diisi dari KategoriItems
+    KategoriItems   []Kategori `json:"kategori_items,omitempty" gorm:"many2many:peraturan_kategori"`
     NamaFile        string    `json:"nama_file"` // Hapus not null karena bisa kosong
-    PathFile        string    `json:"path_file"`
+    PathFile        string    `json:"path_file"` // Key di storage (sha256/xx/<hash>)
+    FileHash        string    `json:"file_hash" gorm:"index"` // SHA-256 isi file
+    FileSize        int64     `json:"file_size"`
+    ContentType     string    `json:"content_type"` // Hasil deteksi magic bytes saat upload
     Keterangan      string    `json:"keterangan"`
+    Status          string    `json:"status" gorm:"default:'berlaku';index"` // berlaku / diubah / dicabut
+    Watermark       bool      `json:"watermark" gorm:"not null;default:false"` // File PDF dibubuhi identitas pengunduh saat dibuka/diunduh
+    Visibilitas     string    `json:"visibilitas" gorm:"size:20;not null;default:'publik';index"` // publik / internal / terbatas
+    Izin            []PeraturanIzin `json:"izin,omitempty" gorm:"foreignKey:PeraturanID"` // Role/unit yang boleh melihat jika terbatas
+    IndexedAt       *time.Time `json:"indexed_at"` // Waktu terakhir teks file diindeks untuk pencarian
     CreatedAt       time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+// SetKategori mengganti KategoriItems dan mengisi ulang Kategori
+func (p *Peraturan) SetKategori(items []Kategori) {
+    p.KategoriItems = items
+    p.Kategori = KategoriNamesJSON(items)
+}
+
+// AfterFind mengisi Kategori jika KategoriItems ikut di-preload
+func (p *Peraturan) AfterFind(tx *gorm.DB) error {
+    if p.KategoriItems != nil {
+        p.Kategori = KategoriNamesJSON(p.KategoriItems)
+    }
+    return nil
+}
+
+// KategoriNamesJSON menghasilkan JSON array nama kategori, terurut agar stabil untuk snapshot revisi
+func KategoriNamesJSON(items []Kategori) string {
+    names := make([]string, 0, len(items))
+    for _, k := range items {
+        names = append(names, k.Nama)
+    }
+    sort.Strings(names)
+    data, _ := json.Marshal(names)
+    return string(data)
+}
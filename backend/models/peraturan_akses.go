@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+    AksesView     = "view"
+    AksesDownload = "download"
+)
+
+// PeraturanAkses mencatat satu kali file peraturan dibuka (view) atau diunduh.
+// Baris dibuat ringkas: referrer hanya host dan path, tanpa query string.
+type PeraturanAkses struct {
+    ID          int64     `json:"id" gorm:"primaryKey"`
+    PeraturanID uint      `json:"peraturan_id" gorm:"not null;index:idx_peraturan_akses_peraturan,priority:1"`
+    UserID      *int64    `json:"user_id" gorm:"index"`
+    Jenis       string    `json:"jenis" gorm:"size:10;not null"`
+    Referrer    string    `json:"referrer" gorm:"size:255"`
+    CreatedAt   time.Time `json:"created_at" gorm:"not null;index;index:idx_peraturan_akses_peraturan,priority:2"`
+}
+
+func (PeraturanAkses) TableName() string {
+    return "peraturan_akses"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PeraturanHalaman menyimpan teks hasil ekstraksi file peraturan per halaman.
+// Kolom tsv (tsvector) dibuat lewat search.Migrate karena merupakan generated column.
+type PeraturanHalaman struct {
+    ID          uint      `json:"id" gorm:"primaryKey"`
+    PeraturanID uint      `json:"peraturan_id" gorm:"not null;index"`
+    Halaman     int       `json:"halaman" gorm:"not null"`
+    Konten      string    `json:"konten" gorm:"type:text"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+func (PeraturanHalaman) TableName() string {
+    return "peraturan_halaman"
+}
@@ -0,0 +1,55 @@
+package models
+
+// Visibilitas peraturan
+const (
+    VisibilitasPublik   = "publik"   // Semua orang, termasuk tanpa login
+    VisibilitasInternal = "internal" // Semua user yang login
+    VisibilitasTerbatas = "terbatas" // Hanya role/unit yang tercantum di PeraturanIzin
+)
+
+// Jenis izin untuk peraturan terbatas
+const (
+    IzinRole = "role" // Nilai = role user
+    IzinUnit = "unit" // Nilai = unit kerja (bidang) user
+)
+
+// PeraturanIzin adalah role atau unit yang boleh melihat peraturan dengan visibilitas terbatas
+type PeraturanIzin struct {
+    ID          uint   `json:"id" gorm:"primaryKey"`
+    PeraturanID uint   `json:"peraturan_id" gorm:"not null;uniqueIndex:idx_peraturan_izin"`
+    Tipe        string `json:"tipe" gorm:"size:10;not null;uniqueIndex:idx_peraturan_izin"`
+    Nilai       string `json:"nilai" gorm:"not null;uniqueIndex:idx_peraturan_izin"`
+}
+
+func (PeraturanIzin) TableName() string {
+    return "peraturan_izin"
+}
+
+// IsValidVisibilitas memeriksa apakah visibilitas dikenal
+func IsValidVisibilitas(v string) bool {
+    switch v {
+    case VisibilitasPublik, VisibilitasInternal, VisibilitasTerbatas:
+        return true
+    }
+    return false
+}
+
+// VisibleTo menandakan peraturan boleh dilihat user (nil = anonim). Untuk peraturan terbatas
+// Izin dan permission user harus sudah dimuat. Kondisi yang sama dipakai di query listing (handlers.scopeVisible).
+func (p *Peraturan) VisibleTo(user *User) bool {
+    switch {
+    case p.Visibilitas == "" || p.Visibilitas == VisibilitasPublik:
+        return true
+    case user == nil:
+        return false
+    case user.Can(PermPeraturanReadAll) || p.Visibilitas == VisibilitasInternal:
+        return true
+    }
+    for _, izin := range p.Izin {
+        if (izin.Tipe == IzinRole && izin.Nilai == user.Role) ||
+            (izin.Tipe == IzinUnit && user.Unit != "" && izin.Nilai == user.Unit) {
+            return true
+        }
+    }
+    return false
+}
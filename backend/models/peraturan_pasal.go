@@ -0,0 +1,35 @@
+package models
+
+// PeraturanPasal adalah satu pasal hasil pemecahan teks peraturan (lihat package struktur).
+// Halaman dan Offset menunjuk posisi heading pasal di file agar viewer bisa langsung
+// membuka halaman yang tepat. Kolom tsv dibuat lewat search.Migrate.
+type PeraturanPasal struct {
+    ID           uint            `json:"id" gorm:"primaryKey"`
+    PeraturanID  uint            `json:"peraturan_id" gorm:"not null;index:idx_peraturan_pasal_nomor,unique"`
+    Urutan       int             `json:"urutan" gorm:"not null"`
+    Nomor        string          `json:"nomor" gorm:"size:10;not null;index:idx_peraturan_pasal_nomor,unique"`
+    Bab          string          `json:"bab,omitempty" gorm:"size:20"`
+    JudulBab     string          `json:"judul_bab,omitempty" gorm:"size:255"`
+    Halaman      int             `json:"halaman" gorm:"not null"`
+    HalamanAkhir int             `json:"halaman_akhir" gorm:"not null"`
+    Offset       int             `json:"offset"`
+    Konten       string          `json:"konten" gorm:"type:text"`
+    Ayat         []PeraturanAyat `json:"ayat,omitempty" gorm:"foreignKey:PasalID"`
+}
+
+func (PeraturanPasal) TableName() string {
+    return "peraturan_pasal"
+}
+
+// PeraturanAyat adalah ayat bernomor di dalam pasal
+type PeraturanAyat struct {
+    ID      uint   `json:"id" gorm:"primaryKey"`
+    PasalID uint   `json:"pasal_id" gorm:"not null;index"`
+    Nomor   int    `json:"nomor" gorm:"not null"`
+    Halaman int    `json:"halaman" gorm:"not null"`
+    Konten  string `json:"konten" gorm:"type:text"`
+}
+
+func (PeraturanAyat) TableName() string {
+    return "peraturan_ayat"
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Jenis relasi antar peraturan. Arah relasi: Peraturan (yang lebih baru) -> Terkait.
+// Contoh: PP 94/2021 (PeraturanID) "revokes" PP 53/2010 (TerkaitID).
+const (
+    RelasiAmends     = "amends"     // mengubah
+    RelasiRevokes    = "revokes"    // mencabut
+    RelasiImplements = "implements" // melaksanakan
+    RelasiReferences = "references" // merujuk
+)
+
+// Status berlaku peraturan, dihitung ulang dari relasi yang mengarah ke peraturan tersebut
+const (
+    StatusBerlaku = "berlaku"
+    StatusDiubah  = "diubah"
+    StatusDicabut = "dicabut"
+)
+
+type PeraturanRelasi struct {
+    ID          uint       `json:"id" gorm:"primaryKey"`
+    PeraturanID uint       `json:"peraturan_id" gorm:"not null;uniqueIndex:idx_peraturan_relasi"`
+    TerkaitID   uint       `json:"terkait_id" gorm:"not null;index;uniqueIndex:idx_peraturan_relasi"`
+    Jenis       string     `json:"jenis" gorm:"not null;uniqueIndex:idx_peraturan_relasi"`
+    Keterangan  string     `json:"keterangan"`
+    CreatedAt   time.Time  `json:"created_at"`
+    Peraturan   *Peraturan `json:"peraturan,omitempty" gorm:"foreignKey:PeraturanID"`
+    Terkait     *Peraturan `json:"terkait,omitempty" gorm:"foreignKey:TerkaitID"`
+}
+
+func (PeraturanRelasi) TableName() string {
+    return "peraturan_relasi"
+}
+
+// IsValidRelasi memeriksa apakah jenis relasi dikenal
+func IsValidRelasi(jenis string) bool {
+    switch jenis {
+    case RelasiAmends, RelasiRevokes, RelasiImplements, RelasiReferences:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Aksi yang menghasilkan revisi peraturan
+const (
+    RevisiCreate   = "create"   // Peraturan dibuat (termasuk lewat import)
+    RevisiUpdate   = "update"   // Metadata dan/atau file diubah
+    RevisiStatus   = "status"   // Status berubah karena relasi amends/revokes
+    RevisiRollback = "rollback" // Dikembalikan ke revisi sebelumnya
+    RevisiBaseline = "baseline" // Snapshot awal untuk data yang ada sebelum riwayat revisi dicatat
+    RevisiDelete   = "delete"   // Peraturan dihapus; riwayat sebelumnya tetap disimpan
+)
+
+// PeraturanRevisi adalah snapshot immutable metadata dan file peraturan setelah satu perubahan.
+// Record tidak pernah diubah; file yang dirujuk PathFile tidak dihapus dari storage selama
+// masih ada revisi yang merujuknya.
+type PeraturanRevisi struct {
+    ID                uint      `json:"id" gorm:"primaryKey"`
+    PeraturanID       uint      `json:"peraturan_id" gorm:"not null;uniqueIndex:idx_peraturan_revisi"`
+    Revisi            int       `json:"revisi" gorm:"not null;uniqueIndex:idx_peraturan_revisi"` // Nomor urut revisi per peraturan, mulai 1
+    Aksi              string    `json:"aksi" gorm:"size:20;not null"`
+    Catatan           string    `json:"catatan"`
+    FileChanged       bool      `json:"file_changed"`
+    Nomor             string    `json:"nomor"`
+    TanggalDitetapkan time.Time `json:"tanggal_ditetapkan"`
+    Judul             string    `json:"judul"`
+    InstansiPembuat   string    `json:"instansi_pembuat"`
+    JenisPeraturan    string    `json:"jenis_peraturan"`
+    Kategori          string    `json:"kategori" gorm:"type:text"`
+    NamaFile          string    `json:"nama_file"`
+    PathFile          string    `json:"path_file" gorm:"index"`
+    FileHash          string    `json:"file_hash"`
+    FileSize          int64     `json:"file_size"`
+    ContentType       string    `json:"content_type"`
+    Keterangan        string    `json:"keterangan"`
+    Status            string    `json:"status"`
+    Watermark         bool      `json:"watermark"`
+    Visibilitas       string    `json:"visibilitas" gorm:"size:20"` // Kosong pada revisi yang dicatat sebelum visibilitas ada
+    Izin              string    `json:"izin" gorm:"type:text"`       // JSON array {tipe, nilai}, terurut
+    UserID            *int64    `json:"user_id"`
+    User              *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+    CreatedAt         time.Time `json:"created_at"`
+}
+
+func (PeraturanRevisi) TableName() string {
+    return "peraturan_revisi"
+}
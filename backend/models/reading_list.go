@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ReadingList adalah daftar bacaan bernama milik satu user yang bisa dibagikan ke anggota tim
+type ReadingList struct {
+    ID        uint               `json:"id" gorm:"primaryKey"`
+    UserID    int64              `json:"user_id" gorm:"not null;index"`
+    Nama      string             `json:"nama" gorm:"not null"`
+    Deskripsi string             `json:"deskripsi"`
+    Items     []ReadingListItem  `json:"items,omitempty"`
+    Shares    []ReadingListShare `json:"shares,omitempty"`
+    Owner     *User              `json:"owner,omitempty" gorm:"foreignKey:UserID"`
+    CreatedAt time.Time          `json:"created_at"`
+    UpdatedAt time.Time          `json:"updated_at"`
+}
+
+func (ReadingList) TableName() string {
+    return "reading_list"
+}
+
+type ReadingListItem struct {
+    ID            uint       `json:"id" gorm:"primaryKey"`
+    ReadingListID uint       `json:"reading_list_id" gorm:"not null;uniqueIndex:idx_reading_list_item"`
+    PeraturanID   uint       `json:"peraturan_id" gorm:"not null;uniqueIndex:idx_reading_list_item;index"`
+    Urutan        int        `json:"urutan"`
+    Catatan       string     `json:"catatan"`
+    CreatedAt     time.Time  `json:"created_at"`
+    Peraturan     *Peraturan `json:"peraturan,omitempty" gorm:"foreignKey:PeraturanID"`
+}
+
+func (ReadingListItem) TableName() string {
+    return "reading_list_item"
+}
+
+// ReadingListShare memberi akses daftar bacaan ke user lain; CanEdit mengizinkan
+// menambah/menghapus item, tetapi hanya pemilik yang bisa mengubah nama, berbagi, atau menghapus
+type ReadingListShare struct {
+    ReadingListID uint      `json:"reading_list_id" gorm:"primaryKey;autoIncrement:false"`
+    UserID        int64     `json:"user_id" gorm:"primaryKey;autoIncrement:false;index"`
+    CanEdit       bool      `json:"can_edit" gorm:"default:false"`
+    CreatedAt     time.Time `json:"created_at"`
+    User          *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (ReadingListShare) TableName() string {
+    return "reading_list_share"
+}
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Permission yang bisa diberikan ke role
+const (
+    PermPeraturanWrite        = "peraturan.write"         // Tambah, ubah, hapus, impor, revisi, dan relasi peraturan
+    PermPeraturanReadAll      = "peraturan.read_all"      // Melihat semua peraturan termasuk yang terbatas
+    PermKategoriWrite         = "kategori.write"          // Mengelola kategori
+    PermFAQWrite              = "faq.write"               // Mengelola FAQ
+    PermSuggestionManage      = "suggestion.manage"       // Menghapus saran
+    PermAnalyticsRead         = "analytics.read"          // Statistik akses peraturan
+    PermAuditRead             = "audit.read"              // Log dan verifikasi salinan ber-watermark
+    PermShareLinkManage       = "share_link.manage"       // Melihat dan mencabut tautan berbagi milik siapa pun
+    PermEmployeeRead          = "employee.read"           // Melihat data pegawai dan pejabat struktural
+    PermEmployeeReadSensitive = "employee.read_sensitive" // Melihat tanggal lahir dan agama pegawai
+    PermEmployeeWrite         = "employee.write"          // Mengelola pejabat struktural
+    PermUserManage            = "user.manage"             // Persetujuan, status, role, unit, dan NIP user
+    PermRoleManage            = "role.manage"             // Mengelola role dan permission-nya
+)
+
+type PermissionInfo struct {
+    Kode      string `json:"kode"`
+    Deskripsi string `json:"deskripsi"`
+}
+
+// AllPermissions adalah katalog permission untuk halaman pengelolaan role
+var AllPermissions = []PermissionInfo{
+    {PermPeraturanWrite, "Tambah, ubah, hapus, impor, revisi, dan relasi peraturan"},
+    {PermPeraturanReadAll, "Melihat semua peraturan termasuk yang terbatas"},
+    {PermKategoriWrite, "Mengelola kategori"},
+    {PermFAQWrite, "Mengelola FAQ"},
+    {PermSuggestionManage, "Menghapus saran"},
+    {PermAnalyticsRead, "Statistik akses peraturan"},
+    {PermAuditRead, "Log dan verifikasi salinan ber-watermark"},
+    {PermShareLinkManage, "Melihat dan mencabut tautan berbagi milik siapa pun"},
+    {PermEmployeeRead, "Melihat data pegawai dan pejabat struktural"},
+    {PermEmployeeReadSensitive, "Melihat tanggal lahir dan agama pegawai"},
+    {PermEmployeeWrite, "Mengelola pejabat struktural"},
+    {PermUserManage, "Persetujuan, status, role, unit, dan NIP user"},
+    {PermRoleManage, "Mengelola role dan permission-nya"},
+}
+
+// IsValidPermission mengecek kode ada di katalog
+func IsValidPermission(kode string) bool {
+    for _, p := range AllPermissions {
+        if p.Kode == kode {
+            return true
+        }
+    }
+    return false
+}
+
+// PermissionCodes mengembalikan semua kode permission
+func PermissionCodes() []string {
+    codes := make([]string, len(AllPermissions))
+    for i, p := range AllPermissions {
+        codes[i] = p.Kode
+    }
+    return codes
+}
+
+// Role bawaan
+const (
+    RoleAdmin               = "admin" // Selalu memiliki semua permission
+    RoleUser                = "user"  // Default untuk pendaftar baru, tanpa permission tambahan
+    RoleOperatorKepegawaian = "operator_kepegawaian"
+    RolePengelolaJDIH       = "pengelola_jdih"
+    RolePejabatStruktural   = "pejabat_struktural"
+    RolePimpinan            = "pimpinan"
+)
+
+// Role adalah kumpulan permission. Nama disimpan di users.role dan dipakai juga untuk izin
+// peraturan terbatas per role.
+type Role struct {
+    ID          uint             `json:"id" gorm:"primaryKey"`
+    Nama        string           `json:"nama" gorm:"size:50;uniqueIndex;not null"`
+    Label       string           `json:"label" gorm:"size:100"`
+    Deskripsi   string           `json:"deskripsi"`
+    System      bool             `json:"system" gorm:"default:false"` // Role bawaan, tidak bisa dihapus
+    Permissions []RolePermission `json:"-" gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
+    CreatedAt   time.Time        `json:"created_at"`
+    UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type RolePermission struct {
+    RoleID     uint   `gorm:"primaryKey"`
+    Permission string `gorm:"primaryKey;size:100"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// TautanBerbagi adalah tautan bertanda tangan untuk membagikan file peraturan ke pihak luar
+// tanpa akun. Tokennya tidak disimpan; bisa dibentuk ulang dari ID, PeraturanID, dan ExpiresAt.
+type TautanBerbagi struct {
+    ID            uint       `json:"id" gorm:"primaryKey"`
+    PeraturanID   uint       `json:"peraturan_id" gorm:"not null;index"`
+    Penerima      string     `json:"penerima"` // Pihak yang diberi tautan, dicetak pada watermark
+    Catatan       string     `json:"catatan"`
+    ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+    MaxDownloads  *int       `json:"max_downloads"` // nil = tanpa batas
+    DownloadCount int        `json:"download_count" gorm:"not null;default:0"`
+    LastUsedAt    *time.Time `json:"last_used_at"`
+    RevokedAt     *time.Time `json:"revoked_at"`
+    CreatedBy     *int64     `json:"created_by" gorm:"index"`
+    CreatedAt     time.Time  `json:"created_at"`
+    Peraturan     *Peraturan `json:"peraturan,omitempty"`
+}
+
+func (TautanBerbagi) TableName() string {
+    return "tautan_berbagi"
+}
+
+// Active menandakan tautan masih bisa dipakai
+func (t *TautanBerbagi) Active(now time.Time) bool {
+    return t.RevokedAt == nil && now.Before(t.ExpiresAt) &&
+        (t.MaxDownloads == nil || t.DownloadCount < *t.MaxDownloads)
+}
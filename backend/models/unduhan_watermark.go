@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// UnduhanWatermark mencatat setiap salinan file ber-watermark yang diberikan ke user.
+// Kode yang tercetak di halaman dipakai untuk melacak asal salinan yang bocor. Sengaja tanpa
+// foreign key agar catatan tetap ada walaupun peraturan atau user-nya dihapus.
+type UnduhanWatermark struct {
+    ID          int64     `json:"id" gorm:"primaryKey"`
+    Kode        string    `json:"kode" gorm:"size:20;uniqueIndex;not null"`
+    PeraturanID uint      `json:"peraturan_id" gorm:"not null;index"`
+    UserID      *int64    `json:"user_id" gorm:"index"`
+    TautanID    *uint     `json:"tautan_id" gorm:"index"` // Diisi jika diunduh lewat tautan berbagi
+    Nama        string    `json:"nama"`
+    NIP         string    `json:"nip" gorm:"column:nip"`
+    FileHash    string    `json:"file_hash"`
+    IPAddress   string    `json:"ip_address" gorm:"size:45"`
+    UserAgent   string    `json:"user_agent" gorm:"size:255"`
+    CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+func (UnduhanWatermark) TableName() string {
+    return "unduhan_watermark"
+}
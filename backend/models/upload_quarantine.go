@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UploadQuarantine mencatat file upload yang ditolak karena terdeteksi malware.
+// File disimpan terpisah di storage (prefix quarantine/) dan tidak pernah dipublikasikan.
+type UploadQuarantine struct {
+    ID         uint      `json:"id" gorm:"primaryKey"`
+    NamaFile   string    `json:"nama_file"`
+    FileHash   string    `json:"file_hash" gorm:"index"`
+    FileSize   int64     `json:"file_size"`
+    StorageKey string    `json:"storage_key"`
+    Signature  string    `json:"signature"` // Nama malware dari ClamAV
+    UploadedBy *int64    `json:"uploaded_by"`
+    CreatedAt  time.Time `json:"created_at"`
+}
+
+func (UploadQuarantine) TableName() string {
+    return "upload_quarantine"
+}
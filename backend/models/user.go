@@ -3,20 +3,65 @@ package models
 import "time"
 
 type User struct {
-    ID        int64     `json:"id" gorm:"primaryKey"`
-    Username  string    `json:"username" gorm:"unique;not null"`
-    Password  string    `json:"-" gorm:"not null"`
-    Email     string    `json:"email" gorm:"unique"`
-    FullName  string    `json:"full_name"`
-    Role      string    `json:"role" gorm:"default:'user'"`
-    CreatedAt time.Time `json:"created_at"`
-    UpdatedAt time.Time `json:"updated_at"`
+    ID       int64  `json:"id" gorm:"primaryKey"`
+    Username string `json:"username" gorm:"unique;not null"`
+    Password string `json:"-" gorm:"not null"`
+    Email    string `json:"email" gorm:"unique"`
+    FullName string `json:"full_name"`
+    Role     string `json:"role" gorm:"default:'user'"`
+    Unit     string `json:"unit" gorm:"index"` // Unit kerja (bidang), dipakai untuk izin peraturan terbatas
+    // Status akun; user lama dianggap aktif, pendaftaran baru menunggu persetujuan admin
+    Status          string     `json:"status" gorm:"size:20;not null;default:'active';index"`
+    StatusAlasan    string     `json:"status_alasan"` // Alasan penolakan/penangguhan
+    StatusUpdatedAt *time.Time `json:"status_updated_at"`
+    StatusUpdatedBy *int64     `json:"status_updated_by"`
+    // Pegawai yang terhubung lewat NIP; dianggap milik user setelah diverifikasi admin
+    EmployeeID         *uint      `json:"employee_id" gorm:"uniqueIndex"`
+    Employee           *Employee  `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+    EmployeeVerifiedAt *time.Time `json:"employee_verified_at"`
+    EmployeeVerifiedBy *int64     `json:"employee_verified_by"`
+    CreatedAt          time.Time  `json:"created_at"`
+    UpdatedAt          time.Time  `json:"updated_at"`
+    // Permission dari role, dimuat per request oleh access.Load
+    Permissions []string `json:"permissions,omitempty" gorm:"-"`
 }
 
+// Can mengecek user (boleh nil) memiliki permission; Permissions harus sudah dimuat
+func (u *User) Can(permission string) bool {
+    if u == nil {
+        return false
+    }
+    for _, p := range u.Permissions {
+        if p == permission {
+            return true
+        }
+    }
+    return false
+}
+
+// VerifiedEmployee mengembalikan data pegawai user jika sudah diverifikasi dan dimuat
+func (u *User) VerifiedEmployee() *Employee {
+    if u.EmployeeVerifiedAt == nil {
+        return nil
+    }
+    return u.Employee
+}
+
+// Status akun user
+const (
+    UserPending   = "pending"   // Baru mendaftar, menunggu persetujuan admin
+    UserActive    = "active"    // Boleh login
+    UserRejected  = "rejected"  // Pendaftaran ditolak
+    UserSuspended = "suspended" // Akun aktif yang dinonaktifkan admin
+)
+
 type Session struct {
-    ID        int64     `json:"id" gorm:"primaryKey"`
-    UserID    int64     `json:"user_id"`
-    Token     string    `json:"token" gorm:"unique;not null"`
-    ExpiresAt time.Time `json:"expires_at"`
-    CreatedAt time.Time `json:"created_at"`
+    ID         int64     `json:"id" gorm:"primaryKey"`
+    UserID     int64     `json:"user_id" gorm:"index"`
+    TokenHash  string    `json:"-" gorm:"size:64;uniqueIndex;not null"` // SHA-256 dari token cookie; token aslinya tidak disimpan
+    ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+    LastSeenAt time.Time `json:"last_seen_at"`
+    IPAddress  string    `json:"ip_address" gorm:"size:45"`
+    UserAgent  string    `json:"user_agent" gorm:"size:255"`
+    CreatedAt  time.Time `json:"created_at"`
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"backend/models"
+)
+
+// AccountStatusChanged mengirim email ke pemilik akun saat pendaftarannya disetujui/ditolak atau
+// akunnya ditangguhkan. Email masuk outbox sehingga ikut dikirim ulang jika SMTP gagal.
+// Aman dipanggil pada Notifier nil.
+func (n *Notifier) AccountStatusChanged(user models.User) {
+    if n == nil || user.Email == "" {
+        return
+    }
+
+    alasan := user.StatusAlasan
+    if alasan == "" {
+        alasan = "-"
+    }
+    var subject, body string
+    switch user.Status {
+    case models.UserActive:
+        subject = "Akun Anda telah disetujui"
+        body = fmt.Sprintf("Halo %s,\n\nAkun %s telah disetujui admin. Anda sekarang dapat login di %s/login.",
+            displayName(user), user.Username, n.appURL)
+    case models.UserRejected:
+        subject = "Pendaftaran akun ditolak"
+        body = fmt.Sprintf("Halo %s,\n\nPendaftaran akun %s ditolak admin.\nAlasan: %s",
+            displayName(user), user.Username, alasan)
+    case models.UserSuspended:
+        subject = "Akun Anda dinonaktifkan"
+        body = fmt.Sprintf("Halo %s,\n\nAkun %s dinonaktifkan admin dan tidak dapat dipakai untuk login.\nAlasan: %s",
+            displayName(user), user.Username, alasan)
+    default:
+        return
+    }
+
+    if err := enqueue(n.db, user, subject, body); err != nil {
+        log.Printf("WARNING: Failed to queue account status email for user %d: %v", user.ID, err)
+    }
+}
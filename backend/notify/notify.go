@@ -0,0 +1,407 @@
+// Package notify membuat notifikasi inbox untuk pelanggan kategori/jenis/instansi saat
+// peraturan baru atau diubah, lalu mengirim email lewat outbox: langsung atau sebagai
+// ringkasan harian sesuai preferensi user.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/access"
+	"backend/mailer"
+	"backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+    outboxInterval = 30 * time.Second
+    outboxBatch    = 50
+    maxAttempts    = 5
+    sendingLease   = 10 * time.Minute // Email sending lebih lama dari ini dianggap ditinggal worker yang mati
+)
+
+type Notifier struct {
+    db         *gorm.DB
+    sender     mailer.Sender
+    appURL     string
+    digestHour int
+}
+
+// New membuat Notifier. appURL adalah alamat frontend untuk tautan di email.
+// Jam pengiriman ringkasan harian dibaca dari NOTIFY_DIGEST_HOUR (0-23, default 7).
+func New(db *gorm.DB, sender mailer.Sender, appURL string) *Notifier {
+    hour, err := strconv.Atoi(os.Getenv("NOTIFY_DIGEST_HOUR"))
+    if err != nil || hour < 0 || hour > 23 {
+        hour = 7
+    }
+    return &Notifier{db: db, sender: sender, appURL: strings.TrimRight(appURL, "/"), digestHour: hour}
+}
+
+// Start menjalankan worker outbox dan ringkasan harian di background
+func (n *Notifier) Start() {
+    go n.runOutbox()
+    go n.runDigest()
+}
+
+// PeraturanChanged membuat notifikasi untuk pelanggan yang cocok dengan peraturan, di background.
+// actorID (user yang mengubah) tidak ikut dinotifikasi. Aman dipanggil pada Notifier nil.
+func (n *Notifier) PeraturanChanged(p models.Peraturan, aksi string, fileChanged bool, actorID *int64) {
+    if n == nil {
+        return
+    }
+    go func() {
+        if err := n.notify(p, aksi, fileChanged, actorID); err != nil {
+            log.Printf("WARNING: Failed to create notifications for peraturan %d: %v", p.ID, err)
+        }
+    }()
+}
+
+func (n *Notifier) notify(p models.Peraturan, aksi string, fileChanged bool, actorID *int64) error {
+    alasan, err := n.matchSubscribers(p)
+    if err != nil || len(alasan) == 0 {
+        return err
+    }
+    if actorID != nil {
+        delete(alasan, *actorID)
+    }
+
+    userIDs := sortedKeys(alasan)
+    // Akun pending, ditolak, atau ditangguhkan tidak menerima notifikasi
+    var users []models.User
+    if err := n.db.Where("id IN ? AND status = ?", userIDs, models.UserActive).Find(&users).Error; err != nil {
+        return err
+    }
+
+    // Pelanggan yang tidak boleh melihat peraturan ini tidak diberi tahu
+    if p.Visibilitas == models.VisibilitasTerbatas {
+        if err := n.db.Where("peraturan_id = ?", p.ID).Find(&p.Izin).Error; err != nil {
+            return err
+        }
+    }
+    visible := users[:0]
+    for _, user := range users {
+        if err := access.Load(n.db, &user); err != nil {
+            return err
+        }
+        if p.VisibleTo(&user) {
+            visible = append(visible, user)
+        }
+    }
+    users = visible
+    prefs, err := n.preferences(userIDs)
+    if err != nil {
+        return err
+    }
+
+    judul, pesan := message(p, aksi, fileChanged)
+    now := time.Now()
+    return n.db.Transaction(func(tx *gorm.DB) error {
+        for _, user := range users {
+            notifikasi := models.Notifikasi{
+                UserID:      user.ID,
+                PeraturanID: p.ID,
+                Aksi:        aksi,
+                Judul:       judul,
+                Pesan:       pesan,
+                Alasan:      alasan[user.ID],
+                CreatedAt:   now,
+            }
+
+            pref := prefs[user.ID]
+            if pref.Email && pref.Frekuensi == models.FrekuensiLangsung && user.Email != "" {
+                body := fmt.Sprintf("Halo %s,\n\n%s\n%s\n\nAnda menerima email ini karena berlangganan %s.\n%s",
+                    displayName(user), judul, pesan, notifikasi.Alasan, n.footer())
+                if err := enqueue(tx, user, judul, body); err != nil {
+                    return err
+                }
+                notifikasi.EmailQueuedAt = &now
+            }
+            if err := tx.Create(&notifikasi).Error; err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// matchSubscribers mengembalikan user yang langganannya cocok beserta alasannya.
+// Langganan kategori juga cocok untuk peraturan di sub-kategorinya.
+func (n *Notifier) matchSubscribers(p models.Peraturan) (map[int64]string, error) {
+    var direct []uint
+    if err := n.db.Table("peraturan_kategori").Where("peraturan_id = ?", p.ID).Pluck("kategori_id", &direct).Error; err != nil {
+        return nil, err
+    }
+    var all []models.Kategori
+    if err := n.db.Select("id, nama, parent_id").Find(&all).Error; err != nil {
+        return nil, err
+    }
+    byID := map[uint]models.Kategori{}
+    for _, k := range all {
+        byID[k.ID] = k
+    }
+    kategoriIDs := []uint{}
+    seen := map[uint]bool{}
+    for _, id := range direct {
+        // Naik ke induk; seen sekaligus mencegah loop jika data parent rusak
+        for cur, ok := byID[id]; ok && !seen[cur.ID]; {
+            seen[cur.ID] = true
+            kategoriIDs = append(kategoriIDs, cur.ID)
+            if cur.ParentID == nil {
+                break
+            }
+            cur, ok = byID[*cur.ParentID]
+        }
+    }
+
+    var subs []models.Langganan
+    if err := n.db.Where("(tipe = ? AND kategori_id IN ?) OR (tipe = ? AND LOWER(nilai) = LOWER(?)) OR (tipe = ? AND LOWER(nilai) = LOWER(?))",
+        models.LanggananKategori, kategoriIDs,
+        models.LanggananJenis, p.JenisPeraturan,
+        models.LanggananInstansi, p.InstansiPembuat,
+    ).Order("id asc").Find(&subs).Error; err != nil {
+        return nil, err
+    }
+
+    reasons := map[int64][]string{}
+    for _, s := range subs {
+        reason := "instansi " + s.Nilai
+        switch s.Tipe {
+        case models.LanggananKategori:
+            if s.KategoriID == nil {
+                continue
+            }
+            reason = "kategori " + byID[*s.KategoriID].Nama
+        case models.LanggananJenis:
+            reason = "jenis " + s.Nilai
+        }
+        reasons[s.UserID] = append(reasons[s.UserID], reason)
+    }
+
+    result := make(map[int64]string, len(reasons))
+    for userID, r := range reasons {
+        result[userID] = strings.Join(r, ", ")
+    }
+    return result, nil
+}
+
+// preferences mengambil preferensi user; user tanpa record mendapat default (email langsung)
+func (n *Notifier) preferences(userIDs []int64) (map[int64]models.PreferensiNotifikasi, error) {
+    var rows []models.PreferensiNotifikasi
+    if err := n.db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    prefs := make(map[int64]models.PreferensiNotifikasi, len(userIDs))
+    for _, id := range userIDs {
+        prefs[id] = models.PreferensiNotifikasi{UserID: id, Frekuensi: models.FrekuensiLangsung, Email: true}
+    }
+    for _, row := range rows {
+        prefs[row.UserID] = row
+    }
+    return prefs, nil
+}
+
+func message(p models.Peraturan, aksi string, fileChanged bool) (string, string) {
+    label := strings.TrimSpace(strings.ToUpper(p.JenisPeraturan) + " Nomor " + p.Nomor)
+    judul := "Peraturan baru: " + label
+    if aksi == models.NotifikasiDiubah {
+        judul = "Peraturan diperbarui: " + label
+    }
+
+    lines := []string{
+        p.Judul,
+        "Instansi: " + p.InstansiPembuat,
+        "Ditetapkan: " + p.TanggalDitetapkan.Format("02-01-2006"),
+    }
+    if aksi == models.NotifikasiDiubah && fileChanged {
+        lines = append(lines, "File peraturan telah diganti dengan versi baru.")
+    }
+    return judul, strings.Join(lines, "\n")
+}
+
+func displayName(user models.User) string {
+    if user.FullName != "" {
+        return user.FullName
+    }
+    return user.Username
+}
+
+func (n *Notifier) footer() string {
+    return fmt.Sprintf("Lihat peraturan: %s/peraturan\nUbah langganan dan preferensi notifikasi di aplikasi.", n.appURL)
+}
+
+// enqueue memasukkan email ke outbox untuk dikirim worker
+func enqueue(tx *gorm.DB, user models.User, subject, body string) error {
+    userID := user.ID
+    return tx.Create(&models.EmailOutbox{
+        UserID:        &userID,
+        To:            user.Email,
+        Subject:       subject,
+        Body:          body,
+        Status:        models.EmailPending,
+        NextAttemptAt: time.Now(),
+    }).Error
+}
+
+// SendDigests mengirim ringkasan notifikasi yang belum diemail ke user dengan preferensi harian
+func (n *Notifier) SendDigests() (int, error) {
+    var userIDs []int64
+    if err := n.db.Model(&models.PreferensiNotifikasi{}).
+        Where("frekuensi = ? AND email = ?", models.FrekuensiHarian, true).
+        Pluck("user_id", &userIDs).Error; err != nil {
+        return 0, err
+    }
+
+    sent := 0
+    for _, userID := range userIDs {
+        var user models.User
+        if err := n.db.Where("status = ?", models.UserActive).First(&user, userID).Error; err != nil || user.Email == "" {
+            continue
+        }
+
+        var items []models.Notifikasi
+        if err := n.db.Where("user_id = ? AND email_queued_at IS NULL", userID).Order("created_at asc").Find(&items).Error; err != nil {
+            return sent, err
+        }
+        if len(items) == 0 {
+            continue
+        }
+
+        var body strings.Builder
+        fmt.Fprintf(&body, "Halo %s,\n\nBerikut %d pembaruan peraturan sesuai langganan Anda:\n", displayName(user), len(items))
+        ids := make([]uint, 0, len(items))
+        for i, item := range items {
+            fmt.Fprintf(&body, "\n%d. %s\n%s\n(%s)\n", i+1, item.Judul, item.Pesan, item.Alasan)
+            ids = append(ids, item.ID)
+        }
+        body.WriteString("\n" + n.footer())
+
+        subject := fmt.Sprintf("Ringkasan harian: %d pembaruan peraturan", len(items))
+        err := n.db.Transaction(func(tx *gorm.DB) error {
+            if err := enqueue(tx, user, subject, body.String()); err != nil {
+                return err
+            }
+            return tx.Model(&models.Notifikasi{}).Where("id IN ?", ids).UpdateColumn("email_queued_at", time.Now()).Error
+        })
+        if err != nil {
+            return sent, err
+        }
+        sent++
+    }
+    return sent, nil
+}
+
+// FlushOutbox mengirim email yang sudah waktunya dikirim. Email gagal dicoba ulang dengan
+// jeda yang makin panjang dan ditandai failed setelah maxAttempts percobaan.
+func (n *Notifier) FlushOutbox(ctx context.Context) (int, error) {
+    sent := 0
+    for {
+        batch, err := n.claimOutbox()
+        if err != nil {
+            return sent, err
+        }
+
+        // SMTP dipanggil di luar transaksi agar koneksi database tidak tertahan selama pengiriman
+        for i := range batch {
+            email := &batch[i]
+            err := n.sender.Send(ctx, mailer.Message{To: []string{email.To}, Subject: email.Subject, Body: email.Body})
+            email.Attempts++
+            if err == nil {
+                now := time.Now()
+                email.Status = models.EmailSent
+                email.SentAt = &now
+                email.LastError = ""
+                sent++
+            } else {
+                email.Status = models.EmailPending
+                email.LastError = err.Error()
+                email.NextAttemptAt = time.Now().Add(time.Duration(email.Attempts*email.Attempts) * time.Minute)
+                if email.Attempts >= maxAttempts {
+                    email.Status = models.EmailFailed
+                }
+                log.Printf("WARNING: Failed to send email %d to %s (attempt %d): %v", email.ID, email.To, email.Attempts, err)
+            }
+            if err := n.db.Save(email).Error; err != nil {
+                return sent, err
+            }
+        }
+        if len(batch) < outboxBatch || ctx.Err() != nil {
+            return sent, nil
+        }
+    }
+}
+
+// claimOutbox menandai satu batch email sebagai sending lalu mengembalikannya. SKIP LOCKED agar
+// beberapa instance backend tidak mengambil email yang sama; email sending yang melewati
+// sendingLease (worker mati di tengah pengiriman) diambil ulang.
+func (n *Notifier) claimOutbox() ([]models.EmailOutbox, error) {
+    var batch []models.EmailOutbox
+    err := n.db.Transaction(func(tx *gorm.DB) error {
+        now := time.Now()
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("status IN ? AND next_attempt_at <= ?", []string{models.EmailPending, models.EmailSending}, now).
+            Order("id asc").Limit(outboxBatch).Find(&batch).Error; err != nil {
+            return err
+        }
+        if len(batch) == 0 {
+            return nil
+        }
+
+        ids := make([]uint, 0, len(batch))
+        for _, email := range batch {
+            ids = append(ids, email.ID)
+        }
+        return tx.Model(&models.EmailOutbox{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+            "status":          models.EmailSending,
+            "next_attempt_at": now.Add(sendingLease),
+        }).Error
+    })
+    return batch, err
+}
+
+func (n *Notifier) runOutbox() {
+    ticker := time.NewTicker(outboxInterval)
+    defer ticker.Stop()
+    for range ticker.C {
+        if _, err := n.FlushOutbox(context.Background()); err != nil {
+            log.Printf("WARNING: Email outbox failed: %v", err)
+        }
+    }
+}
+
+// runDigest mengirim ringkasan harian setiap hari pada jam digestHour
+func (n *Notifier) runDigest() {
+    for {
+        now := time.Now()
+        next := time.Date(now.Year(), now.Month(), now.Day(), n.digestHour, 0, 0, 0, now.Location())
+        if !next.After(now) {
+            next = next.AddDate(0, 0, 1)
+        }
+        time.Sleep(time.Until(next))
+
+        count, err := n.SendDigests()
+        if err != nil {
+            log.Printf("WARNING: Daily digest failed: %v", err)
+        }
+        if count > 0 {
+            log.Printf("INFO: Daily digest queued for %d user(s)", count)
+        }
+    }
+}
+
+// sortedKeys mengurutkan id user agar notifikasi dibuat dengan urutan yang stabil
+func sortedKeys(m map[int64]string) []int64 {
+    keys := make([]int64, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+    return keys
+}
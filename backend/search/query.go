@@ -0,0 +1,165 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Hit adalah satu halaman yang cocok dengan kata kunci beserta cuplikan yang di-highlight.
+// Pasal diisi jika ada pasal di halaman tersebut yang juga cocok dengan kata kunci.
+type Hit struct {
+    Halaman int     `json:"halaman"`
+    Snippet string  `json:"snippet"`
+    Rank    float64 `json:"rank"`
+    Pasal   string  `json:"pasal,omitempty"`
+}
+
+// Result mengelompokkan hit per peraturan
+type Result struct {
+    Peraturan models.Peraturan `json:"peraturan"`
+    Rank      float64          `json:"rank"`
+    Hits      []Hit            `json:"hits"`
+}
+
+type pasalHit struct {
+    PeraturanID  uint
+    Nomor        string
+    Halaman      int
+    HalamanAkhir int
+}
+
+type pageHit struct {
+    PeraturanID uint
+    Halaman     int
+    Rank        float64
+    Snippet     string
+}
+
+// Penanda awal/akhir highlight dari ts_headline. Karakter private-use dipakai agar tidak bentrok
+// dengan isi dokumen; cuplikan di-escape dulu baru penanda diganti <mark> (lihat highlightHTML).
+const (
+    markStart = "\uE000"
+    markStop  = "\uE001"
+)
+
+// headlineOptions mengatur cuplikan ts_headline
+const headlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// highlightHTML meng-escape cuplikan ts_headline lalu mengganti penanda dengan <mark> yang
+// dipakai frontend untuk highlight. Isi dokumen tidak pernah dikirim sebagai HTML mentah.
+func highlightHTML(snippet string) string {
+    return markReplacer.Replace(html.EscapeString(snippet))
+}
+
+// MatchingIDs adalah subquery id peraturan yang isinya cocok dengan kata kunci.
+// websearch_to_tsquery mendukung "frasa", OR, dan -kata dari input pengguna.
+func MatchingIDs(db *gorm.DB, q string) *gorm.DB {
+    return db.Model(&models.PeraturanHalaman{}).
+        Select("peraturan_id").
+        Where(fmt.Sprintf("tsv @@ websearch_to_tsquery('%s', ?)", Config), q)
+}
+
+// Search mencari isi peraturan, diurutkan berdasarkan relevansi. maxHits membatasi
+// jumlah halaman yang ditampilkan per peraturan. allowed adalah subquery id peraturan yang
+// boleh muncul di hasil (nil = semua); dipasang sebelum LIMIT agar jumlah hasil tetap penuh.
+func Search(db *gorm.DB, q string, limit, maxHits int, allowed *gorm.DB) ([]Result, error) {
+    vars := map[string]interface{}{"q": q, "rows": limit * maxHits * 4}
+    allowedSQL := ""
+    if allowed != nil {
+        allowedSQL = "AND peraturan_id IN (@allowed)"
+        vars["allowed"] = allowed
+    }
+
+    var hits []pageHit
+    // Ranking dilakukan dulu di subquery agar ts_headline (mahal) hanya dihitung untuk baris teratas
+    sql := fmt.Sprintf(`
+        SELECT h.peraturan_id, h.halaman, h.rank,
+               ts_headline('%[1]s', h.konten, websearch_to_tsquery('%[1]s', @q), '%[2]s') AS snippet
+        FROM (
+            SELECT peraturan_id, halaman, konten,
+                   ts_rank_cd(tsv, websearch_to_tsquery('%[1]s', @q)) AS rank
+            FROM peraturan_halaman
+            WHERE tsv @@ websearch_to_tsquery('%[1]s', @q) %[3]s
+            ORDER BY rank DESC
+            LIMIT @rows
+        ) h
+        ORDER BY h.rank DESC`, Config, headlineOptions, allowedSQL)
+
+    if err := db.Raw(sql, vars).Scan(&hits).Error; err != nil {
+        return nil, err
+    }
+
+    var order []uint
+    grouped := map[uint]*Result{}
+    for _, h := range hits {
+        r, ok := grouped[h.PeraturanID]
+        if !ok {
+            if len(order) >= limit {
+                continue
+            }
+            r = &Result{Rank: h.Rank}
+            grouped[h.PeraturanID] = r
+            order = append(order, h.PeraturanID)
+        }
+        if len(r.Hits) < maxHits {
+            r.Hits = append(r.Hits, Hit{Halaman: h.Halaman, Snippet: highlightHTML(h.Snippet), Rank: h.Rank})
+        }
+    }
+
+    if len(order) == 0 {
+        return []Result{}, nil
+    }
+
+    var peraturans []models.Peraturan
+    if err := db.Preload("KategoriItems").Where("id IN ?", order).Find(&peraturans).Error; err != nil {
+        return nil, err
+    }
+    for _, p := range peraturans {
+        grouped[p.ID].Peraturan = p
+    }
+    if err := attachPasal(db, q, order, grouped); err != nil {
+        return nil, err
+    }
+
+    results := make([]Result, 0, len(order))
+    for _, id := range order {
+        if grouped[id].Peraturan.ID == 0 {
+            continue // Peraturan sudah dihapus tapi indeks belum dibersihkan
+        }
+        results = append(results, *grouped[id])
+    }
+    return results, nil
+}
+
+// attachPasal menautkan setiap hit halaman ke pasal paling relevan yang mencakup halaman tersebut
+func attachPasal(db *gorm.DB, q string, ids []uint, grouped map[uint]*Result) error {
+    var pasals []pasalHit
+    err := db.Model(&models.PeraturanPasal{}).
+        Select("peraturan_id, nomor, halaman, halaman_akhir").
+        Where("peraturan_id IN ?", ids).
+        Where(fmt.Sprintf("tsv @@ websearch_to_tsquery('%s', ?)", Config), q).
+        Order(gorm.Expr(fmt.Sprintf("ts_rank_cd(tsv, websearch_to_tsquery('%s', ?)) DESC", Config), q)).
+        Scan(&pasals).Error
+    if err != nil {
+        return err
+    }
+
+    for _, r := range grouped {
+        for i := range r.Hits {
+            for _, p := range pasals {
+                if p.PeraturanID == r.Peraturan.ID && p.Halaman <= r.Hits[i].Halaman && r.Hits[i].Halaman <= p.HalamanAkhir {
+                    r.Hits[i].Pasal = p.Nomor
+                    break
+                }
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,164 @@
+// Package search mengelola indeks full-text isi file peraturan menggunakan tsvector PostgreSQL.
+package search
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/models"
+	"backend/storage"
+	"backend/struktur"
+	"backend/textextract"
+
+	"gorm.io/gorm"
+)
+
+// Config adalah text search configuration PostgreSQL (stemmer Snowball bahasa Indonesia, PostgreSQL 12+)
+const Config = "indonesian"
+
+// Migrate menambahkan kolom tsvector dan GIN index pada tabel peraturan_halaman dan peraturan_pasal.
+// Dipanggil setelah AutoMigrate karena generated column tidak bisa dibuat lewat tag GORM.
+func Migrate(db *gorm.DB) error {
+    statements := []string{
+        fmt.Sprintf(`ALTER TABLE peraturan_halaman ADD COLUMN IF NOT EXISTS tsv tsvector
+            GENERATED ALWAYS AS (to_tsvector('%s', coalesce(konten, ''))) STORED`, Config),
+        `CREATE INDEX IF NOT EXISTS idx_peraturan_halaman_tsv ON peraturan_halaman USING GIN (tsv)`,
+        fmt.Sprintf(`ALTER TABLE peraturan_pasal ADD COLUMN IF NOT EXISTS tsv tsvector
+            GENERATED ALWAYS AS (to_tsvector('%s', coalesce(konten, ''))) STORED`, Config),
+        `CREATE INDEX IF NOT EXISTS idx_peraturan_pasal_tsv ON peraturan_pasal USING GIN (tsv)`,
+    }
+    for _, stmt := range statements {
+        if err := db.Exec(stmt).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// IndexPeraturan mengekstrak teks file peraturan dan mengganti isi indeksnya,
+// termasuk pemecahan pasal/ayat untuk deep link
+func IndexPeraturan(db *gorm.DB, store storage.Storage, p *models.Peraturan) error {
+    if p.PathFile == "" {
+        return nil
+    }
+
+    data, err := storage.ReadAll(context.Background(), store, p.PathFile)
+    if err != nil {
+        return err
+    }
+
+    pages, err := textextract.Extract(data)
+    if err == textextract.ErrUnsupportedFormat {
+        // File .doc lama tidak bisa diekstrak, tandai saja agar tidak diulang terus
+        pages = nil
+    } else if err != nil {
+        return err
+    }
+
+    now := time.Now()
+    return db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("peraturan_id = ?", p.ID).Delete(&models.PeraturanHalaman{}).Error; err != nil {
+            return err
+        }
+
+        rows := make([]models.PeraturanHalaman, 0, len(pages))
+        for i, text := range pages {
+            if strings.TrimSpace(text) == "" {
+                continue // Halaman hasil scan tanpa lapisan teks
+            }
+            rows = append(rows, models.PeraturanHalaman{
+                PeraturanID: p.ID,
+                Halaman:     i + 1,
+                Konten:      text,
+                CreatedAt:   now,
+            })
+        }
+        if len(rows) > 0 {
+            if err := tx.CreateInBatches(&rows, 50).Error; err != nil {
+                return err
+            }
+        }
+
+        if err := savePasal(tx, p.ID, struktur.Parse(pages)); err != nil {
+            return err
+        }
+
+        return tx.Model(&models.Peraturan{}).Where("id = ?", p.ID).UpdateColumn("indexed_at", now).Error
+    })
+}
+
+// DeletePasal menghapus pasal dan ayat milik peraturan
+func DeletePasal(tx *gorm.DB, peraturanID uint) error {
+    if err := tx.Where("pasal_id IN (?)", tx.Model(&models.PeraturanPasal{}).Select("id").Where("peraturan_id = ?", peraturanID)).
+        Delete(&models.PeraturanAyat{}).Error; err != nil {
+        return err
+    }
+    return tx.Where("peraturan_id = ?", peraturanID).Delete(&models.PeraturanPasal{}).Error
+}
+
+func savePasal(tx *gorm.DB, peraturanID uint, parsed []struktur.Pasal) error {
+    if err := DeletePasal(tx, peraturanID); err != nil {
+        return err
+    }
+    if len(parsed) == 0 {
+        return nil
+    }
+
+    rows := make([]models.PeraturanPasal, 0, len(parsed))
+    for i, p := range parsed {
+        row := models.PeraturanPasal{
+            PeraturanID:  peraturanID,
+            Urutan:       i + 1,
+            Nomor:        p.Nomor,
+            Bab:          p.Bab,
+            JudulBab:     truncate(p.JudulBab, 255),
+            Halaman:      p.Halaman,
+            HalamanAkhir: p.HalamanAkhir,
+            Offset:       p.Offset,
+            Konten:       p.Konten,
+        }
+        for _, a := range p.Ayat {
+            row.Ayat = append(row.Ayat, models.PeraturanAyat{Nomor: a.Nomor, Halaman: a.Halaman, Konten: a.Konten})
+        }
+        rows = append(rows, row)
+    }
+    // Ayat ikut tersimpan lewat asosiasi has-many
+    return tx.CreateInBatches(&rows, 50).Error
+}
+
+func truncate(s string, n int) string {
+    if len(s) <= n {
+        return s
+    }
+    for n > 0 && !utf8.RuneStart(s[n]) {
+        n--
+    }
+    return s[:n]
+}
+
+// Reindex mengindeks ulang peraturan yang sudah ada. Jika all=false hanya yang belum pernah diindeks.
+func Reindex(db *gorm.DB, store storage.Storage, all bool) (int, error) {
+    query := db.Model(&models.Peraturan{}).Where("path_file <> ''")
+    if !all {
+        query = query.Where("indexed_at IS NULL")
+    }
+
+    var peraturans []models.Peraturan
+    if err := query.Order("id asc").Find(&peraturans).Error; err != nil {
+        return 0, err
+    }
+
+    indexed := 0
+    for i := range peraturans {
+        if err := IndexPeraturan(db, store, &peraturans[i]); err != nil {
+            log.Printf("WARNING: Failed to index peraturan %d (%s): %v", peraturans[i].ID, peraturans[i].NamaFile, err)
+            continue
+        }
+        indexed++
+    }
+    return indexed, nil
+}
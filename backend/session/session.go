@@ -0,0 +1,159 @@
+// Package session mengelola session login berbasis cookie. Browser hanya menerima token acak
+// 256-bit; database menyimpan hash SHA-256-nya sehingga isi tabel sessions tidak bisa dipakai
+// untuk login. Expiry diperpanjang selama session dipakai (sliding) sampai batas MaxLifetime.
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"backend/access"
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CookieName adalah nama cookie yang berisi token session
+const CookieName = "token"
+
+const (
+    IdleTimeout   = 24 * time.Hour     // Session berakhir jika tidak dipakai selama ini
+    MaxLifetime   = 7 * 24 * time.Hour // Batas umur session walaupun terus dipakai
+    touchInterval = 5 * time.Minute    // Expiry diperpanjang paling sering sekali per interval
+)
+
+var ErrInvalid = errors.New("invalid or expired session")
+
+// NewToken membuat token acak 256-bit. Dipakai juga untuk token reset password.
+func NewToken() (string, error) {
+    raw := make([]byte, 32)
+    if _, err := rand.Read(raw); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// HashToken adalah nilai yang disimpan di kolom token_hash
+func HashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+func setCookie(c *gin.Context, token string, expiresAt time.Time) {
+    c.SetCookie(CookieName, token, int(time.Until(expiresAt).Seconds()), "/", "", false, true)
+}
+
+// Migrate menghapus session format lama (token disimpan apa adanya). Harus dijalankan sebelum
+// AutoMigrate; semua user perlu login ulang.
+func Migrate(db *gorm.DB) error {
+    if !db.Migrator().HasTable(&models.Session{}) || !db.Migrator().HasColumn(&models.Session{}, "token") {
+        return nil
+    }
+    if err := db.Exec("DELETE FROM sessions").Error; err != nil {
+        return err
+    }
+    if err := db.Migrator().DropColumn(&models.Session{}, "token"); err != nil {
+        return err
+    }
+    log.Println("INFO: Legacy sessions invalidated, users need to log in again")
+    return nil
+}
+
+// Create membuat session baru untuk user dan mengirim cookie-nya
+func Create(c *gin.Context, db *gorm.DB, userID int64) error {
+    token, err := NewToken()
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    userAgent := c.Request.UserAgent()
+    if len(userAgent) > 255 {
+        userAgent = userAgent[:255]
+    }
+    s := models.Session{
+        UserID:     userID,
+        TokenHash:  HashToken(token),
+        ExpiresAt:  now.Add(IdleTimeout),
+        LastSeenAt: now,
+        IPAddress:  c.ClientIP(),
+        UserAgent:  userAgent,
+        CreatedAt:  now,
+    }
+    if err := db.Create(&s).Error; err != nil {
+        return err
+    }
+    // Bersihkan session user yang sudah kedaluwarsa
+    db.Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&models.Session{})
+
+    setCookie(c, token, s.ExpiresAt)
+    c.Set("session_id", s.ID)
+    return nil
+}
+
+// Lookup mencari user dari token. Jika session masih berlaku, expiry diperpanjang dan cookie
+// dikirim ulang; id session disimpan di context sebagai "session_id".
+func Lookup(c *gin.Context, db *gorm.DB, token string) (models.User, error) {
+    var s models.Session
+    if err := db.Where("token_hash = ? AND expires_at > ?", HashToken(token), time.Now()).First(&s).Error; err != nil {
+        return models.User{}, ErrInvalid
+    }
+    var user models.User
+    if err := db.Preload("Employee").First(&user, s.UserID).Error; err != nil || user.Status != models.UserActive {
+        return models.User{}, ErrInvalid
+    }
+    if err := access.Load(db, &user); err != nil {
+        return models.User{}, err
+    }
+
+    now := time.Now()
+    if expiresAt, ok := touchExpiry(s, now); ok {
+        if err := db.Model(&s).Updates(map[string]interface{}{"expires_at": expiresAt, "last_seen_at": now}).Error; err == nil {
+            setCookie(c, token, expiresAt)
+        }
+    }
+    c.Set("session_id", s.ID)
+    return user, nil
+}
+
+// touchExpiry menghitung expiry baru jika session dipakai pada now: diperpanjang IdleTimeout
+// paling sering sekali per touchInterval, dan tidak pernah melewati CreatedAt + MaxLifetime
+func touchExpiry(s models.Session, now time.Time) (time.Time, bool) {
+    if now.Sub(s.LastSeenAt) < touchInterval {
+        return s.ExpiresAt, false
+    }
+    expiresAt := now.Add(IdleTimeout)
+    if limit := s.CreatedAt.Add(MaxLifetime); expiresAt.After(limit) {
+        expiresAt = limit
+    }
+    return expiresAt, true
+}
+
+// Revoke menghapus session dari cookie request ini dan cookie-nya (logout)
+func Revoke(c *gin.Context, db *gorm.DB) error {
+    token, err := c.Cookie(CookieName)
+    if err != nil {
+        return nil
+    }
+    c.SetCookie(CookieName, "", -1, "/", "", false, true)
+    return db.Where("token_hash = ?", HashToken(token)).Delete(&models.Session{}).Error
+}
+
+// RevokeUser menghapus semua session milik user, kecuali session exceptID (0 = semua)
+func RevokeUser(db *gorm.DB, userID, exceptID int64) error {
+    return db.Where("user_id = ? AND id <> ?", userID, exceptID).Delete(&models.Session{}).Error
+}
+
+// Rotate mencabut semua session user lalu membuat session baru untuk request ini. Dipakai saat
+// hak akses user berubah agar token yang dibuat sebelumnya tidak bisa dipakai lagi.
+func Rotate(c *gin.Context, db *gorm.DB, userID int64) error {
+    if err := RevokeUser(db, userID, 0); err != nil {
+        return err
+    }
+    return Create(c, db, userID)
+}
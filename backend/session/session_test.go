@@ -0,0 +1,207 @@
+package session
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/dbtest"
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestNewToken(t *testing.T) {
+    seen := map[string]bool{}
+    for i := 0; i < 100; i++ {
+        token, err := NewToken()
+        if err != nil {
+            t.Fatal(err)
+        }
+        raw, err := base64.RawURLEncoding.DecodeString(token)
+        if err != nil || len(raw) != 32 {
+            t.Fatalf("token %q is not 32 bytes of base64url: %v", token, err)
+        }
+        if seen[token] {
+            t.Fatalf("duplicate token %q", token)
+        }
+        seen[token] = true
+    }
+}
+
+func TestHashToken(t *testing.T) {
+    token, _ := NewToken()
+    hash := HashToken(token)
+    if len(hash) != 64 || strings.Contains(hash, token) {
+        t.Fatalf("hash = %q", hash)
+    }
+    if HashToken(token) != hash {
+        t.Fatal("hash is not deterministic")
+    }
+    if HashToken(token+"x") == hash {
+        t.Fatal("different tokens share a hash")
+    }
+}
+
+func TestTouchExpiry(t *testing.T) {
+    created := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+    s := models.Session{CreatedAt: created, LastSeenAt: created, ExpiresAt: created.Add(IdleTimeout)}
+
+    cases := []struct {
+        name  string
+        now   time.Time
+        want  time.Time
+        touch bool
+    }{
+        {"within touch interval", created.Add(touchInterval - time.Second), s.ExpiresAt, false},
+        {"at touch interval", created.Add(touchInterval), created.Add(touchInterval + IdleTimeout), true},
+        {"after touch interval", created.Add(time.Hour), created.Add(time.Hour + IdleTimeout), true},
+        {"capped at max lifetime", created.Add(MaxLifetime - time.Hour), created.Add(MaxLifetime), true},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            got, touch := touchExpiry(s, tc.now)
+            if touch != tc.touch || !got.Equal(tc.want) {
+                t.Fatalf("touchExpiry = %v, %v; want %v, %v", got, touch, tc.want, tc.touch)
+            }
+            if got.Before(s.ExpiresAt) {
+                t.Fatalf("expiry moved back from %v to %v", s.ExpiresAt, got)
+            }
+        })
+    }
+}
+
+func newContext(token string) (*gin.Context, *httptest.ResponseRecorder) {
+    gin.SetMode(gin.TestMode)
+    w := httptest.NewRecorder()
+    c, _ := gin.CreateTestContext(w)
+    c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+    if token != "" {
+        c.Request.AddCookie(&http.Cookie{Name: CookieName, Value: token})
+    }
+    return c, w
+}
+
+// createSession menyimpan session user dengan token baru; lastSeen dan expires relatif terhadap sekarang
+func createSession(t *testing.T, db *gorm.DB, userID int64, lastSeen, expires time.Duration) (string, models.Session) {
+    t.Helper()
+    token, err := NewToken()
+    if err != nil {
+        t.Fatal(err)
+    }
+    now := time.Now()
+    s := models.Session{
+        UserID:     userID,
+        TokenHash:  HashToken(token),
+        ExpiresAt:  now.Add(expires),
+        LastSeenAt: now.Add(-lastSeen),
+        CreatedAt:  now.Add(-lastSeen),
+    }
+    if err := db.Create(&s).Error; err != nil {
+        t.Fatal(err)
+    }
+    return token, s
+}
+
+func reloadSession(t *testing.T, db *gorm.DB, id int64) models.Session {
+    t.Helper()
+    var s models.Session
+    if err := db.First(&s, id).Error; err != nil {
+        t.Fatal(err)
+    }
+    return s
+}
+
+func TestLookupSlidesExpiry(t *testing.T) {
+    db := dbtest.Open(t)
+    user := dbtest.CreateUser(t, db, "session-lookup")
+
+    // Dipakai lagi sebelum touchInterval: expiry dan cookie tidak berubah
+    token, s := createSession(t, db, user.ID, time.Minute, time.Hour)
+    c, w := newContext(token)
+    got, err := Lookup(c, db, token)
+    if err != nil || got.ID != user.ID {
+        t.Fatalf("Lookup = %d, %v; want user %d", got.ID, err, user.ID)
+    }
+    if id, _ := c.Get("session_id"); id != s.ID {
+        t.Fatalf("session_id = %v, want %d", id, s.ID)
+    }
+    // Postgres menyimpan waktu dalam mikrodetik
+    if after := reloadSession(t, db, s.ID); after.ExpiresAt.Sub(s.ExpiresAt).Abs() > time.Millisecond {
+        t.Fatalf("expiry moved within touch interval: %v -> %v", s.ExpiresAt, after.ExpiresAt)
+    }
+    if cookie := w.Header().Get("Set-Cookie"); cookie != "" {
+        t.Fatalf("cookie re-sent within touch interval: %q", cookie)
+    }
+
+    // Dipakai lagi setelah touchInterval: expiry maju menjadi sekarang + IdleTimeout
+    token, s = createSession(t, db, user.ID, touchInterval+time.Minute, time.Hour)
+    c, w = newContext(token)
+    before := time.Now()
+    if _, err := Lookup(c, db, token); err != nil {
+        t.Fatal(err)
+    }
+    after := reloadSession(t, db, s.ID)
+    if after.ExpiresAt.Before(before.Add(IdleTimeout).Add(-time.Second)) || !after.LastSeenAt.After(s.LastSeenAt) {
+        t.Fatalf("expiry = %v, last seen = %v; want about %v", after.ExpiresAt, after.LastSeenAt, before.Add(IdleTimeout))
+    }
+    if cookie := w.Header().Get("Set-Cookie"); !strings.HasPrefix(cookie, CookieName+"="+token) {
+        t.Fatalf("Set-Cookie = %q, want the same token re-sent", cookie)
+    }
+}
+
+func TestLookupRejects(t *testing.T) {
+    db := dbtest.Open(t)
+    user := dbtest.CreateUser(t, db, "session-reject")
+    suspended := dbtest.CreateUser(t, db, "session-suspended")
+    if err := db.Model(&suspended).Update("status", models.UserSuspended).Error; err != nil {
+        t.Fatal(err)
+    }
+
+    expired, _ := createSession(t, db, user.ID, time.Hour, -time.Second)
+    inactive, _ := createSession(t, db, suspended.ID, time.Minute, time.Hour)
+    cases := map[string]string{
+        "unknown token": "not-a-session",
+        "expired":       expired,
+        "inactive user": inactive,
+    }
+    for name, token := range cases {
+        t.Run(name, func(t *testing.T) {
+            c, _ := newContext(token)
+            if _, err := Lookup(c, db, token); !errors.Is(err, ErrInvalid) {
+                t.Fatalf("err = %v, want ErrInvalid", err)
+            }
+            if _, ok := c.Get("session_id"); ok {
+                t.Fatal("session_id set for an invalid session")
+            }
+        })
+    }
+}
+
+func TestRevoke(t *testing.T) {
+    db := dbtest.Open(t)
+    user := dbtest.CreateUser(t, db, "session-revoke")
+    token, _ := createSession(t, db, user.ID, time.Minute, time.Hour)
+    other, _ := createSession(t, db, user.ID, time.Minute, time.Hour)
+
+    c, w := newContext(token)
+    if err := Revoke(c, db); err != nil {
+        t.Fatal(err)
+    }
+    cookie := w.Header().Get("Set-Cookie")
+    if !strings.HasPrefix(cookie, CookieName+"=;") || !strings.Contains(cookie, "Max-Age=0") || !strings.Contains(cookie, "HttpOnly") {
+        t.Fatalf("Set-Cookie = %q", cookie)
+    }
+    if _, err := Lookup(c, db, token); !errors.Is(err, ErrInvalid) {
+        t.Fatalf("revoked session still valid: %v", err)
+    }
+    // Session lain milik user yang sama tidak ikut dicabut
+    if _, err := Lookup(c, db, other); err != nil {
+        t.Fatalf("other session revoked: %v", err)
+    }
+}
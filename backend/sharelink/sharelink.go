@@ -0,0 +1,134 @@
+// Package sharelink menandatangani dan memverifikasi token tautan berbagi file peraturan.
+// Token berisi id tautan, id peraturan, dan waktu kedaluwarsa yang ditandatangani HMAC-SHA256,
+// sehingga tidak bisa diubah tanpa secret. Pencabutan dan batas unduhan dicek di database.
+package sharelink
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+    ErrInvalid = errors.New("invalid share link")
+    ErrExpired = errors.New("share link has expired")
+)
+
+// minSecretLength adalah panjang minimum SHARE_LINK_SECRET dalam byte
+const minSecretLength = 32
+
+// Claims adalah isi token tautan berbagi
+type Claims struct {
+    LinkID      uint
+    PeraturanID uint
+    ExpiresAt   time.Time
+}
+
+type Signer struct {
+    key []byte
+}
+
+func New(key []byte) *Signer {
+    return &Signer{key: key}
+}
+
+// FromEnv membaca SHARE_LINK_SECRET (minimal 32 karakter). Jika kosong dibuat secret acak,
+// yang berarti semua tautan tidak berlaku lagi setelah server restart.
+func FromEnv() (*Signer, error) {
+    secret := os.Getenv("SHARE_LINK_SECRET")
+    if secret == "" {
+        key := make([]byte, minSecretLength)
+        if _, err := rand.Read(key); err != nil {
+            return nil, err
+        }
+        log.Println("⚠️ Warning: SHARE_LINK_SECRET is not set, share links will stop working after restart")
+        return New(key), nil
+    }
+    if len(secret) < minSecretLength {
+        return nil, fmt.Errorf("SHARE_LINK_SECRET must be at least %d characters", minSecretLength)
+    }
+    return New([]byte(secret)), nil
+}
+
+// Sign menghasilkan token "<link>.<peraturan>.<expires>.<signature>"
+func (s *Signer) Sign(c Claims) string {
+    payload := fmt.Sprintf("%d.%d.%d", c.LinkID, c.PeraturanID, c.ExpiresAt.Unix())
+    return payload + "." + s.signature(payload)
+}
+
+// Verify memeriksa tanda tangan dan waktu kedaluwarsa token
+func (s *Signer) Verify(token string) (Claims, error) {
+    idx := strings.LastIndexByte(token, '.')
+    if idx < 0 {
+        return Claims{}, ErrInvalid
+    }
+    payload, sig := token[:idx], token[idx+1:]
+    if !hmac.Equal([]byte(sig), []byte(s.signature(payload))) {
+        return Claims{}, ErrInvalid
+    }
+
+    parts := strings.Split(payload, ".")
+    if len(parts) != 3 {
+        return Claims{}, ErrInvalid
+    }
+    linkID, err1 := strconv.ParseUint(parts[0], 10, 32)
+    peraturanID, err2 := strconv.ParseUint(parts[1], 10, 32)
+    expires, err3 := strconv.ParseInt(parts[2], 10, 64)
+    if err1 != nil || err2 != nil || err3 != nil {
+        return Claims{}, ErrInvalid
+    }
+
+    claims := Claims{LinkID: uint(linkID), PeraturanID: uint(peraturanID), ExpiresAt: time.Unix(expires, 0)}
+    if time.Now().After(claims.ExpiresAt) {
+        return claims, ErrExpired
+    }
+    return claims, nil
+}
+
+// SignDownload menandatangani izin melanjutkan unduhan (range request) setelah unduhan ke-seq
+// dari tautan linkID dihitung. Awalan "dl" membuat token ini tidak bisa dipakai sebagai token
+// tautan berbagi, dan sebaliknya.
+func (s *Signer) SignDownload(linkID uint, seq int64, expiresAt time.Time) string {
+    payload := fmt.Sprintf("dl.%d.%d.%d", linkID, seq, expiresAt.Unix())
+    return payload + "." + s.signature(payload)
+}
+
+// VerifyDownload memeriksa token dari SignDownload untuk tautan linkID dan mengembalikan seq
+func (s *Signer) VerifyDownload(token string, linkID uint) (int64, error) {
+    idx := strings.LastIndexByte(token, '.')
+    if idx < 0 {
+        return 0, ErrInvalid
+    }
+    payload, sig := token[:idx], token[idx+1:]
+    if !hmac.Equal([]byte(sig), []byte(s.signature(payload))) {
+        return 0, ErrInvalid
+    }
+
+    parts := strings.Split(payload, ".")
+    if len(parts) != 4 || parts[0] != "dl" || parts[1] != strconv.FormatUint(uint64(linkID), 10) {
+        return 0, ErrInvalid
+    }
+    seq, err1 := strconv.ParseInt(parts[2], 10, 64)
+    expires, err2 := strconv.ParseInt(parts[3], 10, 64)
+    if err1 != nil || err2 != nil {
+        return 0, ErrInvalid
+    }
+    if time.Now().After(time.Unix(expires, 0)) {
+        return 0, ErrExpired
+    }
+    return seq, nil
+}
+
+func (s *Signer) signature(payload string) string {
+    mac := hmac.New(sha256.New, s.key)
+    mac.Write([]byte(payload))
+    return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
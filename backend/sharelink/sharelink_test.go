@@ -0,0 +1,97 @@
+package sharelink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKey = []byte(strings.Repeat("k", minSecretLength))
+
+func TestSignVerify(t *testing.T) {
+    s := New(testKey)
+    expires := time.Now().Add(time.Hour).Truncate(time.Second)
+    token := s.Sign(Claims{LinkID: 7, PeraturanID: 42, ExpiresAt: expires})
+
+    claims, err := s.Verify(token)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if claims.LinkID != 7 || claims.PeraturanID != 42 || !claims.ExpiresAt.Equal(expires) {
+        t.Fatalf("claims = %+v", claims)
+    }
+}
+
+func TestVerifyRejects(t *testing.T) {
+    s := New(testKey)
+    valid := s.Sign(Claims{LinkID: 7, PeraturanID: 42, ExpiresAt: time.Now().Add(time.Hour)})
+    payload, sig := valid[:strings.LastIndexByte(valid, '.')], valid[strings.LastIndexByte(valid, '.')+1:]
+
+    cases := map[string]string{
+        "empty":                 "",
+        "no signature":          payload,
+        "tampered peraturan":    strings.Replace(payload, ".42.", ".43.", 1) + "." + sig,
+        "tampered signature":    payload + "." + strings.Repeat("A", len(sig)),
+        "other secret":          New([]byte(strings.Repeat("x", minSecretLength))).Sign(Claims{LinkID: 7, PeraturanID: 42, ExpiresAt: time.Now().Add(time.Hour)}),
+        "download grant":        s.SignDownload(7, 1, time.Now().Add(time.Hour)),
+        "extra payload segment": s.Sign(Claims{LinkID: 7, PeraturanID: 42, ExpiresAt: time.Now().Add(time.Hour)}) + ".x",
+    }
+    for name, token := range cases {
+        t.Run(name, func(t *testing.T) {
+            if _, err := s.Verify(token); !errors.Is(err, ErrInvalid) {
+                t.Fatalf("err = %v, want ErrInvalid", err)
+            }
+        })
+    }
+}
+
+func TestVerifyExpired(t *testing.T) {
+    s := New(testKey)
+    token := s.Sign(Claims{LinkID: 1, PeraturanID: 2, ExpiresAt: time.Now().Add(-time.Second)})
+    if _, err := s.Verify(token); !errors.Is(err, ErrExpired) {
+        t.Fatalf("err = %v, want ErrExpired", err)
+    }
+}
+
+func TestDownloadGrant(t *testing.T) {
+    s := New(testKey)
+    grant := s.SignDownload(7, 3, time.Now().Add(time.Minute))
+
+    seq, err := s.VerifyDownload(grant, 7)
+    if err != nil || seq != 3 {
+        t.Fatalf("VerifyDownload = %d, %v; want 3", seq, err)
+    }
+    // Izin terikat ke satu tautan
+    if _, err := s.VerifyDownload(grant, 8); !errors.Is(err, ErrInvalid) {
+        t.Fatalf("other link: err = %v, want ErrInvalid", err)
+    }
+    // Token tautan berbagi tidak bisa dipakai sebagai izin lanjutan
+    share := s.Sign(Claims{LinkID: 7, PeraturanID: 3, ExpiresAt: time.Now().Add(time.Hour)})
+    if _, err := s.VerifyDownload(share, 7); !errors.Is(err, ErrInvalid) {
+        t.Fatalf("share token: err = %v, want ErrInvalid", err)
+    }
+    if _, err := s.VerifyDownload(strings.Replace(grant, "dl.7.3.", "dl.7.4.", 1), 7); !errors.Is(err, ErrInvalid) {
+        t.Fatalf("tampered seq: err = %v, want ErrInvalid", err)
+    }
+    expired := s.SignDownload(7, 3, time.Now().Add(-time.Second))
+    if _, err := s.VerifyDownload(expired, 7); !errors.Is(err, ErrExpired) {
+        t.Fatalf("expired: err = %v, want ErrExpired", err)
+    }
+}
+
+func TestFromEnv(t *testing.T) {
+    t.Setenv("SHARE_LINK_SECRET", "too-short")
+    if _, err := FromEnv(); err == nil {
+        t.Fatal("short secret accepted")
+    }
+    t.Setenv("SHARE_LINK_SECRET", string(testKey))
+    s, err := FromEnv()
+    if err != nil {
+        t.Fatal(err)
+    }
+    token := New(testKey).Sign(Claims{LinkID: 1, PeraturanID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+    if _, err := s.Verify(token); err != nil {
+        t.Fatalf("token from same secret rejected: %v", err)
+    }
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local menyimpan objek sebagai file biasa di bawah direktori root
+type Local struct {
+    root string
+}
+
+func NewLocal(root string) (*Local, error) {
+    abs, err := filepath.Abs(root)
+    if err != nil {
+        return nil, err
+    }
+    if err := os.MkdirAll(abs, 0755); err != nil {
+        return nil, err
+    }
+    return &Local{root: abs}, nil
+}
+
+// path memetakan key ke lokasi file dan menolak key yang keluar dari root
+func (l *Local) path(key string) (string, error) {
+    p := filepath.Join(l.root, filepath.FromSlash(key))
+    if p != l.root && !strings.HasPrefix(p, l.root+string(filepath.Separator)) {
+        return "", errors.New("invalid storage key")
+    }
+    return p, nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+    p, err := l.path(key)
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+        return err
+    }
+
+    // Tulis ke file sementara lalu rename agar pembaca tidak melihat file setengah jadi
+    tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(tmp, r); err != nil {
+        tmp.Close()
+        os.Remove(tmp.Name())
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        os.Remove(tmp.Name())
+        return err
+    }
+    return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+    p, err := l.path(key)
+    if err != nil {
+        return nil, Info{}, err
+    }
+    f, err := os.Open(p)
+    if os.IsNotExist(err) {
+        return nil, Info{}, ErrNotFound
+    }
+    if err != nil {
+        return nil, Info{}, err
+    }
+    st, err := f.Stat()
+    if err != nil {
+        f.Close()
+        return nil, Info{}, err
+    }
+    return f, Info{Key: key, Size: st.Size(), ModTime: st.ModTime()}, nil
+}
+
+func (l *Local) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+    f, _, err := l.Get(ctx, key)
+    if err != nil {
+        return nil, err
+    }
+    file := f.(*os.File)
+    if _, err := file.Seek(offset, io.SeekStart); err != nil {
+        file.Close()
+        return nil, err
+    }
+    return struct {
+        io.Reader
+        io.Closer
+    }{io.LimitReader(file, length), file}, nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (Info, error) {
+    p, err := l.path(key)
+    if err != nil {
+        return Info{}, err
+    }
+    st, err := os.Stat(p)
+    if os.IsNotExist(err) {
+        return Info{}, ErrNotFound
+    }
+    if err != nil {
+        return Info{}, err
+    }
+    return Info{Key: key, Size: st.Size(), ModTime: st.ModTime()}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+    p, err := l.path(key)
+    if err != nil {
+        return err
+    }
+    if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    return nil
+}
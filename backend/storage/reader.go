@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Reader adalah io.ReadSeeker di atas objek storage. Isi baru diambil saat Read pertama
+// setelah Seek, memakai GetRange, sehingga http.ServeContent bisa melayani range request
+// tanpa mengunduh seluruh objek dari backend.
+type Reader struct {
+    ctx    context.Context
+    store  Storage
+    info   Info
+    offset int64
+    body   io.ReadCloser
+}
+
+// Open menyiapkan Reader untuk key; hanya Stat yang dijalankan di sini
+func Open(ctx context.Context, s Storage, key string) (*Reader, error) {
+    info, err := s.Stat(ctx, key)
+    if err != nil {
+        return nil, err
+    }
+    return &Reader{ctx: ctx, store: s, info: info}, nil
+}
+
+func (r *Reader) Info() Info {
+    return r.info
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+    if r.offset >= r.info.Size {
+        return 0, io.EOF
+    }
+    if r.body == nil {
+        body, err := r.store.GetRange(r.ctx, r.info.Key, r.offset, r.info.Size-r.offset)
+        if err != nil {
+            return 0, err
+        }
+        r.body = body
+    }
+    n, err := r.body.Read(p)
+    r.offset += int64(n)
+    if err == io.EOF && r.offset < r.info.Size {
+        err = io.ErrUnexpectedEOF
+    }
+    return n, err
+}
+
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+    var abs int64
+    switch whence {
+    case io.SeekStart:
+        abs = offset
+    case io.SeekCurrent:
+        abs = r.offset + offset
+    case io.SeekEnd:
+        abs = r.info.Size + offset
+    default:
+        return 0, errors.New("storage: invalid whence")
+    }
+    if abs < 0 {
+        return 0, errors.New("storage: negative position")
+    }
+    if abs != r.offset {
+        r.closeBody()
+        r.offset = abs
+    }
+    return abs, nil
+}
+
+func (r *Reader) Close() error {
+    r.closeBody()
+    return nil
+}
+
+func (r *Reader) closeBody() {
+    if r.body != nil {
+        r.body.Close()
+        r.body = nil
+    }
+}
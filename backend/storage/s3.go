@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config berisi koneksi ke layanan S3-compatible. Endpoint menyertakan skema,
+// misal http://localhost:9000 untuk MinIO lokal.
+type S3Config struct {
+    Endpoint  string
+    Region    string
+    Bucket    string
+    AccessKey string
+    SecretKey string
+}
+
+// S3 adalah klien minimal S3 (path-style, Signature V4) tanpa SDK eksternal
+type S3 struct {
+    cfg    S3Config
+    base   *url.URL
+    client *http.Client
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+    if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+        return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required")
+    }
+    if cfg.Region == "" {
+        cfg.Region = "us-east-1"
+    }
+    base, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+    if err != nil {
+        return nil, err
+    }
+    return &S3{cfg: cfg, base: base, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+}
+
+// objectURL membentuk URL path-style: {endpoint}/{bucket}/{key}
+func (s *S3) objectURL(key string) *url.URL {
+    u := *s.base
+    segments := []string{s.cfg.Bucket}
+    segments = append(segments, strings.Split(key, "/")...)
+    for i := range segments {
+        segments[i] = uriEncode(segments[i])
+    }
+    u.RawPath = u.Path + "/" + strings.Join(segments, "/")
+    u.Path = u.Path + "/" + s.cfg.Bucket + "/" + key
+    return &u
+}
+
+// uriEncode mengikuti aturan encoding SigV4 (RFC 3986 unreserved tidak di-encode)
+func uriEncode(s string) string {
+    var sb strings.Builder
+    for i := 0; i < len(s); i++ {
+        b := s[i]
+        if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' {
+            sb.WriteByte(b)
+        } else {
+            fmt.Fprintf(&sb, "%%%02X", b)
+        }
+    }
+    return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+    h := hmac.New(sha256.New, key)
+    h.Write([]byte(data))
+    return h.Sum(nil)
+}
+
+// sign menambahkan header Authorization AWS Signature Version 4.
+// Request dari klien ini memakai UNSIGNED-PAYLOAD supaya upload bisa di-stream.
+func (s *S3) sign(req *http.Request, now time.Time, payloadHash string) {
+    amzDate := now.UTC().Format("20060102T150405Z")
+    date := amzDate[:8]
+
+    req.Header.Set("x-amz-date", amzDate)
+    req.Header.Set("x-amz-content-sha256", payloadHash)
+
+    headerNames := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+    headerValues := map[string]string{
+        "host":                 req.URL.Host,
+        "x-amz-content-sha256": payloadHash,
+        "x-amz-date":           amzDate,
+    }
+    if r := req.Header.Get("Range"); r != "" {
+        headerNames = append(headerNames, "range")
+        headerValues["range"] = r
+    }
+    sort.Strings(headerNames)
+
+    var canonicalHeaders strings.Builder
+    for _, name := range headerNames {
+        canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headerValues[name]) + "\n")
+    }
+    signedHeaders := strings.Join(headerNames, ";")
+
+    canonicalRequest := strings.Join([]string{
+        req.Method,
+        req.URL.EscapedPath(),
+        req.URL.RawQuery,
+        canonicalHeaders.String(),
+        signedHeaders,
+        payloadHash,
+    }, "\n")
+
+    scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+    requestHash := sha256.Sum256([]byte(canonicalRequest))
+    stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+    key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+    key = hmacSHA256(key, s.cfg.Region)
+    key = hmacSHA256(key, "s3")
+    key = hmacSHA256(key, "aws4_request")
+    signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+    req.Header.Set("Authorization", fmt.Sprintf(
+        "AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+        s.cfg.AccessKey, scope, signedHeaders, signature,
+    ))
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+    req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+    if err != nil {
+        return nil, err
+    }
+    for name, values := range header {
+        for _, v := range values {
+            req.Header.Add(name, v)
+        }
+    }
+    if body != nil {
+        req.ContentLength = size
+    }
+    s.sign(req, time.Now(), "UNSIGNED-PAYLOAD")
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode == http.StatusNotFound {
+        resp.Body.Close()
+        return nil, ErrNotFound
+    }
+    if resp.StatusCode >= 300 {
+        msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+        resp.Body.Close()
+        return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(msg)))
+    }
+    return resp, nil
+}
+
+func infoFromResponse(key string, resp *http.Response) Info {
+    info := Info{Key: key, Size: resp.ContentLength}
+    if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+        info.ModTime = t
+    }
+    // Untuk response 206, ukuran total ada di Content-Range: bytes a-b/total
+    if cr := resp.Header.Get("Content-Range"); cr != "" {
+        if idx := strings.LastIndex(cr, "/"); idx >= 0 {
+            if total, err := strconv.ParseInt(cr[idx+1:], 10, 64); err == nil {
+                info.Size = total
+            }
+        }
+    }
+    return info
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+    header := http.Header{}
+    if contentType != "" {
+        header.Set("Content-Type", contentType)
+    }
+    resp, err := s.do(ctx, http.MethodPut, key, r, size, header)
+    if err != nil {
+        return err
+    }
+    resp.Body.Close()
+    return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+    resp, err := s.do(ctx, http.MethodGet, key, nil, 0, nil)
+    if err != nil {
+        return nil, Info{}, err
+    }
+    return resp.Body, infoFromResponse(key, resp), nil
+}
+
+func (s *S3) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+    header := http.Header{}
+    header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+    resp, err := s.do(ctx, http.MethodGet, key, nil, 0, header)
+    if err != nil {
+        return nil, err
+    }
+    return resp.Body, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (Info, error) {
+    resp, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+    if err != nil {
+        return Info{}, err
+    }
+    resp.Body.Close()
+    return infoFromResponse(key, resp), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+    resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+    if errors.Is(err, ErrNotFound) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    resp.Body.Close()
+    return nil
+}
@@ -0,0 +1,127 @@
+// Package storage menyediakan penyimpanan file dokumen yang bisa diganti backend-nya
+// (disk lokal atau S3-compatible seperti MinIO). File disimpan berdasarkan hash SHA-256
+// isinya sehingga upload yang identik hanya tersimpan sekali.
+//
+// Konfigurasi lewat environment:
+//
+//	STORAGE_DRIVER      local (default) atau s3
+//	STORAGE_LOCAL_ROOT  direktori root untuk driver local (default ./uploads)
+//	S3_ENDPOINT         misal http://localhost:9000 untuk MinIO lokal
+//	S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Info adalah metadata objek yang tersimpan
+type Info struct {
+    Key     string
+    Size    int64
+    ModTime time.Time
+}
+
+// Storage adalah kontrak backend penyimpanan file
+type Storage interface {
+    Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+    Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+    // GetRange membaca length byte mulai dari offset, dipakai untuk HTTP range request
+    GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+    Stat(ctx context.Context, key string) (Info, error)
+    Delete(ctx context.Context, key string) error
+}
+
+// Object adalah hasil PutContent
+type Object struct {
+    Key  string `json:"key"`
+    Hash string `json:"hash"`
+    Size int64  `json:"size"`
+}
+
+// KeyForHash membentuk key content-addressed, dipecah per 2 karakter pertama
+// agar satu direktori tidak berisi terlalu banyak file
+func KeyForHash(hash string) string {
+    return "sha256/" + hash[:2] + "/" + hash
+}
+
+// IsContentKey membedakan key storage dengan path file lama (timestamp_namafile)
+func IsContentKey(key string) bool {
+    return strings.HasPrefix(key, "sha256/")
+}
+
+// PutContent menyimpan isi r dengan key berdasarkan SHA-256. Isi ditampung dulu di file
+// sementara karena hash harus diketahui sebelum upload. Jika objek dengan hash yang sama
+// sudah ada, upload dilewati (deduplikasi).
+func PutContent(ctx context.Context, s Storage, r io.Reader, contentType string) (Object, error) {
+    tmp, err := os.CreateTemp("", "upload-*")
+    if err != nil {
+        return Object{}, err
+    }
+    defer os.Remove(tmp.Name())
+    defer tmp.Close()
+
+    hasher := sha256.New()
+    size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+    if err != nil {
+        return Object{}, err
+    }
+
+    hash := hex.EncodeToString(hasher.Sum(nil))
+    obj := Object{Key: KeyForHash(hash), Hash: hash, Size: size}
+
+    if _, err := s.Stat(ctx, obj.Key); err == nil {
+        return obj, nil
+    } else if !errors.Is(err, ErrNotFound) {
+        return Object{}, err
+    }
+
+    if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+        return Object{}, err
+    }
+    if err := s.Put(ctx, obj.Key, tmp, size, contentType); err != nil {
+        return Object{}, err
+    }
+    return obj, nil
+}
+
+// ReadAll membaca seluruh isi objek
+func ReadAll(ctx context.Context, s Storage, key string) ([]byte, error) {
+    rc, _, err := s.Get(ctx, key)
+    if err != nil {
+        return nil, err
+    }
+    defer rc.Close()
+    return io.ReadAll(rc)
+}
+
+// FromEnv membuat backend sesuai STORAGE_DRIVER (local atau s3)
+func FromEnv() (Storage, error) {
+    switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+    case "", "local":
+        root := os.Getenv("STORAGE_LOCAL_ROOT")
+        if root == "" {
+            root = "./uploads"
+        }
+        return NewLocal(root)
+    case "s3":
+        return NewS3(S3Config{
+            Endpoint:  os.Getenv("S3_ENDPOINT"),
+            Region:    os.Getenv("S3_REGION"),
+            Bucket:    os.Getenv("S3_BUCKET"),
+            AccessKey: os.Getenv("S3_ACCESS_KEY"),
+            SecretKey: os.Getenv("S3_SECRET_KEY"),
+        })
+    default:
+        return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+    }
+}
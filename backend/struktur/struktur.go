@@ -0,0 +1,391 @@
+// Package struktur memecah teks peraturan hasil ekstraksi menjadi BAB, Pasal, dan ayat
+// beserta halaman tempatnya. Teks PDF sering kehilangan baris dan memecah angka
+// ("Pasal 1 3"), sehingga heading dikenali dari urutan nomor dan konteks kalimat,
+// bukan dari posisi di awal baris.
+package struktur
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Ayat adalah satu ayat bernomor di dalam pasal
+type Ayat struct {
+    Nomor   int
+    Halaman int
+    Konten  string
+}
+
+// Pasal adalah satu pasal beserta BAB tempatnya. Halaman dihitung mulai 1 dan Offset
+// adalah posisi byte heading pasal di teks halaman Halaman setelah spasi dirapikan
+// dan kepala halaman dibuang.
+type Pasal struct {
+    Nomor        string
+    Bab          string
+    JudulBab     string
+    Halaman      int
+    HalamanAkhir int
+    Offset       int
+    Konten       string
+    Ayat         []Ayat
+}
+
+var (
+    pasalRe    = regexp.MustCompile(`\bPasal\s+(\d(?:\s?\d){0,2})(?:\s?([A-Z])\b)?`)
+    romanRe    = regexp.MustCompile(`\bPasal\s+([IVX]+)\b`)
+    babRe      = regexp.MustCompile(`\bBAB\s+([IVXLC](?:\s?[IVXLC])*)\b`)
+    ayatRe     = regexp.MustCompile(`\(\s?(\d{1,2})\s?\)`)
+    pageNumRe  = regexp.MustCompile(`^\s*-\s*(\d+)\s*-\s*`)
+    sectionRe  = regexp.MustCompile(`[.;:]\s+(?:Bagian\s+(?:Ke\s?\w+|Pertama)|Paragraf\s+\d+)\b`)
+    stopMarker = []string{"Agar setiap orang mengetahuinya", "Ditetapkan di", "PENJELASAN ATAS", "LAMPIRAN"}
+)
+
+// refWords adalah kata sebelum "Pasal" yang menandakan rujukan, bukan heading
+var refWords = map[string]bool{
+    "dalam": true, "dimaksud": true, "sebagaimana": true, "dan": true, "atau": true, "serta": true,
+    "pada": true, "oleh": true, "dengan": true, "menurut": true, "berdasarkan": true, "ketentuan": true,
+    "kecuali": true, "di": true, "terhadap": true, "mengenai": true, "sampai": true, "lihat": true,
+    "jo.": true, "juncto": true, "dari": true, "ke": true, "tentang": true, "melalui": true, "dalam,": true,
+}
+
+// refFollowers adalah kata setelah nomor pasal yang menandakan rujukan
+var refFollowers = []string{"ayat", "huruf", "angka", "sampai", "dan ", "atau", ",", "jo"}
+
+// lawFollowers menandakan rujukan ke pasal peraturan lain ("Pasal 5 Peraturan Pemerintah Nomor ..."),
+// kecuali diikuti "ini" sebelum "Nomor" seperti pada pasal penutup "Peraturan Menteri ini mulai berlaku ..."
+var lawFollowers = []string{"Undang", "Peraturan", "Keputusan", "UU", "KUH"}
+
+// page menyimpan posisi awal tiap halaman di teks gabungan
+type page struct {
+    start int
+    text  string
+}
+
+type document struct {
+    text  string
+    pages []page
+}
+
+// Parse mengenali pasal dari teks per halaman (indeks 0 = halaman 1)
+func Parse(pages []string) []Pasal {
+    doc := newDocument(pages)
+    if doc.text == "" {
+        return nil
+    }
+
+    end := len(doc.text)
+    headings := doc.pasalHeadings()
+    if len(headings) == 0 {
+        return nil
+    }
+    for _, marker := range stopMarker {
+        if idx := strings.Index(doc.text[headings[0].start:], marker); idx >= 0 && headings[0].start+idx < end {
+            end = headings[0].start + idx
+        }
+    }
+    babs := doc.babHeadings(end)
+
+    var result []Pasal
+    for i, h := range headings {
+        if h.start >= end {
+            break
+        }
+        contentEnd := end
+        if i+1 < len(headings) && headings[i+1].start < contentEnd {
+            contentEnd = headings[i+1].start
+        }
+        for _, b := range babs {
+            if b.start > h.end && b.start < contentEnd {
+                contentEnd = b.start
+                break
+            }
+        }
+
+        konten := doc.text[h.end:contentEnd]
+        if loc := sectionRe.FindStringIndex(konten); loc != nil {
+            konten = konten[:loc[0]+1] // Buang heading "Bagian/Paragraf" milik pasal berikutnya
+        }
+        konten = strings.TrimSpace(konten)
+
+        p := Pasal{
+            Nomor:        h.nomor,
+            Halaman:      doc.pageAt(h.start),
+            HalamanAkhir: doc.pageAt(h.end + len(konten)),
+            Offset:       h.start - doc.pages[doc.pageAt(h.start)-1].start,
+            Konten:       konten,
+        }
+        for _, b := range babs {
+            if b.start < h.start {
+                p.Bab, p.JudulBab = b.nomor, b.judul
+            }
+        }
+        p.Ayat = doc.ayat(konten, h.end+strings.Index(doc.text[h.end:], konten))
+        result = append(result, p)
+    }
+    return result
+}
+
+func newDocument(pages []string) *document {
+    header := commonHeader(pages)
+
+    var sb strings.Builder
+    doc := &document{}
+    for i, text := range pages {
+        text = strings.Join(strings.Fields(text), " ")
+        if header != "" {
+            text = strings.TrimPrefix(text, header)
+        }
+        // Nomor halaman "- 5 -" di kepala halaman
+        if m := pageNumRe.FindStringSubmatch(text); m != nil && m[1] == strconv.Itoa(i+1) {
+            text = text[len(m[0]):]
+        }
+        text = strings.TrimSpace(text)
+
+        if i > 0 {
+            sb.WriteString(" ")
+        }
+        doc.pages = append(doc.pages, page{start: sb.Len(), text: text})
+        sb.WriteString(text)
+    }
+    doc.text = sb.String()
+    return doc
+}
+
+// commonHeader mencari kepala halaman yang berulang (misal watermark "jdih.menpan.go.id"),
+// yaitu kata pertama yang sama pada sedikitnya separuh halaman
+func commonHeader(pages []string) string {
+    if len(pages) < 3 {
+        return ""
+    }
+    count := map[string]int{}
+    for _, text := range pages {
+        if fields := strings.Fields(text); len(fields) > 0 {
+            count[fields[0]]++
+        }
+    }
+    header, best := "", 0
+    for word, n := range count {
+        if n > best || (n == best && word < header) {
+            header, best = word, n
+        }
+    }
+    if best*2 < len(pages) {
+        return ""
+    }
+    return header
+}
+
+// pageAt mengembalikan nomor halaman (mulai 1) untuk posisi di teks gabungan
+func (d *document) pageAt(pos int) int {
+    idx := sort.Search(len(d.pages), func(i int) bool { return d.pages[i].start > pos })
+    if idx == 0 {
+        return 1
+    }
+    return idx
+}
+
+type heading struct {
+    start, end int
+    nomor      string
+    judul      string
+}
+
+// pasalHeadings memilih kemunculan "Pasal N" yang merupakan heading: nomornya
+// melanjutkan urutan pasal sebelumnya dan konteksnya bukan rujukan. Peraturan perubahan
+// memakai "Pasal I", "Pasal II", ... dengan pasal yang diubah dikutip di dalamnya;
+// untuk peraturan seperti itu yang dipakai adalah pasal romawi.
+func (d *document) pasalHeadings() []heading {
+    arabic := d.arabicHeadings()
+    roman := d.romanHeadings()
+    if len(roman) > 0 && (len(arabic) == 0 || roman[0].start < arabic[0].start) {
+        return roman
+    }
+    return arabic
+}
+
+func (d *document) romanHeadings() []heading {
+    var result []heading
+    last := 0
+    for _, m := range romanRe.FindAllStringSubmatchIndex(d.text, -1) {
+        if romanToInt(d.text[m[2]:m[3]]) != last+1 || d.isReference(m[0], m[1]) {
+            continue
+        }
+        last++
+        result = append(result, heading{start: m[0], end: m[1], nomor: d.text[m[2]:m[3]]})
+    }
+    return result
+}
+
+func (d *document) arabicHeadings() []heading {
+    var result []heading
+    last, lastSuffix := 0, ""
+
+    for _, m := range pasalRe.FindAllStringSubmatchIndex(d.text, -1) {
+        if d.isReference(m[0], m[1]) {
+            continue
+        }
+        digits := d.text[m[2]:m[3]]
+        suffix := ""
+        if m[4] >= 0 {
+            suffix = d.text[m[4]:m[5]]
+            // "Pasal 7 T ingkat" adalah kata yang terpecah, bukan Pasal 7T
+            if rest := strings.TrimLeft(d.text[m[5]:], " "); rest != "" && unicode.IsLower(rune(rest[0])) {
+                suffix = ""
+                m[1] = m[3]
+            }
+        }
+
+        // "Pasal 1 3" bisa berarti Pasal 13 (angka terpecah) atau Pasal 1 diikuti angka 3
+        joined, _ := strconv.Atoi(strings.ReplaceAll(digits, " ", ""))
+        first, _ := strconv.Atoi(strings.Fields(digits)[0])
+        end := m[1]
+
+        var nomor int
+        switch {
+        case joined > last && joined <= last+3:
+            nomor = joined
+        case first > last && first <= last+3:
+            nomor = first
+            end = m[2] + len(strings.Fields(digits)[0])
+            suffix = ""
+        case suffix != "" && joined == last && suffix > lastSuffix:
+            nomor = joined // Pasal sisipan, misal 12A setelah 12
+        default:
+            continue
+        }
+        if last == 0 && nomor != 1 {
+            continue
+        }
+
+        last, lastSuffix = nomor, suffix
+        result = append(result, heading{start: m[0], end: end, nomor: strconv.Itoa(nomor) + suffix})
+    }
+    return result
+}
+
+func (d *document) isReference(start, end int) bool {
+    before := strings.Fields(d.text[max(0, start-20):start])
+    if len(before) > 0 && refWords[strings.ToLower(before[len(before)-1])] {
+        return true
+    }
+    after := strings.TrimSpace(d.text[end:min(len(d.text), end+150)])
+    for _, w := range refFollowers {
+        if strings.HasPrefix(after, w) {
+            return true
+        }
+    }
+    for _, w := range lawFollowers {
+        if !strings.HasPrefix(after, w) {
+            continue
+        }
+        ini, nomor := strings.Index(after, " ini "), strings.Index(after, " Nomor ")
+        if ini < 0 || (nomor >= 0 && nomor < ini) {
+            return true
+        }
+    }
+    return false
+}
+
+// babHeadings mengenali "BAB IV JUDUL" dengan nomor romawi berurutan
+func (d *document) babHeadings(limit int) []heading {
+    var result []heading
+    last := 0
+    for _, m := range babRe.FindAllStringSubmatchIndex(d.text[:limit], -1) {
+        // Nomor romawi bisa terpecah ("BAB X I V"); jika gabungannya tidak berurutan,
+        // coba huruf pertama saja karena sisanya mungkin awal judul
+        // BAB tanpa judul (sisa daftar isi/potongan halaman) boleh digantikan heading berikutnya
+        untitled := len(result) > 0 && result[len(result)-1].judul == ""
+        accept := func(n int) bool { return n == last+1 || (untitled && n == last) }
+
+        roman := strings.ReplaceAll(d.text[m[2]:m[3]], " ", "")
+        if !accept(romanToInt(roman)) {
+            roman = strings.Fields(d.text[m[2]:m[3]])[0]
+            m[1] = m[2] + len(roman)
+        }
+        if !accept(romanToInt(roman)) {
+            continue
+        }
+        replace := romanToInt(roman) == last
+
+        // Judul BAB adalah kata-kata kapital setelah nomor
+        var judul []string
+        for _, word := range strings.Fields(d.text[m[1]:min(len(d.text), m[1]+200)]) {
+            if word == "Bagian" || word == "Pasal" || word == "Paragraf" || strings.ToUpper(word) != word {
+                break
+            }
+            if strings.Trim(word, ",-/&") != "" && strings.IndexFunc(word, unicode.IsLetter) < 0 {
+                break // Nomor halaman atau titik-titik daftar isi
+            }
+            judul = append(judul, word)
+        }
+        h := heading{start: m[0], end: m[1], nomor: roman, judul: strings.Join(judul, " ")}
+        if replace {
+            if h.judul != "" {
+                result[len(result)-1] = h
+            }
+            continue
+        }
+        last++
+        result = append(result, h)
+    }
+    return result
+}
+
+// ayat memecah isi pasal menjadi ayat (1), (2), ... jika pasal diawali "(1)".
+// base adalah posisi awal konten di teks gabungan untuk menghitung halaman ayat.
+func (d *document) ayat(konten string, base int) []Ayat {
+    matches := ayatRe.FindAllStringSubmatchIndex(konten, -1)
+    if len(matches) == 0 || matches[0][0] != 0 {
+        return nil
+    }
+
+    type marker struct{ start, end, nomor int }
+    var markers []marker
+    next := 1
+    for _, m := range matches {
+        nomor, _ := strconv.Atoi(konten[m[2]:m[3]])
+        if nomor != next {
+            continue
+        }
+        before := strings.Fields(konten[max(0, m[0]-12):m[0]])
+        if len(before) > 0 {
+            prev := strings.ToLower(before[len(before)-1])
+            if prev == "ayat" || prev == "dan" || prev == "atau" || prev == "sampai" || strings.HasSuffix(prev, ",") {
+                continue
+            }
+        }
+        markers = append(markers, marker{m[0], m[1], nomor})
+        next++
+    }
+
+    result := make([]Ayat, 0, len(markers))
+    for i, m := range markers {
+        end := len(konten)
+        if i+1 < len(markers) {
+            end = markers[i+1].start
+        }
+        result = append(result, Ayat{
+            Nomor:   m.nomor,
+            Halaman: d.pageAt(base + m.start),
+            Konten:  strings.TrimSpace(konten[m.end:end]),
+        })
+    }
+    return result
+}
+
+func romanToInt(s string) int {
+    values := map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100}
+    total := 0
+    for i := 0; i < len(s); i++ {
+        v := values[s[i]]
+        if i+1 < len(s) && values[s[i+1]] > v {
+            total -= v
+        } else {
+            total += v
+        }
+    }
+    return total
+}
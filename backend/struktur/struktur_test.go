@@ -0,0 +1,84 @@
+package struktur
+
+import "testing"
+
+var samplePages = []string{
+    "PERATURAN MENTERI NOMOR 1 TAHUN 2024 BAB I KETENTUAN UMUM Pasal 1 Dalam Peraturan Menteri ini yang dimaksud dengan: 1. Menteri adalah menteri. " +
+        "Pasal 2 (1) Setiap pegawai wajib hadir. (2) Kewajiban sebagaimana dimaksud dalam Pasal 1 berlaku umum.",
+    "- 2 - BAB II PELAKSANAAN Pasal 3 Ketentuan lebih lanjut diatur sesuai Pasal 2 ayat (1). " +
+        "Pasal 4 Peraturan Menteri ini mulai berlaku pada tanggal diundangkan. " +
+        "Agar setiap orang mengetahuinya, memerintahkan pengundangan. Ditetapkan di Jakarta Pasal 9 bukan",
+}
+
+func TestParse(t *testing.T) {
+    got := Parse(samplePages)
+    want := []struct {
+        nomor, bab, judulBab string
+        halaman, ayat        int
+    }{
+        {"1", "I", "KETENTUAN UMUM", 1, 0},
+        {"2", "I", "KETENTUAN UMUM", 1, 2},
+        {"3", "II", "PELAKSANAAN", 2, 0},
+        {"4", "II", "PELAKSANAAN", 2, 0},
+    }
+    // Rujukan "dalam Pasal 1"/"sesuai Pasal 2 ayat" dan "Pasal 9" setelah penutup tidak dihitung
+    if len(got) != len(want) {
+        t.Fatalf("got %d pasal, want %d: %+v", len(got), len(want), got)
+    }
+    for i, w := range want {
+        p := got[i]
+        if p.Nomor != w.nomor || p.Bab != w.bab || p.JudulBab != w.judulBab || p.Halaman != w.halaman || len(p.Ayat) != w.ayat {
+            t.Errorf("pasal %d = %+v, want %+v", i, p, w)
+        }
+    }
+
+    if ayat := got[1].Ayat; ayat[0].Nomor != 1 || ayat[0].Konten != "Setiap pegawai wajib hadir." || ayat[1].Nomor != 2 {
+        t.Errorf("ayat = %+v", ayat)
+    }
+    if got[3].Konten != "Peraturan Menteri ini mulai berlaku pada tanggal diundangkan." {
+        t.Errorf("konten pasal 4 = %q", got[3].Konten)
+    }
+    // Offset dihitung setelah nomor halaman "- 2 -" dibuang
+    if got[2].Offset != 19 {
+        t.Errorf("offset pasal 3 = %d, want 19", got[2].Offset)
+    }
+}
+
+func TestParseMalformed(t *testing.T) {
+    cases := map[string]struct {
+        pages []string
+        want  int
+    }{
+        "nil":               {nil, 0},
+        "no pages":          {[]string{}, 0},
+        "empty page":        {[]string{"", "  "}, 0},
+        "pasal tanpa nomor": {[]string{"Pasal"}, 0},
+        "hanya tanda baca":  {[]string{"Pasal Pasal ( ) BAB"}, 0},
+        "heading saja":      {[]string{"Pasal 1"}, 1},
+        "ayat tanpa pasal":  {[]string{"(1) (2) Pasal 1 2 3 A"}, 1},
+        "tanpa pasal 1":     {[]string{"BAB XX Pasal 7 isi"}, 0},
+        "bukan utf-8":       {[]string{"Pasal 1 \xff\xfe (1) \x00"}, 1},
+    }
+    for name, tc := range cases {
+        t.Run(name, func(t *testing.T) {
+            got := Parse(tc.pages)
+            if len(got) != tc.want {
+                t.Fatalf("got %d pasal, want %d: %+v", len(got), tc.want, got)
+            }
+            for _, p := range got {
+                if p.Halaman < 1 || p.HalamanAkhir < p.Halaman {
+                    t.Errorf("invalid halaman in %+v", p)
+                }
+            }
+        })
+    }
+}
+
+func TestRomanToInt(t *testing.T) {
+    cases := map[string]int{"I": 1, "IV": 4, "IX": 9, "XIV": 14, "XL": 40, "XC": 90}
+    for in, want := range cases {
+        if got := romanToInt(in); got != want {
+            t.Errorf("romanToInt(%q) = %d, want %d", in, got, want)
+        }
+    }
+}
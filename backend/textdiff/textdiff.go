@@ -0,0 +1,149 @@
+// Package textdiff membandingkan dua teks per kalimat untuk menampilkan perbedaan isi
+// dua versi file peraturan.
+package textdiff
+
+import "strings"
+
+// Jenis operasi diff
+const (
+    Equal  = "equal"
+    Delete = "delete"
+    Insert = "insert"
+    Skip   = "skip" // Potongan teks sama yang disembunyikan oleh Compact
+)
+
+// maxCells membatasi ukuran tabel LCS (baris x kolom) agar dokumen besar tidak menghabiskan memori.
+// Di atas batas ini bagian tengah yang berbeda dilaporkan sebagai hapus-semua lalu tambah-semua.
+const maxCells = 4_000_000
+
+// Op adalah satu langkah diff. A dan B adalah indeks unit di teks lama/baru (-1 jika tidak ada).
+type Op struct {
+    Kind  string `json:"op"`
+    Text  string `json:"text,omitempty"`
+    A     int    `json:"-"`
+    B     int    `json:"-"`
+    Count int    `json:"count,omitempty"` // Jumlah unit yang dilewati (hanya untuk Skip)
+}
+
+// Diff menghasilkan urutan operasi yang mengubah a menjadi b
+func Diff(a, b []string) []Op {
+    // Bagian awal dan akhir yang sama tidak perlu masuk tabel LCS
+    prefix := 0
+    for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+        prefix++
+    }
+    suffix := 0
+    for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+        suffix++
+    }
+
+    ops := make([]Op, 0, len(a)+len(b))
+    for i := 0; i < prefix; i++ {
+        ops = append(ops, Op{Kind: Equal, Text: a[i], A: i, B: i})
+    }
+    ops = append(ops, middle(a, b, prefix, len(a)-suffix, len(b)-suffix)...)
+    for k := suffix; k > 0; k-- {
+        i, j := len(a)-k, len(b)-k
+        ops = append(ops, Op{Kind: Equal, Text: a[i], A: i, B: j})
+    }
+    return ops
+}
+
+// middle membandingkan a[start:endA] dengan b[start:endB] menggunakan LCS
+func middle(a, b []string, start, endA, endB int) []Op {
+    n, m := endA-start, endB-start
+    var ops []Op
+    if n == 0 || m == 0 || n*m > maxCells {
+        for i := start; i < endA; i++ {
+            ops = append(ops, Op{Kind: Delete, Text: a[i], A: i, B: -1})
+        }
+        for j := start; j < endB; j++ {
+            ops = append(ops, Op{Kind: Insert, Text: b[j], A: -1, B: j})
+        }
+        return ops
+    }
+
+    // lcs[i][j] = panjang LCS dari a[start+i:endA] dan b[start+j:endB]
+    lcs := make([][]int32, n+1)
+    for i := range lcs {
+        lcs[i] = make([]int32, m+1)
+    }
+    for i := n - 1; i >= 0; i-- {
+        for j := m - 1; j >= 0; j-- {
+            if a[start+i] == b[start+j] {
+                lcs[i][j] = lcs[i+1][j+1] + 1
+            } else {
+                lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+            }
+        }
+    }
+
+    i, j := 0, 0
+    for i < n || j < m {
+        switch {
+        case i < n && j < m && a[start+i] == b[start+j]:
+            ops = append(ops, Op{Kind: Equal, Text: a[start+i], A: start + i, B: start + j})
+            i++
+            j++
+        case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+            ops = append(ops, Op{Kind: Delete, Text: a[start+i], A: start + i, B: -1})
+            i++
+        default:
+            ops = append(ops, Op{Kind: Insert, Text: b[start+j], A: -1, B: start + j})
+            j++
+        }
+    }
+    return ops
+}
+
+// Compact menyembunyikan potongan Equal yang panjang dan hanya menyisakan
+// context unit di sekitar perubahan, seperti unified diff
+func Compact(ops []Op, context int) []Op {
+    result := make([]Op, 0, len(ops))
+    for i := 0; i < len(ops); {
+        if ops[i].Kind != Equal {
+            result = append(result, ops[i])
+            i++
+            continue
+        }
+
+        end := i
+        for end < len(ops) && ops[end].Kind == Equal {
+            end++
+        }
+        keepBefore, keepAfter := context, context
+        if i == 0 {
+            keepBefore = 0
+        }
+        if end == len(ops) {
+            keepAfter = 0
+        }
+
+        if end-i <= keepBefore+keepAfter {
+            result = append(result, ops[i:end]...)
+        } else {
+            result = append(result, ops[i:i+keepBefore]...)
+            result = append(result, Op{Kind: Skip, A: ops[i+keepBefore].A, B: ops[i+keepBefore].B, Count: end - i - keepBefore - keepAfter})
+            result = append(result, ops[end-keepAfter:end]...)
+        }
+        i = end
+    }
+    return result
+}
+
+// SplitSentences memecah teks menjadi kalimat/butir (akhiran . ; : atau baris baru)
+// dengan spasi yang dirapikan, sehingga perubahan satu kata hanya menandai satu kalimat
+func SplitSentences(text string) []string {
+    var result []string
+    for _, line := range strings.Split(text, "\n") {
+        words := strings.Fields(line)
+        start := 0
+        for i, w := range words {
+            if strings.HasSuffix(w, ".") || strings.HasSuffix(w, ";") || strings.HasSuffix(w, ":") || i == len(words)-1 {
+                result = append(result, strings.Join(words[start:i+1], " "))
+                start = i + 1
+            }
+        }
+    }
+    return result
+}
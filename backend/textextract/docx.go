@@ -0,0 +1,94 @@
+package textextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// extractDOCX membaca word/document.xml. Batas halaman diambil dari page break eksplisit
+// dan penanda lastRenderedPageBreak yang disimpan Word saat dokumen terakhir dirender.
+func extractDOCX(data []byte) ([]string, error) {
+    zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+    if err != nil {
+        return nil, err
+    }
+
+    var doc *zip.File
+    for _, f := range zr.File {
+        if f.Name == "word/document.xml" {
+            doc = f
+            break
+        }
+    }
+    if doc == nil {
+        return nil, errors.New("word/document.xml not found")
+    }
+
+    rc, err := doc.Open()
+    if err != nil {
+        return nil, err
+    }
+    defer rc.Close()
+
+    var pages []string
+    var sb strings.Builder
+    inText := false
+
+    dec := xml.NewDecoder(rc)
+    for {
+        tok, err := dec.Token()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        switch t := tok.(type) {
+        case xml.StartElement:
+            switch t.Name.Local {
+            case "t":
+                inText = true
+            case "tab":
+                sb.WriteByte('\t')
+            case "cr":
+                sb.WriteByte('\n')
+            case "br":
+                isPage := false
+                for _, attr := range t.Attr {
+                    if attr.Name.Local == "type" && attr.Value == "page" {
+                        isPage = true
+                    }
+                }
+                if isPage {
+                    pages = append(pages, sb.String())
+                    sb.Reset()
+                } else {
+                    sb.WriteByte('\n')
+                }
+            case "lastRenderedPageBreak":
+                if sb.Len() > 0 {
+                    pages = append(pages, sb.String())
+                    sb.Reset()
+                }
+            }
+        case xml.EndElement:
+            switch t.Name.Local {
+            case "t":
+                inText = false
+            case "p":
+                sb.WriteByte('\n')
+            }
+        case xml.CharData:
+            if inText {
+                sb.Write(t)
+            }
+        }
+    }
+    pages = append(pages, sb.String())
+    return pages, nil
+}
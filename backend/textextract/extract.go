@@ -0,0 +1,74 @@
+// Package textextract mengekstrak teks per halaman dari dokumen peraturan (PDF dan DOCX)
+// tanpa dependensi eksternal. Parser PDF yang sama juga dipakai untuk membubuhkan watermark.
+package textextract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported document format")
+
+// ExtractFile membaca file di path lalu mengekstrak teksnya per halaman
+func ExtractFile(path string) ([]string, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    return Extract(data)
+}
+
+// Extract mengembalikan teks per halaman (index 0 = halaman 1).
+// Format dideteksi dari magic bytes, bukan dari ekstensi file.
+// Dokumen rusak yang membuat parser panic dikembalikan sebagai error.
+func Extract(data []byte) (pages []string, err error) {
+    defer recoverParse(&err)
+
+    switch {
+    case bytes.HasPrefix(data, []byte("%PDF")):
+        pages, err = extractPDF(data)
+    case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+        pages, err = extractDOCX(data)
+    default:
+        return nil, ErrUnsupportedFormat
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    for i := range pages {
+        pages[i] = normalizeSpace(pages[i])
+    }
+    return pages, nil
+}
+
+// recoverParse mengubah panic dari parser (input yang rusak atau sengaja dibuat aneh) menjadi
+// error agar satu file tidak menjatuhkan server
+func recoverParse(err *error) {
+    if r := recover(); r != nil {
+        *err = fmt.Errorf("failed to parse document: %v", r)
+    }
+}
+
+// normalizeSpace merapikan spasi berulang dan baris kosong berlebih
+func normalizeSpace(s string) string {
+    lines := strings.Split(s, "\n")
+    out := make([]string, 0, len(lines))
+    blank := false
+    for _, line := range lines {
+        line = strings.Join(strings.Fields(line), " ")
+        if line == "" {
+            if !blank && len(out) > 0 {
+                out = append(out, "")
+            }
+            blank = true
+            continue
+        }
+        blank = false
+        out = append(out, line)
+    }
+    return strings.TrimSpace(strings.Join(out, "\n"))
+}
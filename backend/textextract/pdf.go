@@ -0,0 +1,379 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var ErrEncryptedPDF = errors.New("encrypted PDF is not supported")
+
+// maxDecodedStream membatasi hasil dekompresi satu stream agar zip bomb tidak menghabiskan memori
+const maxDecodedStream = 64 << 20
+
+var errStreamTooLarge = errors.New("decoded PDF stream is too large")
+
+var pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+type pdfObject struct {
+    value  any
+    stream []byte // data stream mentah (belum di-decode), nil jika bukan stream
+}
+
+type pdfFile struct {
+    objects map[int]*pdfObject
+}
+
+// parsePDF memindai seluruh objek "N G obj ... endobj" termasuk yang ada di dalam object stream
+func parsePDF(data []byte) (*pdfFile, error) {
+    f := &pdfFile{objects: map[int]*pdfObject{}}
+
+    pos := 0
+    for pos < len(data) {
+        loc := pdfObjHeader.FindSubmatchIndex(data[pos:])
+        if loc == nil {
+            break
+        }
+        num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+        bodyStart := pos + loc[1]
+        obj, end := parseIndirectObject(data, bodyStart)
+        f.objects[num] = obj
+        pos = end
+    }
+
+    // Objek yang disimpan di dalam object stream (PDF 1.5+)
+    var streamNums []int
+    for num, obj := range f.objects {
+        if d, ok := obj.value.(pdfDict); ok && d["Type"] == pdfName("ObjStm") && obj.stream != nil {
+            streamNums = append(streamNums, num)
+        }
+    }
+    sort.Ints(streamNums)
+    for _, num := range streamNums {
+        f.loadObjectStream(f.objects[num])
+    }
+
+    for _, obj := range f.objects {
+        if d, ok := obj.value.(pdfDict); ok {
+            if _, enc := d["Encrypt"]; enc {
+                return nil, ErrEncryptedPDF
+            }
+        }
+    }
+    if bytes.Contains(data, []byte("/Encrypt")) && trailerHasEncrypt(data) {
+        return nil, ErrEncryptedPDF
+    }
+
+    return f, nil
+}
+
+func trailerHasEncrypt(data []byte) bool {
+    idx := bytes.LastIndex(data, []byte("trailer"))
+    if idx < 0 {
+        return false
+    }
+    lex := &pdfLexer{data: data, pos: idx + len("trailer")}
+    v, _ := lex.object()
+    d, ok := v.(pdfDict)
+    if !ok {
+        return false
+    }
+    _, enc := d["Encrypt"]
+    return enc
+}
+
+// parseIndirectObject membaca isi objek mulai dari start dan mengembalikan posisi setelah endobj
+func parseIndirectObject(data []byte, start int) (*pdfObject, int) {
+    lex := &pdfLexer{data: data, pos: start}
+    val, _ := lex.object()
+    obj := &pdfObject{value: val}
+
+    lex.skipSpace()
+    if bytes.HasPrefix(data[lex.pos:], []byte("stream")) {
+        streamStart := lex.pos + len("stream")
+        if streamStart < len(data) && data[streamStart] == '\r' {
+            streamStart++
+        }
+        if streamStart < len(data) && data[streamStart] == '\n' {
+            streamStart++
+        }
+
+        streamEnd := -1
+        if d, ok := val.(pdfDict); ok {
+            if n, ok := d["Length"].(float64); ok && n >= 0 && n <= float64(len(data)-streamStart) {
+                end := streamStart + int(n)
+                if end <= len(data) {
+                    rest := bytes.TrimLeft(data[end:min(end+32, len(data))], "\r\n \t")
+                    if bytes.HasPrefix(rest, []byte("endstream")) {
+                        streamEnd = end
+                    }
+                }
+            }
+        }
+        if streamEnd < 0 {
+            idx := bytes.Index(data[streamStart:], []byte("endstream"))
+            if idx < 0 {
+                return obj, len(data)
+            }
+            streamEnd = streamStart + idx
+            for streamEnd > streamStart && (data[streamEnd-1] == '\n' || data[streamEnd-1] == '\r') {
+                streamEnd--
+            }
+        }
+        obj.stream = data[streamStart:streamEnd]
+        lex.pos = streamEnd
+    }
+
+    idx := bytes.Index(data[lex.pos:], []byte("endobj"))
+    if idx < 0 {
+        return obj, len(data)
+    }
+    return obj, lex.pos + idx + len("endobj")
+}
+
+func (f *pdfFile) loadObjectStream(obj *pdfObject) {
+    d := obj.value.(pdfDict)
+    data, err := f.decodeStream(obj)
+    if err != nil {
+        return
+    }
+    n, _ := f.resolve(d["N"]).(float64)
+    first, _ := f.resolve(d["First"]).(float64)
+    // Setiap entri minimal "0 0 " (4 byte), jadi N yang lebih besar pasti rusak
+    if n < 0 || n > float64(len(data)/4) || first < 0 || first > float64(len(data)) {
+        return
+    }
+
+    lex := &pdfLexer{data: data}
+    type entry struct{ num, offset int }
+    entries := make([]entry, 0, int(n))
+    for i := 0; i < int(n); i++ {
+        numTok, ok1 := lex.token()
+        offTok, ok2 := lex.token()
+        num, isNum := numTok.(float64)
+        off, isOff := offTok.(float64)
+        if !ok1 || !ok2 || !isNum || !isOff {
+            break
+        }
+        entries = append(entries, entry{int(num), int(off)})
+    }
+
+    for _, e := range entries {
+        if _, exists := f.objects[e.num]; exists {
+            continue
+        }
+        start := int(first) + e.offset
+        if start < 0 || start >= len(data) {
+            continue
+        }
+        objLex := &pdfLexer{data: data, pos: start}
+        val, ok := objLex.object()
+        if !ok {
+            continue
+        }
+        f.objects[e.num] = &pdfObject{value: val}
+    }
+}
+
+// resolve mengikuti referensi tidak langsung sampai mendapatkan nilai sebenarnya
+func (f *pdfFile) resolve(v any) any {
+    for i := 0; i < 32; i++ {
+        ref, ok := v.(pdfRef)
+        if !ok {
+            return v
+        }
+        obj, ok := f.objects[ref.Num]
+        if !ok {
+            return nil
+        }
+        v = obj.value
+    }
+    return nil
+}
+
+func (f *pdfFile) resolveDict(v any) pdfDict {
+    d, _ := f.resolve(v).(pdfDict)
+    return d
+}
+
+// streamOf mengembalikan objek stream yang dirujuk oleh v
+func (f *pdfFile) streamOf(v any) *pdfObject {
+    ref, ok := v.(pdfRef)
+    if !ok {
+        return nil
+    }
+    obj, ok := f.objects[ref.Num]
+    if !ok || obj.stream == nil {
+        return nil
+    }
+    return obj
+}
+
+// decodeStream menerapkan filter stream (FlateDecode, ASCII85Decode, ASCIIHexDecode)
+func (f *pdfFile) decodeStream(obj *pdfObject) ([]byte, error) {
+    d, _ := obj.value.(pdfDict)
+    data := obj.stream
+
+    var filters []any
+    switch v := f.resolve(d["Filter"]).(type) {
+    case pdfName:
+        filters = []any{v}
+    case pdfArray:
+        filters = v
+    }
+
+    for _, raw := range filters {
+        name, _ := f.resolve(raw).(pdfName)
+        switch name {
+        case "FlateDecode", "Fl":
+            zr, err := zlib.NewReader(bytes.NewReader(data))
+            if err != nil {
+                return nil, err
+            }
+            out, err := io.ReadAll(io.LimitReader(zr, maxDecodedStream+1))
+            zr.Close()
+            if len(out) > maxDecodedStream {
+                return nil, errStreamTooLarge
+            }
+            // Stream yang terpotong masih bisa dipakai sebagian
+            if err != nil && len(out) == 0 {
+                return nil, err
+            }
+            data = out
+        case "ASCII85Decode", "A85":
+            src := bytes.TrimSpace(data)
+            src = bytes.TrimPrefix(src, []byte("<~"))
+            src = bytes.TrimSuffix(src, []byte("~>"))
+            out := make([]byte, len(src))
+            n, _, err := ascii85.Decode(out, src, true)
+            if err != nil {
+                return nil, err
+            }
+            data = out[:n]
+        case "ASCIIHexDecode", "AHx":
+            lex := &pdfLexer{data: append(append([]byte("<"), data...), '>')}
+            data = []byte(lex.hexString())
+        default:
+            return nil, errors.New("unsupported stream filter: " + string(name))
+        }
+    }
+    return data, nil
+}
+
+// pdfPage adalah satu halaman beserta resources yang sudah diwariskan dari node induknya
+type pdfPage struct {
+    dict      pdfDict
+    resources pdfDict
+}
+
+// pages mengumpulkan halaman sesuai urutan di page tree
+func (f *pdfFile) pages() []pdfPage {
+    var catalog pdfDict
+    var catalogNum = -1
+    for num, obj := range f.objects {
+        if d, ok := obj.value.(pdfDict); ok && d["Type"] == pdfName("Catalog") && num > catalogNum {
+            catalog, catalogNum = d, num
+        }
+    }
+
+    var pages []pdfPage
+    if catalog != nil {
+        visited := map[int]bool{}
+        f.walkPages(catalog["Pages"], nil, visited, &pages)
+    }
+    if len(pages) > 0 {
+        return pages
+    }
+
+    // Fallback: urutkan objek /Type /Page berdasarkan nomor objek
+    var nums []int
+    for num, obj := range f.objects {
+        if d, ok := obj.value.(pdfDict); ok && d["Type"] == pdfName("Page") {
+            nums = append(nums, num)
+        }
+    }
+    sort.Ints(nums)
+    for _, num := range nums {
+        d := f.objects[num].value.(pdfDict)
+        pages = append(pages, pdfPage{dict: d, resources: f.resolveDict(d["Resources"])})
+    }
+    return pages
+}
+
+func (f *pdfFile) walkPages(node any, inherited pdfDict, visited map[int]bool, out *[]pdfPage) {
+    if ref, ok := node.(pdfRef); ok {
+        if visited[ref.Num] {
+            return
+        }
+        visited[ref.Num] = true
+    }
+    d := f.resolveDict(node)
+    if d == nil {
+        return
+    }
+
+    resources := inherited
+    if r := f.resolveDict(d["Resources"]); r != nil {
+        resources = r
+    }
+
+    if d["Type"] == pdfName("Pages") || d["Kids"] != nil {
+        kids, _ := f.resolve(d["Kids"]).(pdfArray)
+        for _, kid := range kids {
+            f.walkPages(kid, resources, visited, out)
+        }
+        return
+    }
+    *out = append(*out, pdfPage{dict: d, resources: resources})
+}
+
+// contents menggabungkan seluruh content stream milik halaman
+func (f *pdfFile) contents(page pdfDict) []byte {
+    var refs []any
+    switch v := page["Contents"].(type) {
+    case pdfRef:
+        if arr, ok := f.resolve(v).(pdfArray); ok {
+            refs = arr
+        } else {
+            refs = []any{v}
+        }
+    case pdfArray:
+        refs = v
+    }
+
+    var buf bytes.Buffer
+    for _, ref := range refs {
+        obj := f.streamOf(ref)
+        if obj == nil {
+            continue
+        }
+        data, err := f.decodeStream(obj)
+        if err != nil {
+            continue
+        }
+        buf.Write(data)
+        buf.WriteByte('\n')
+    }
+    return buf.Bytes()
+}
+
+func extractPDF(data []byte) ([]string, error) {
+    f, err := parsePDF(data)
+    if err != nil {
+        return nil, err
+    }
+
+    pages := f.pages()
+    out := make([]string, len(pages))
+    for i, page := range pages {
+        te := newTextExtractor(f)
+        te.run(f.contents(page.dict), page.resources, 0)
+        out[i] = te.String()
+    }
+    return out, nil
+}
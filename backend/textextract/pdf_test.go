@@ -0,0 +1,154 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildPDF menyusun PDF satu halaman dengan content stream yang diberikan
+func buildPDF(content string) []byte {
+    objs := []string{
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+        fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+        "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+    }
+    var b bytes.Buffer
+    b.WriteString("%PDF-1.4\n")
+    offsets := make([]int, len(objs))
+    for i, o := range objs {
+        offsets[i] = b.Len()
+        fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+    }
+    xref := b.Len()
+    fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+    for _, off := range offsets {
+        fmt.Fprintf(&b, "%010d 00000 n \n", off)
+    }
+    fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+    return b.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+    pages, err := Extract(buildPDF("BT /F1 12 Tf 72 700 Td (Pasal 1 berlaku) Tj ET"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(pages) != 1 || !strings.Contains(pages[0], "Pasal 1 berlaku") {
+        t.Fatalf("unexpected pages: %q", pages)
+    }
+}
+
+func TestExtractMalformedPDF(t *testing.T) {
+    var bomb bytes.Buffer
+    zw := zlib.NewWriter(&bomb)
+    zw.Write(bytes.Repeat([]byte{0}, maxDecodedStream+1024))
+    zw.Close()
+
+    cases := []struct {
+        name string
+        data []byte
+    }{
+        {"unterminated hex string", []byte("%PDF-1.4\n1 0 obj\n<abc")},
+        {"unterminated hex string before stream", []byte("%PDF-1.4\n1 0 obj\n<< /Length 5 >> <61")},
+        {"negative object stream count", []byte("%PDF-1.4\n1 0 obj\n<< /Type /ObjStm /N -1 /First 0 /Length 4 >>\nstream\n1 0 \nendstream\nendobj\n")},
+        {"huge object stream count", []byte("%PDF-1.4\n1 0 obj\n<< /Type /ObjStm /N 1e15 /First 1e15 /Length 4 >>\nstream\n1 0 \nendstream\nendobj\n")},
+        {"negative stream length", []byte("%PDF-1.4\n1 0 obj\n<< /Length -100 >>\nstream\nabc\nendstream\nendobj\n")},
+        {"huge stream length", []byte("%PDF-1.4\n1 0 obj\n<< /Length 1e300 >>\nstream\nabc\nendstream\nendobj\n")},
+        {"truncated literal string", []byte("%PDF-1.4\n1 0 obj\n(abc\\")},
+        {"truncated name escape", []byte("%PDF-1.4\n1 0 obj\n/A#4")},
+        {"unterminated dictionary", []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages [1 0 R")},
+        {"flate bomb", append(append([]byte(fmt.Sprintf("%%PDF-1.4\n1 0 obj\n<< /Filter /FlateDecode /Length %d >>\nstream\n", bomb.Len())), bomb.Bytes()...), []byte("\nendstream\nendobj\n")...)},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            // Cukup tidak panic; hasil atau error bebas. parsePDF dipanggil langsung agar
+            // recover di Extract tidak menyembunyikan bug parser.
+            if f, err := parsePDF(tc.data); err == nil {
+                for _, page := range f.pages() {
+                    f.contents(page.dict)
+                }
+            }
+            Extract(tc.data)
+            StampPDF(tc.data, Stamp{Diagonal: "x"})
+        })
+    }
+}
+
+func TestLexerObject(t *testing.T) {
+    cases := []struct {
+        in   string
+        want any
+    }{
+        {"(a\\(b\\)c)", "a(b)c"},
+        {"(nested (paren) ok)", "nested (paren) ok"},
+        {"(oct\\101\\1)", "octA\x01"},
+        {"(unterminated", "unterminated"},
+        {"<48 65 6c>", "Hel"},
+        {"<4>", "@"},
+        {"<zz41>", "A"},
+        {"/A#20B", pdfName("A B")},
+        {"/A#zz", pdfName("A#zz")},
+        {"[1 0 R (x)]", pdfArray{pdfRef{1, 0}, "x"}},
+        // Array yang tidak ditutup menelan sisa dict; parser cukup berhenti di akhir data
+        {"<< /K [1 2 /V (v) >>", pdfDict{"K": pdfArray{1.0, 2.0, pdfName("V"), "v", pdfKeyword(">>")}}},
+        {"<< /A 1 /B >>", pdfDict{"A": 1.0, "B": pdfKeyword(">>")}},
+        {"% komentar\n/Nama", pdfName("Nama")},
+    }
+    for _, tc := range cases {
+        t.Run(tc.in, func(t *testing.T) {
+            lex := &pdfLexer{data: []byte(tc.in)}
+            got, ok := lex.object()
+            if !ok {
+                t.Fatal("no object")
+            }
+            if !reflect.DeepEqual(got, tc.want) {
+                t.Fatalf("object = %#v, want %#v", got, tc.want)
+            }
+            if lex.pos > len(lex.data) {
+                t.Fatalf("pos %d past end of data (%d)", lex.pos, len(lex.data))
+            }
+        })
+    }
+}
+
+func TestHexStringUnterminated(t *testing.T) {
+    lex := &pdfLexer{data: []byte("<616")}
+    if got := lex.hexString(); got != "a`" {
+        t.Fatalf("hexString = %q", got)
+    }
+    if lex.pos > len(lex.data) {
+        t.Fatalf("pos %d past end of data (%d)", lex.pos, len(lex.data))
+    }
+}
+
+func TestDecodeStreamLimit(t *testing.T) {
+    var buf bytes.Buffer
+    zw := zlib.NewWriter(&buf)
+    zw.Write(bytes.Repeat([]byte{'a'}, maxDecodedStream+1))
+    zw.Close()
+    f := &pdfFile{objects: map[int]*pdfObject{}}
+    _, err := f.decodeStream(&pdfObject{value: pdfDict{"Filter": pdfName("FlateDecode")}, stream: buf.Bytes()})
+    if err != errStreamTooLarge {
+        t.Fatalf("err = %v, want errStreamTooLarge", err)
+    }
+}
+
+func TestStampPDF(t *testing.T) {
+    out, err := StampPDF(buildPDF("BT /F1 12 Tf 72 700 Td (Isi) Tj ET"), Stamp{Diagonal: "Budi - NIP 1", Footer: []string{"Kode verifikasi: ABCDE-FGHJK"}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    pages, err := Extract(out)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(pages) != 1 || !strings.Contains(pages[0], "ABCDE-FGHJK") || !strings.Contains(pages[0], "Isi") {
+        t.Fatalf("stamped text not found: %q", pages)
+    }
+}
@@ -0,0 +1,258 @@
+package textextract
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Tipe nilai hasil parsing objek PDF
+type (
+    pdfName    string
+    pdfKeyword string
+    pdfDict    map[string]any
+    pdfArray   []any
+    pdfRef     struct{ Num, Gen int }
+)
+
+// pdfLexer membaca token dari objek PDF maupun content stream
+type pdfLexer struct {
+    data []byte
+    pos  int
+}
+
+func isPDFSpace(b byte) bool {
+    return b == 0 || b == '\t' || b == '\n' || b == '\f' || b == '\r' || b == ' '
+}
+
+func isPDFDelim(b byte) bool {
+    return strings.IndexByte("()<>[]{}/%", b) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+    for l.pos < len(l.data) {
+        b := l.data[l.pos]
+        if isPDFSpace(b) {
+            l.pos++
+            continue
+        }
+        if b == '%' {
+            for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+                l.pos++
+            }
+            continue
+        }
+        return
+    }
+}
+
+// token mengembalikan token berikutnya, ok=false jika data habis
+func (l *pdfLexer) token() (any, bool) {
+    l.skipSpace()
+    if l.pos >= len(l.data) {
+        return nil, false
+    }
+
+    b := l.data[l.pos]
+    switch {
+    case b == '(':
+        return l.literalString(), true
+    case b == '<':
+        if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+            l.pos += 2
+            return pdfKeyword("<<"), true
+        }
+        return l.hexString(), true
+    case b == '>':
+        if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+            l.pos += 2
+            return pdfKeyword(">>"), true
+        }
+        l.pos++
+        return pdfKeyword(">"), true
+    case b == '[' || b == ']' || b == '{' || b == '}':
+        l.pos++
+        return pdfKeyword(string(b)), true
+    case b == '/':
+        l.pos++
+        return l.name(), true
+    case b == ')':
+        l.pos++
+        return pdfKeyword(")"), true
+    }
+
+    start := l.pos
+    for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+        l.pos++
+    }
+    word := string(l.data[start:l.pos])
+    if n, err := strconv.ParseFloat(word, 64); err == nil {
+        return n, true
+    }
+    switch word {
+    case "true":
+        return true, true
+    case "false":
+        return false, true
+    case "null":
+        return nil, true
+    }
+    return pdfKeyword(word), true
+}
+
+func (l *pdfLexer) name() pdfName {
+    var sb strings.Builder
+    for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+        b := l.data[l.pos]
+        if b == '#' && l.pos+2 < len(l.data) {
+            if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+                sb.WriteByte(byte(v))
+                l.pos += 3
+                continue
+            }
+        }
+        sb.WriteByte(b)
+        l.pos++
+    }
+    return pdfName(sb.String())
+}
+
+func (l *pdfLexer) literalString() string {
+    l.pos++ // lewati '('
+    var sb strings.Builder
+    depth := 1
+    for l.pos < len(l.data) {
+        b := l.data[l.pos]
+        l.pos++
+        switch b {
+        case '(':
+            depth++
+        case ')':
+            depth--
+            if depth == 0 {
+                return sb.String()
+            }
+        case '\\':
+            if l.pos >= len(l.data) {
+                return sb.String()
+            }
+            e := l.data[l.pos]
+            l.pos++
+            switch e {
+            case 'n':
+                sb.WriteByte('\n')
+            case 'r':
+                sb.WriteByte('\r')
+            case 't':
+                sb.WriteByte('\t')
+            case 'b':
+                sb.WriteByte('\b')
+            case 'f':
+                sb.WriteByte('\f')
+            case '\r':
+                if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+                    l.pos++
+                }
+            case '\n':
+                // line continuation
+            default:
+                if e >= '0' && e <= '7' {
+                    v := int(e - '0')
+                    for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+                        v = v*8 + int(l.data[l.pos]-'0')
+                        l.pos++
+                    }
+                    sb.WriteByte(byte(v))
+                } else {
+                    sb.WriteByte(e)
+                }
+            }
+            continue
+        }
+        sb.WriteByte(b)
+    }
+    return sb.String()
+}
+
+func (l *pdfLexer) hexString() string {
+    l.pos++ // lewati '<'
+    var digits []byte
+    for l.pos < len(l.data) && l.data[l.pos] != '>' {
+        b := l.data[l.pos]
+        if !isPDFSpace(b) {
+            digits = append(digits, b)
+        }
+        l.pos++
+    }
+    if l.pos < len(l.data) {
+        l.pos++ // lewati '>'; string yang tidak ditutup berakhir di akhir data
+    }
+    if len(digits)%2 == 1 {
+        digits = append(digits, '0')
+    }
+    out := make([]byte, 0, len(digits)/2)
+    for i := 0; i+1 < len(digits); i += 2 {
+        v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+        if err != nil {
+            continue
+        }
+        out = append(out, byte(v))
+    }
+    return string(out)
+}
+
+// object membaca satu objek PDF utuh (dict, array, referensi, atau nilai sederhana)
+func (l *pdfLexer) object() (any, bool) {
+    tok, ok := l.token()
+    if !ok {
+        return nil, false
+    }
+    return l.objectFrom(tok), true
+}
+
+func (l *pdfLexer) objectFrom(tok any) any {
+    switch t := tok.(type) {
+    case pdfKeyword:
+        switch t {
+        case "<<":
+            dict := pdfDict{}
+            for {
+                key, ok := l.token()
+                if !ok || key == pdfKeyword(">>") {
+                    return dict
+                }
+                name, isName := key.(pdfName)
+                if !isName {
+                    continue
+                }
+                val, ok := l.object()
+                if !ok {
+                    return dict
+                }
+                dict[string(name)] = val
+            }
+        case "[":
+            var arr pdfArray
+            for {
+                tok, ok := l.token()
+                if !ok || tok == pdfKeyword("]") {
+                    return arr
+                }
+                arr = append(arr, l.objectFrom(tok))
+            }
+        }
+        return t
+    case float64:
+        // Cek pola "num gen R" untuk referensi objek
+        save := l.pos
+        gen, ok := l.token()
+        if g, isNum := gen.(float64); ok && isNum {
+            r, ok := l.token()
+            if ok && r == pdfKeyword("R") {
+                return pdfRef{Num: int(t), Gen: int(g)}
+            }
+        }
+        l.pos = save
+        return t
+    }
+    return tok
+}
@@ -0,0 +1,474 @@
+package textextract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrNotPDF = errors.New("document is not a PDF")
+
+// Stamp adalah teks watermark yang dibubuhkan pada setiap halaman
+type Stamp struct {
+    Diagonal string   // Teks besar miring di tengah halaman, dibuat transparan
+    Footer   []string // Baris kecil di bagian bawah halaman
+}
+
+// Nama resource yang ditambahkan ke halaman; dibuat unik agar tidak bentrok dengan resource asli
+const (
+    stampFont       = "SIKepWMF"
+    stampDiagonalGS = "SIKepWMG1"
+    stampFooterGS   = "SIKepWMG2"
+)
+
+// stampPage adalah halaman beserta atribut yang bisa diwariskan dari page tree
+type stampPage struct {
+    ref       pdfRef
+    dict      pdfDict
+    resources pdfDict
+    box       pdfArray
+    rotate    int
+}
+
+// StampPDF membubuhkan watermark pada setiap halaman PDF. File asli tidak diubah: objek halaman
+// yang baru ditambahkan sebagai incremental update (xref baru dengan /Prev ke xref lama),
+// sehingga struktur dan isi dokumen asli tetap utuh.
+func StampPDF(data []byte, stamp Stamp) (out []byte, err error) {
+    defer recoverParse(&err)
+    if !bytes.HasPrefix(data, []byte("%PDF")) {
+        return nil, ErrNotPDF
+    }
+    f, err := parsePDF(data)
+    if err != nil {
+        return nil, err
+    }
+
+    prevXref, trailer, xrefStream, err := lastTrailer(data)
+    if err != nil {
+        return nil, err
+    }
+    root, ok := trailer["Root"].(pdfRef)
+    if !ok {
+        return nil, errors.New("PDF trailer has no /Root")
+    }
+    var pages []stampPage
+    f.walkStampPages(f.resolveDict(root)["Pages"], stampPage{}, map[int]bool{}, &pages)
+    if len(pages) == 0 {
+        return nil, errors.New("PDF has no pages")
+    }
+
+    next := 0
+    if size, ok := trailer["Size"].(float64); ok {
+        next = int(size)
+    }
+    for num := range f.objects {
+        next = max(next, num+1)
+    }
+
+    w := &pdfWriter{buf: bytes.NewBuffer(make([]byte, 0, len(data)+len(pages)*2048))}
+    w.buf.Write(data)
+    if !bytes.HasSuffix(data, []byte("\n")) {
+        w.buf.WriteByte('\n')
+    }
+    alloc := func() pdfRef {
+        ref := pdfRef{Num: next}
+        next++
+        return ref
+    }
+
+    fontRef := alloc()
+    w.object(fontRef, pdfDict{"Type": pdfName("Font"), "Subtype": pdfName("Type1"), "BaseFont": pdfName("Helvetica"), "Encoding": pdfName("WinAnsiEncoding")})
+    diagonalGS := alloc()
+    w.object(diagonalGS, pdfDict{"Type": pdfName("ExtGState"), "ca": 0.15, "CA": 0.15})
+    footerGS := alloc()
+    w.object(footerGS, pdfDict{"Type": pdfName("ExtGState"), "ca": 0.75, "CA": 0.75})
+    // Isi asli diapit q ... Q agar perubahan graphics state di dalamnya tidak memengaruhi watermark
+    openRef := alloc()
+    w.stream(openRef, []byte("q\n"))
+
+    overlays := map[string]pdfRef{}
+    for _, page := range pages {
+        width, height, matrix := pageGeometry(page.box, page.rotate)
+        key := fmt.Sprintf("%g %g %s", width, height, matrix)
+        overlay, ok := overlays[key]
+        if !ok {
+            overlay = alloc()
+            w.stream(overlay, stampContent(stamp, width, height, matrix))
+            overlays[key] = overlay
+        }
+
+        contents := pdfArray{openRef}
+        switch v := page.dict["Contents"].(type) {
+        case pdfRef:
+            if arr, ok := f.resolve(v).(pdfArray); ok {
+                contents = append(contents, arr...)
+            } else {
+                contents = append(contents, v)
+            }
+        case pdfArray:
+            contents = append(contents, v...)
+        }
+        contents = append(contents, overlay)
+
+        resources := copyDict(page.resources)
+        fonts := copyDict(f.resolveDict(resources["Font"]))
+        fonts[stampFont] = fontRef
+        resources["Font"] = fonts
+        states := copyDict(f.resolveDict(resources["ExtGState"]))
+        states[stampDiagonalGS] = diagonalGS
+        states[stampFooterGS] = footerGS
+        resources["ExtGState"] = states
+
+        dict := copyDict(page.dict)
+        dict["Contents"] = contents
+        dict["Resources"] = resources
+        w.object(page.ref, dict)
+    }
+
+    extra := pdfDict{"Root": root, "Prev": float64(prevXref)}
+    for _, key := range []string{"Info", "ID"} {
+        if v, ok := trailer[key]; ok {
+            extra[key] = v
+        }
+    }
+    if xrefStream {
+        w.xrefStream(alloc(), extra)
+    } else {
+        w.xrefTable(next, extra)
+    }
+    return w.buf.Bytes(), nil
+}
+
+// lastTrailer membaca trailer yang ditunjuk startxref terakhir, baik xref table maupun xref stream
+func lastTrailer(data []byte) (int, pdfDict, bool, error) {
+    idx := bytes.LastIndex(data, []byte("startxref"))
+    if idx < 0 {
+        return 0, nil, false, errors.New("PDF has no startxref")
+    }
+    lex := &pdfLexer{data: data, pos: idx + len("startxref")}
+    tok, _ := lex.token()
+    offset, ok := tok.(float64)
+    if !ok || offset < 0 || offset >= float64(len(data)) {
+        return 0, nil, false, errors.New("invalid startxref offset")
+    }
+
+    pos := int(offset)
+    lex = &pdfLexer{data: data, pos: pos}
+    lex.skipSpace()
+    if bytes.HasPrefix(data[lex.pos:], []byte("xref")) {
+        t := bytes.Index(data[lex.pos:], []byte("trailer"))
+        if t < 0 {
+            return 0, nil, false, errors.New("PDF has no trailer")
+        }
+        lex.pos += t + len("trailer")
+        v, _ := lex.object()
+        trailer, ok := v.(pdfDict)
+        if !ok {
+            return 0, nil, false, errors.New("invalid PDF trailer")
+        }
+        return pos, trailer, false, nil
+    }
+
+    loc := pdfObjHeader.FindIndex(data[lex.pos:])
+    if loc == nil || loc[0] != 0 {
+        return 0, nil, false, errors.New("startxref does not point to a cross-reference section")
+    }
+    obj, _ := parseIndirectObject(data, lex.pos+loc[1])
+    trailer, ok := obj.value.(pdfDict)
+    if !ok || trailer["Type"] != pdfName("XRef") {
+        return 0, nil, false, errors.New("invalid cross-reference stream")
+    }
+    return pos, trailer, true, nil
+}
+
+// walkStampPages seperti walkPages, tetapi menyimpan referensi objek halaman dan
+// atribut turunan (Resources, MediaBox/CropBox, Rotate) yang diperlukan untuk watermark
+func (f *pdfFile) walkStampPages(node any, inherited stampPage, visited map[int]bool, out *[]stampPage) {
+    ref, isRef := node.(pdfRef)
+    if !isRef || visited[ref.Num] {
+        return
+    }
+    visited[ref.Num] = true
+    d := f.resolveDict(node)
+    if d == nil {
+        return
+    }
+
+    if r := f.resolveDict(d["Resources"]); r != nil {
+        inherited.resources = r
+    }
+    if box, ok := f.resolve(d["MediaBox"]).(pdfArray); ok && len(box) == 4 {
+        inherited.box = box
+    }
+    if box, ok := f.resolve(d["CropBox"]).(pdfArray); ok && len(box) == 4 {
+        inherited.box = box
+    }
+    if rotate, ok := f.resolve(d["Rotate"]).(float64); ok {
+        inherited.rotate = int(rotate)
+    }
+
+    if d["Type"] == pdfName("Pages") || d["Kids"] != nil {
+        kids, _ := f.resolve(d["Kids"]).(pdfArray)
+        for _, kid := range kids {
+            f.walkStampPages(kid, inherited, visited, out)
+        }
+        return
+    }
+    if inherited.box != nil {
+        resolved := make(pdfArray, len(inherited.box))
+        for i, v := range inherited.box {
+            resolved[i] = f.resolve(v)
+        }
+        inherited.box = resolved
+    }
+    inherited.ref, inherited.dict = ref, d
+    *out = append(*out, inherited)
+}
+
+// pageGeometry mengembalikan ukuran halaman sebagaimana tampil (setelah /Rotate) dan matriks cm
+// yang memetakan koordinat tampilan ke koordinat halaman
+func pageGeometry(box pdfArray, rotate int) (float64, float64, string) {
+    llx, lly, urx, ury := 0.0, 0.0, 595.0, 842.0 // A4 jika MediaBox tidak ada
+    if len(box) == 4 {
+        values := make([]float64, 4)
+        valid := true
+        for i, v := range box {
+            n, ok := v.(float64)
+            valid = valid && ok
+            values[i] = n
+        }
+        if valid {
+            llx, lly = math.Min(values[0], values[2]), math.Min(values[1], values[3])
+            urx, ury = math.Max(values[0], values[2]), math.Max(values[1], values[3])
+        }
+    }
+    w, h := urx-llx, ury-lly
+
+    switch ((rotate % 360) + 360) % 360 {
+    case 90:
+        return h, w, fmt.Sprintf("0 1 -1 0 %s %s", num(llx+w), num(lly))
+    case 180:
+        return w, h, fmt.Sprintf("-1 0 0 -1 %s %s", num(llx+w), num(lly+h))
+    case 270:
+        return h, w, fmt.Sprintf("0 -1 1 0 %s %s", num(llx), num(lly+h))
+    }
+    return w, h, fmt.Sprintf("1 0 0 1 %s %s", num(llx), num(lly))
+}
+
+// stampContent membuat content stream watermark. Stream diawali Q untuk menutup q
+// yang mengapit isi asli halaman.
+func stampContent(stamp Stamp, width, height float64, matrix string) []byte {
+    var b bytes.Buffer
+    b.WriteString("\nQ\nq\n" + matrix + " cm\n")
+
+    if stamp.Diagonal != "" {
+        text := winAnsi(stamp.Diagonal)
+        angle := math.Atan2(height, width)
+        diagonal := math.Hypot(width, height)
+        // Lebar rata-rata glyph Helvetica kira-kira 0,55 em
+        size := math.Min(54, 0.8*diagonal/(0.55*float64(len(text))))
+        textWidth := 0.55 * size * float64(len(text))
+        cos, sin := math.Cos(angle), math.Sin(angle)
+        x := width/2 - cos*textWidth/2 + sin*size*0.35
+        y := height/2 - sin*textWidth/2 - cos*size*0.35
+        fmt.Fprintf(&b, "q /%s gs BT /%s %s Tf 0.5 0 0 rg %s %s %s %s %s %s Tm %s Tj ET Q\n",
+            stampDiagonalGS, stampFont, num(size), num(cos), num(sin), num(-sin), num(cos), num(x), num(y), pdfString(text))
+    }
+
+    if len(stamp.Footer) > 0 {
+        fmt.Fprintf(&b, "q /%s gs BT /%s 7 Tf 0.3 g 9 TL\n", stampFooterGS, stampFont)
+        fmt.Fprintf(&b, "1 0 0 1 20 %s Tm\n", num(12+9*float64(len(stamp.Footer)-1)))
+        for i, line := range stamp.Footer {
+            if i > 0 {
+                b.WriteString("T* ")
+            }
+            b.WriteString(pdfString(winAnsi(line)) + " Tj\n")
+        }
+        b.WriteString("ET Q\n")
+    }
+    b.WriteString("Q\n")
+    return b.Bytes()
+}
+
+// winAnsi mengubah teks ke byte WinAnsiEncoding; karakter di luar Latin-1 diganti '?'
+func winAnsi(s string) string {
+    out := make([]byte, 0, len(s))
+    for _, r := range s {
+        switch {
+        case r == '\n' || r == '\r' || r == '\t':
+            out = append(out, ' ')
+        case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+            out = append(out, '?')
+        default:
+            out = append(out, byte(r))
+        }
+    }
+    return string(out)
+}
+
+func pdfString(s string) string {
+    return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s) + ")"
+}
+
+func num(v float64) string {
+    return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+func copyDict(d pdfDict) pdfDict {
+    out := make(pdfDict, len(d)+2)
+    for k, v := range d {
+        out[k] = v
+    }
+    return out
+}
+
+// pdfWriter menulis objek baru di akhir file dan mencatat offset-nya untuk xref
+type pdfWriter struct {
+    buf     *bytes.Buffer
+    offsets map[pdfRef]int
+}
+
+func (w *pdfWriter) begin(ref pdfRef) {
+    if w.offsets == nil {
+        w.offsets = map[pdfRef]int{}
+    }
+    w.offsets[ref] = w.buf.Len()
+    fmt.Fprintf(w.buf, "%d %d obj\n", ref.Num, ref.Gen)
+}
+
+func (w *pdfWriter) object(ref pdfRef, v any) {
+    w.begin(ref)
+    writePDFValue(w.buf, v)
+    w.buf.WriteString("\nendobj\n")
+}
+
+func (w *pdfWriter) stream(ref pdfRef, data []byte) {
+    w.begin(ref)
+    fmt.Fprintf(w.buf, "<< /Length %d >>\nstream\n", len(data))
+    w.buf.Write(data)
+    w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// sortedRefs mengurutkan objek yang ditulis berdasarkan nomor untuk subsection xref
+func (w *pdfWriter) sortedRefs() []pdfRef {
+    refs := make([]pdfRef, 0, len(w.offsets))
+    for ref := range w.offsets {
+        refs = append(refs, ref)
+    }
+    sort.Slice(refs, func(i, j int) bool { return refs[i].Num < refs[j].Num })
+    return refs
+}
+
+// xrefTable menulis xref table klasik beserta trailer
+func (w *pdfWriter) xrefTable(size int, trailer pdfDict) {
+    refs := w.sortedRefs()
+    start := w.buf.Len()
+    w.buf.WriteString("xref\n")
+    for i := 0; i < len(refs); {
+        j := i + 1
+        for j < len(refs) && refs[j].Num == refs[j-1].Num+1 {
+            j++
+        }
+        fmt.Fprintf(w.buf, "%d %d\n", refs[i].Num, j-i)
+        for _, ref := range refs[i:j] {
+            fmt.Fprintf(w.buf, "%010d %05d n\r\n", w.offsets[ref], ref.Gen)
+        }
+        i = j
+    }
+    trailer["Size"] = float64(size)
+    w.buf.WriteString("trailer\n")
+    writePDFValue(w.buf, trailer)
+    fmt.Fprintf(w.buf, "\nstartxref\n%d\n%%%%EOF\n", start)
+}
+
+// xrefStream menulis cross-reference stream (untuk PDF asli yang memakai xref stream)
+func (w *pdfWriter) xrefStream(ref pdfRef, trailer pdfDict) {
+    start := w.buf.Len()
+    w.offsets[ref] = start
+    refs := w.sortedRefs()
+
+    var index pdfArray
+    var data bytes.Buffer
+    for i := 0; i < len(refs); {
+        j := i + 1
+        for j < len(refs) && refs[j].Num == refs[j-1].Num+1 {
+            j++
+        }
+        index = append(index, float64(refs[i].Num), float64(j-i))
+        for _, r := range refs[i:j] {
+            off := w.offsets[r]
+            data.Write([]byte{1, byte(off >> 24), byte(off >> 16), byte(off >> 8), byte(off), byte(r.Gen >> 8), byte(r.Gen)})
+        }
+        i = j
+    }
+
+    trailer["Type"] = pdfName("XRef")
+    trailer["Size"] = float64(ref.Num + 1)
+    trailer["Index"] = index
+    trailer["W"] = pdfArray{1.0, 4.0, 2.0}
+    trailer["Length"] = float64(data.Len())
+
+    fmt.Fprintf(w.buf, "%d 0 obj\n", ref.Num)
+    writePDFValue(w.buf, trailer)
+    w.buf.WriteString("\nstream\n")
+    w.buf.Write(data.Bytes())
+    fmt.Fprintf(w.buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", start)
+}
+
+// writePDFValue menulis nilai hasil parsing kembali ke sintaks PDF. String ditulis
+// sebagai hex string agar byte biner tetap utuh.
+func writePDFValue(b *bytes.Buffer, v any) {
+    switch t := v.(type) {
+    case nil:
+        b.WriteString("null")
+    case bool:
+        b.WriteString(strconv.FormatBool(t))
+    case float64:
+        b.WriteString(strconv.FormatFloat(t, 'f', -1, 64))
+    case string:
+        fmt.Fprintf(b, "<%x>", t)
+    case pdfName:
+        b.WriteByte('/')
+        for i := 0; i < len(t); i++ {
+            c := t[i]
+            if c <= ' ' || c >= 0x7f || c == '#' || isPDFDelim(c) {
+                fmt.Fprintf(b, "#%02X", c)
+            } else {
+                b.WriteByte(c)
+            }
+        }
+    case pdfRef:
+        fmt.Fprintf(b, "%d %d R", t.Num, t.Gen)
+    case pdfArray:
+        b.WriteByte('[')
+        for i, item := range t {
+            if i > 0 {
+                b.WriteByte(' ')
+            }
+            writePDFValue(b, item)
+        }
+        b.WriteByte(']')
+    case pdfDict:
+        keys := make([]string, 0, len(t))
+        for k := range t {
+            keys = append(keys, k)
+        }
+        sort.Strings(keys)
+        b.WriteString("<<")
+        for _, k := range keys {
+            b.WriteByte(' ')
+            writePDFValue(b, pdfName(k))
+            b.WriteByte(' ')
+            writePDFValue(b, t[k])
+        }
+        b.WriteString(" >>")
+    case pdfKeyword:
+        b.WriteString(string(t))
+    default:
+        b.WriteString("null")
+    }
+}
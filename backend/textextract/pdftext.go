@@ -0,0 +1,381 @@
+package textextract
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+)
+
+// winAnsiHigh memetakan byte 0x80-0x9F WinAnsiEncoding yang berbeda dari Latin-1
+var winAnsiHigh = map[byte]rune{
+    0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+    0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+    0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+    0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// fontDecoder menerjemahkan kode karakter pada string teks menjadi Unicode
+type fontDecoder struct {
+    cmap     map[uint32]string
+    width    int  // jumlah byte per kode pada ToUnicode CMap
+    identity bool // Identity-H/V tanpa ToUnicode: tidak bisa diterjemahkan
+}
+
+func (fd *fontDecoder) decode(s string) string {
+    if fd == nil {
+        return decodeSimple(s)
+    }
+    if fd.cmap != nil {
+        var sb strings.Builder
+        w := fd.width
+        if w <= 0 {
+            w = 1
+        }
+        for i := 0; i+w <= len(s); i += w {
+            var code uint32
+            for j := 0; j < w; j++ {
+                code = code<<8 | uint32(s[i+j])
+            }
+            if u, ok := fd.cmap[code]; ok {
+                sb.WriteString(u)
+            } else if w == 1 {
+                sb.WriteString(decodeSimple(s[i : i+1]))
+            }
+        }
+        return sb.String()
+    }
+    if fd.identity {
+        return ""
+    }
+    return decodeSimple(s)
+}
+
+func decodeSimple(s string) string {
+    var sb strings.Builder
+    for i := 0; i < len(s); i++ {
+        b := s[i]
+        if r, ok := winAnsiHigh[b]; ok {
+            sb.WriteRune(r)
+            continue
+        }
+        if b < 0x20 && b != '\t' && b != '\n' {
+            continue
+        }
+        sb.WriteRune(rune(b))
+    }
+    return sb.String()
+}
+
+// parseCMap membaca bfchar/bfrange dari ToUnicode CMap
+func parseCMap(data []byte) *fontDecoder {
+    fd := &fontDecoder{cmap: map[uint32]string{}, width: 1}
+    lex := &pdfLexer{data: data}
+
+    var operands []any
+    for {
+        tok, ok := lex.token()
+        if !ok {
+            break
+        }
+        kw, isKw := tok.(pdfKeyword)
+        if !isKw {
+            operands = append(operands, tok)
+            continue
+        }
+        switch kw {
+        case "[":
+            operands = append(operands, lex.objectFrom(tok))
+            continue
+        case "begincodespacerange":
+            lo, ok := lex.token()
+            if s, isStr := lo.(string); ok && isStr && len(s) > 0 {
+                fd.width = len(s)
+            }
+        case "endbfchar":
+            for i := 0; i+1 < len(operands); i += 2 {
+                src, ok1 := operands[i].(string)
+                dst, ok2 := operands[i+1].(string)
+                if ok1 && ok2 {
+                    fd.cmap[codeOf(src)] = utf16BE(dst)
+                }
+            }
+        case "endbfrange":
+            for i := 0; i+2 < len(operands); i += 3 {
+                lo, ok1 := operands[i].(string)
+                hi, ok2 := operands[i+1].(string)
+                if !ok1 || !ok2 {
+                    continue
+                }
+                start, end := codeOf(lo), codeOf(hi)
+                if end < start || end-start > 0xFFFF {
+                    continue
+                }
+                switch dst := operands[i+2].(type) {
+                case string:
+                    base := []rune(utf16BE(dst))
+                    if len(base) == 0 {
+                        continue
+                    }
+                    for code := start; code <= end; code++ {
+                        r := append([]rune{}, base...)
+                        r[len(r)-1] += rune(code - start)
+                        fd.cmap[code] = string(r)
+                    }
+                case pdfArray:
+                    for j, item := range dst {
+                        if s, ok := item.(string); ok && start+uint32(j) <= end {
+                            fd.cmap[start+uint32(j)] = utf16BE(s)
+                        }
+                    }
+                }
+            }
+        }
+        operands = operands[:0]
+    }
+    return fd
+}
+
+func codeOf(s string) uint32 {
+    var code uint32
+    for i := 0; i < len(s); i++ {
+        code = code<<8 | uint32(s[i])
+    }
+    return code
+}
+
+func utf16BE(s string) string {
+    if len(s)%2 == 1 {
+        s += "\x00"
+    }
+    units := make([]uint16, len(s)/2)
+    for i := range units {
+        units[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+    }
+    return string(utf16.Decode(units))
+}
+
+// textExtractor menafsirkan operator teks pada content stream
+type textExtractor struct {
+    f      *pdfFile
+    sb     strings.Builder
+    fonts  map[pdfRef]*fontDecoder
+    font   *fontDecoder
+    lastY  float64
+    hasY   bool
+}
+
+func newTextExtractor(f *pdfFile) *textExtractor {
+    return &textExtractor{f: f, fonts: map[pdfRef]*fontDecoder{}}
+}
+
+func (te *textExtractor) String() string {
+    return te.sb.String()
+}
+
+func (te *textExtractor) write(s string) {
+    if s != "" {
+        te.sb.WriteString(s)
+    }
+}
+
+func (te *textExtractor) space() {
+    s := te.sb.String()
+    if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+        te.sb.WriteByte(' ')
+    }
+}
+
+func (te *textExtractor) newline() {
+    s := te.sb.String()
+    if s != "" && !strings.HasSuffix(s, "\n") {
+        te.sb.WriteByte('\n')
+    }
+}
+
+// moveTo mencatat posisi baris; perpindahan vertikal dianggap baris baru
+func (te *textExtractor) moveTo(y float64) {
+    if te.hasY && absFloat(y-te.lastY) > 1 {
+        te.newline()
+    } else {
+        te.space()
+    }
+    te.lastY, te.hasY = y, true
+}
+
+func absFloat(v float64) float64 {
+    if v < 0 {
+        return -v
+    }
+    return v
+}
+
+func (te *textExtractor) selectFont(resources pdfDict, name pdfName) {
+    te.font = nil
+    fonts := te.f.resolveDict(resources["Font"])
+    if fonts == nil {
+        return
+    }
+    raw := fonts[string(name)]
+    ref, isRef := raw.(pdfRef)
+    if isRef {
+        if fd, ok := te.fonts[ref]; ok {
+            te.font = fd
+            return
+        }
+    }
+
+    fd := &fontDecoder{}
+    if font := te.f.resolveDict(raw); font != nil {
+        if obj := te.f.streamOf(font["ToUnicode"]); obj != nil {
+            if data, err := te.f.decodeStream(obj); err == nil {
+                fd = parseCMap(data)
+            }
+        }
+        if enc, ok := te.f.resolve(font["Encoding"]).(pdfName); ok && (enc == "Identity-H" || enc == "Identity-V") {
+            fd.identity = true
+        }
+    }
+    if isRef {
+        te.fonts[ref] = fd
+    }
+    te.font = fd
+}
+
+// run memproses content stream; depth membatasi rekursi Form XObject
+func (te *textExtractor) run(content []byte, resources pdfDict, depth int) {
+    lex := &pdfLexer{data: content}
+    var operands []any
+
+    for {
+        tok, ok := lex.token()
+        if !ok {
+            return
+        }
+        kw, isKw := tok.(pdfKeyword)
+        if !isKw {
+            operands = append(operands, tok)
+            continue
+        }
+
+        switch kw {
+        case "[", "<<":
+            operands = append(operands, lex.objectFrom(tok))
+            continue
+        case "BT":
+            te.hasY = false
+        case "ET":
+            te.space()
+        case "Tf":
+            if len(operands) >= 1 {
+                if name, ok := operands[0].(pdfName); ok {
+                    te.selectFont(resources, name)
+                }
+            }
+        case "Td", "TD":
+            if len(operands) >= 2 {
+                tx, _ := operands[0].(float64)
+                ty, _ := operands[1].(float64)
+                if absFloat(ty) > 1 {
+                    te.newline()
+                } else if tx != 0 {
+                    te.space()
+                }
+            }
+        case "Tm":
+            if len(operands) >= 6 {
+                y, _ := operands[5].(float64)
+                te.moveTo(y)
+            }
+        case "T*":
+            te.newline()
+        case "Tj":
+            if len(operands) >= 1 {
+                if s, ok := operands[0].(string); ok {
+                    te.write(te.font.decode(s))
+                }
+            }
+        case "'":
+            te.newline()
+            if len(operands) >= 1 {
+                if s, ok := operands[0].(string); ok {
+                    te.write(te.font.decode(s))
+                }
+            }
+        case "\"":
+            te.newline()
+            if len(operands) >= 3 {
+                if s, ok := operands[2].(string); ok {
+                    te.write(te.font.decode(s))
+                }
+            }
+        case "TJ":
+            if len(operands) >= 1 {
+                if arr, ok := operands[0].(pdfArray); ok {
+                    for _, item := range arr {
+                        switch v := item.(type) {
+                        case string:
+                            te.write(te.font.decode(v))
+                        case float64:
+                            if v < -180 {
+                                te.space()
+                            }
+                        }
+                    }
+                }
+            }
+        case "Do":
+            if depth < 5 && len(operands) >= 1 {
+                if name, ok := operands[0].(pdfName); ok {
+                    te.runXObject(resources, name, depth)
+                }
+            }
+        case "ID":
+            // Lewati data inline image sampai operator EI
+            idx := bytes.Index(content[lex.pos:], []byte("EI"))
+            for idx >= 0 {
+                end := lex.pos + idx
+                if (end+2 >= len(content) || isPDFSpace(content[end+2])) && end > 0 && isPDFSpace(content[end-1]) {
+                    break
+                }
+                next := bytes.Index(content[end+2:], []byte("EI"))
+                if next < 0 {
+                    idx = -1
+                    break
+                }
+                idx = end + 2 + next - lex.pos
+            }
+            if idx < 0 {
+                return
+            }
+            lex.pos += idx + 2
+        }
+        operands = operands[:0]
+    }
+}
+
+func (te *textExtractor) runXObject(resources pdfDict, name pdfName, depth int) {
+    xobjects := te.f.resolveDict(resources["XObject"])
+    if xobjects == nil {
+        return
+    }
+    obj := te.f.streamOf(xobjects[string(name)])
+    if obj == nil {
+        return
+    }
+    d, _ := obj.value.(pdfDict)
+    if d["Subtype"] != pdfName("Form") {
+        return
+    }
+    data, err := te.f.decodeStream(obj)
+    if err != nil {
+        return
+    }
+
+    formResources := resources
+    if r := te.f.resolveDict(d["Resources"]); r != nil {
+        formResources = r
+    }
+    font := te.font
+    te.run(data, formResources, depth+1)
+    te.font = font
+}
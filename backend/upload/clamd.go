@@ -0,0 +1,121 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ScanResult adalah hasil pemindaian satu file
+type ScanResult struct {
+    Infected  bool
+    Signature string
+}
+
+// Scanner adalah hook pemindai malware yang dijalankan sebelum file dipublikasikan
+type Scanner interface {
+    Scan(ctx context.Context, r io.Reader) (ScanResult, error)
+}
+
+// NoopScanner dipakai jika CLAMD_ADDRESS tidak diset
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(ctx context.Context, r io.Reader) (ScanResult, error) {
+    return ScanResult{}, nil
+}
+
+// Clamd berbicara dengan daemon ClamAV memakai perintah INSTREAM
+type Clamd struct {
+    Network string // "tcp" atau "unix"
+    Address string
+    Timeout time.Duration
+}
+
+const clamdChunkSize = 64 << 10
+
+// ScannerFromEnv membaca CLAMD_ADDRESS, contoh tcp://localhost:3310 atau unix:///var/run/clamav/clamd.ctl
+func ScannerFromEnv() (Scanner, error) {
+    addr := os.Getenv("CLAMD_ADDRESS")
+    if addr == "" {
+        return NoopScanner{}, nil
+    }
+    u, err := url.Parse(addr)
+    if err != nil {
+        return nil, err
+    }
+    switch u.Scheme {
+    case "tcp":
+        return &Clamd{Network: "tcp", Address: u.Host, Timeout: 2 * time.Minute}, nil
+    case "unix":
+        return &Clamd{Network: "unix", Address: u.Path, Timeout: 2 * time.Minute}, nil
+    }
+    return nil, fmt.Errorf("unsupported CLAMD_ADDRESS scheme %q", u.Scheme)
+}
+
+// Scan mengirim isi r ke clamd dalam potongan [panjang 4 byte big-endian][data],
+// diakhiri potongan kosong, lalu membaca balasan "stream: OK" atau "stream: <nama> FOUND"
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (ScanResult, error) {
+    var d net.Dialer
+    conn, err := d.DialContext(ctx, c.Network, c.Address)
+    if err != nil {
+        return ScanResult{}, fmt.Errorf("clamd unavailable: %w", err)
+    }
+    defer conn.Close()
+    if c.Timeout > 0 {
+        conn.SetDeadline(time.Now().Add(c.Timeout))
+    }
+
+    if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+        return ScanResult{}, err
+    }
+
+    buf := make([]byte, clamdChunkSize)
+    size := make([]byte, 4)
+    for {
+        n, readErr := r.Read(buf)
+        if n > 0 {
+            binary.BigEndian.PutUint32(size, uint32(n))
+            if _, err := conn.Write(size); err != nil {
+                return ScanResult{}, err
+            }
+            if _, err := conn.Write(buf[:n]); err != nil {
+                return ScanResult{}, err
+            }
+        }
+        if readErr == io.EOF {
+            break
+        }
+        if readErr != nil {
+            return ScanResult{}, readErr
+        }
+    }
+    if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+        return ScanResult{}, err
+    }
+
+    reply, err := bufio.NewReader(conn).ReadString(0)
+    if err != nil && err != io.EOF {
+        return ScanResult{}, err
+    }
+    return parseClamdReply(reply)
+}
+
+func parseClamdReply(reply string) (ScanResult, error) {
+    reply = strings.TrimRight(reply, "\x00\n")
+    reply = strings.TrimPrefix(reply, "stream: ")
+
+    switch {
+    case reply == "OK":
+        return ScanResult{}, nil
+    case strings.HasSuffix(reply, " FOUND"):
+        return ScanResult{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+    }
+    return ScanResult{}, fmt.Errorf("clamd error: %s", reply)
+}
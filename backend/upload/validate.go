@@ -0,0 +1,108 @@
+// Package upload memvalidasi file dokumen yang diupload: deteksi tipe dari magic bytes,
+// batas ukuran, sanitasi nama file, dan pemindaian malware lewat clamd.
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+    ErrTooLarge       = errors.New("file exceeds maximum upload size")
+    ErrTypeNotAllowed = errors.New("file type not allowed, only PDF, DOC and DOCX are accepted")
+)
+
+// DefaultMaxSize dipakai jika UPLOAD_MAX_SIZE_MB tidak diset
+const DefaultMaxSize = 50 << 20
+
+// FileType adalah tipe dokumen yang diizinkan
+type FileType struct {
+    Ext         string
+    ContentType string
+}
+
+var (
+    TypePDF  = FileType{".pdf", "application/pdf"}
+    TypeDOC  = FileType{".doc", "application/msword"}
+    TypeDOCX = FileType{".docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}
+)
+
+var (
+    magicPDF = []byte("%PDF-")
+    magicOLE = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+    magicZIP = []byte("PK\x03\x04")
+)
+
+// MaxSizeFromEnv membaca UPLOAD_MAX_SIZE_MB
+func MaxSizeFromEnv() int64 {
+    if mb, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_SIZE_MB")); err == nil && mb > 0 {
+        return int64(mb) << 20
+    }
+    return DefaultMaxSize
+}
+
+// Sniff menentukan tipe file dari isinya, bukan dari nama file.
+// DOCX dibedakan dari ZIP biasa dengan memeriksa adanya word/document.xml.
+func Sniff(r io.ReaderAt, size int64) (FileType, error) {
+    head := make([]byte, 8)
+    n, err := r.ReadAt(head, 0)
+    if err != nil && err != io.EOF {
+        return FileType{}, err
+    }
+    head = head[:n]
+
+    switch {
+    case bytes.HasPrefix(head, magicPDF):
+        return TypePDF, nil
+    case bytes.HasPrefix(head, magicOLE):
+        return TypeDOC, nil
+    case bytes.HasPrefix(head, magicZIP):
+        zr, err := zip.NewReader(r, size)
+        if err != nil {
+            return FileType{}, ErrTypeNotAllowed
+        }
+        for _, f := range zr.File {
+            if f.Name == "word/document.xml" {
+                return TypeDOCX, nil
+            }
+        }
+    }
+    return FileType{}, ErrTypeNotAllowed
+}
+
+// SanitizeFilename membuang komponen path dan karakter berbahaya, lalu memastikan
+// ekstensi sesuai tipe yang terdeteksi
+func SanitizeFilename(name string, fileType FileType) string {
+    name = strings.ReplaceAll(name, "\\", "/")
+    name = path.Base(name)
+
+    var sb strings.Builder
+    for _, r := range name {
+        switch {
+        case unicode.IsLetter(r), unicode.IsDigit(r):
+            sb.WriteRune(r)
+        case r == ' ', r == '-', r == '_', r == '.', r == '(', r == ')', r == ',':
+            sb.WriteRune(r)
+        default:
+            sb.WriteRune('_')
+        }
+    }
+
+    base := strings.TrimSpace(sb.String())
+    base = strings.TrimSuffix(base, path.Ext(base))
+    base = strings.Trim(base, ". ")
+    if base == "" {
+        base = "dokumen"
+    }
+    if len(base) > 180 {
+        base = strings.TrimSpace(string([]rune(base)[:150]))
+    }
+    return base + fileType.Ext
+}
@@ -0,0 +1,215 @@
+// Package xlsx membaca dan menulis spreadsheet Office Open XML (.xlsx) sederhana:
+// satu sheet berisi teks dan angka, tanpa style atau formula. Cukup untuk manifest
+// import dan ekspor data tanpa dependensi eksternal.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidWorkbook = errors.New("invalid xlsx workbook")
+
+type xmlSharedStrings struct {
+    Items []xmlRichText `xml:"si"`
+}
+
+// xmlRichText menampung <t> langsung atau potongan <r><t> (rich text)
+type xmlRichText struct {
+    Text string       `xml:"t"`
+    Runs []xmlTextRun `xml:"r"`
+}
+
+type xmlTextRun struct {
+    Text string `xml:"t"`
+}
+
+func (t xmlRichText) String() string {
+    if len(t.Runs) == 0 {
+        return t.Text
+    }
+    var sb strings.Builder
+    sb.WriteString(t.Text)
+    for _, r := range t.Runs {
+        sb.WriteString(r.Text)
+    }
+    return sb.String()
+}
+
+type xmlWorksheet struct {
+    Rows []struct {
+        Cells []struct {
+            Ref    string      `xml:"r,attr"`
+            Type   string      `xml:"t,attr"`
+            Value  string      `xml:"v"`
+            Inline xmlRichText `xml:"is"`
+        } `xml:"c"`
+    } `xml:"sheetData>row"`
+}
+
+type xmlWorkbook struct {
+    Sheets []struct {
+        RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+    } `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+    Items []struct {
+        ID     string `xml:"Id,attr"`
+        Target string `xml:"Target,attr"`
+    } `xml:"Relationship"`
+}
+
+// ReadRows membaca sheet pertama sebagai baris-baris teks. Sel kosong di tengah baris
+// diisi string kosong sehingga indeks kolom tetap sesuai huruf kolomnya.
+func ReadRows(r io.ReaderAt, size int64) ([][]string, error) {
+    zr, err := zip.NewReader(r, size)
+    if err != nil {
+        return nil, ErrInvalidWorkbook
+    }
+    files := make(map[string]*zip.File, len(zr.File))
+    for _, f := range zr.File {
+        files[f.Name] = f
+    }
+
+    var shared xmlSharedStrings
+    if f, ok := files["xl/sharedStrings.xml"]; ok {
+        if err := decodeXML(f, &shared); err != nil {
+            return nil, err
+        }
+    }
+
+    sheet, ok := files[firstSheetPath(files)]
+    if !ok {
+        return nil, ErrInvalidWorkbook
+    }
+    var ws xmlWorksheet
+    if err := decodeXML(sheet, &ws); err != nil {
+        return nil, err
+    }
+
+    rows := make([][]string, 0, len(ws.Rows))
+    for _, row := range ws.Rows {
+        var values []string
+        for i, cell := range row.Cells {
+            col := i
+            if cell.Ref != "" {
+                if col, err = columnIndex(cell.Ref); err != nil {
+                    return nil, err
+                }
+            }
+            if col > maxColumn {
+                return nil, ErrInvalidWorkbook
+            }
+            for len(values) < col {
+                values = append(values, "")
+            }
+
+            var value string
+            switch cell.Type {
+            case "s":
+                idx, err := strconv.Atoi(cell.Value)
+                if err != nil || idx < 0 || idx >= len(shared.Items) {
+                    return nil, ErrInvalidWorkbook
+                }
+                value = shared.Items[idx].String()
+            case "inlineStr":
+                value = cell.Inline.String()
+            default:
+                value = cell.Value
+            }
+            if col < len(values) {
+                values[col] = value
+            } else {
+                values = append(values, value)
+            }
+        }
+        rows = append(rows, values)
+    }
+    return rows, nil
+}
+
+// firstSheetPath mengikuti workbook.xml dan relasinya; jatuh ke sheet1.xml jika tidak ditemukan
+func firstSheetPath(files map[string]*zip.File) string {
+    fallback := "xl/worksheets/sheet1.xml"
+
+    var wb xmlWorkbook
+    var rels xmlRelationships
+    wbFile, ok1 := files["xl/workbook.xml"]
+    relFile, ok2 := files["xl/_rels/workbook.xml.rels"]
+    if !ok1 || !ok2 || decodeXML(wbFile, &wb) != nil || decodeXML(relFile, &rels) != nil || len(wb.Sheets) == 0 {
+        return fallback
+    }
+    for _, rel := range rels.Items {
+        if rel.ID != wb.Sheets[0].RelID {
+            continue
+        }
+        if strings.HasPrefix(rel.Target, "/") {
+            return strings.TrimPrefix(rel.Target, "/")
+        }
+        return path.Join("xl", rel.Target)
+    }
+    return fallback
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+    rc, err := f.Open()
+    if err != nil {
+        return err
+    }
+    defer rc.Close()
+    if err := xml.NewDecoder(rc).Decode(v); err != nil {
+        return ErrInvalidWorkbook
+    }
+    return nil
+}
+
+// maxColumn adalah indeks kolom terakhir yang diizinkan Excel ("XFD")
+const maxColumn = 16383
+
+// columnIndex mengubah referensi sel seperti "AB12" menjadi indeks kolom 0-based. Referensi
+// tanpa huruf kolom, diikuti selain angka baris, atau melewati kolom XFD ditolak agar
+// workbook rusak tidak membuat panic atau alokasi baris raksasa.
+func columnIndex(ref string) (int, error) {
+    col, i := 0, 0
+    for ; i < len(ref); i++ {
+        ch := ref[i]
+        if ch >= 'a' && ch <= 'z' {
+            ch -= 'a' - 'A'
+        }
+        if ch < 'A' || ch > 'Z' {
+            break
+        }
+        col = col*26 + int(ch-'A'+1)
+        if col-1 > maxColumn {
+            return 0, ErrInvalidWorkbook
+        }
+    }
+    if i == 0 || i == len(ref) {
+        return 0, ErrInvalidWorkbook
+    }
+    for _, ch := range ref[i:] {
+        if ch < '0' || ch > '9' {
+            return 0, ErrInvalidWorkbook
+        }
+    }
+    return col - 1, nil
+}
+
+// excelEpoch adalah tanggal nol serial Excel (sistem 1900, sudah termasuk bug tahun kabisat 1900)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// ParseDate mengubah serial tanggal Excel (misal "44197") menjadi time.Time
+func ParseDate(serial string) (time.Time, bool) {
+    days, err := strconv.ParseFloat(serial, 64)
+    if err != nil || days <= 0 {
+        return time.Time{}, false
+    }
+    return excelEpoch.Add(time.Duration(days * 24 * float64(time.Hour))).Truncate(24 * time.Hour), true
+}
@@ -0,0 +1,137 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCellLength adalah batas panjang teks satu sel di Excel
+const maxCellLength = 32767
+
+var staticParts = []struct {
+    name    string
+    content string
+}{
+    {"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+        `<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+        `<Default Extension="xml" ContentType="application/xml"/>` +
+        `<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+        `<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+        `</Types>`},
+    {"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+        `<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+        `</Relationships>`},
+    {"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+        `<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+        `</Relationships>`},
+}
+
+// Writer menulis workbook satu sheet secara streaming: baris langsung ditulis ke ZIP
+// sehingga ekspor besar tidak perlu ditampung di memori
+type Writer struct {
+    zw    *zip.Writer
+    sheet *bufio.Writer
+    row   int
+}
+
+// NewWriter menulis bagian statis workbook lalu membuka sheet untuk diisi WriteRow
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+    zw := zip.NewWriter(w)
+    for _, part := range staticParts {
+        if err := writePart(zw, part.name, part.content); err != nil {
+            return nil, err
+        }
+    }
+
+    workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+        `<sheets><sheet name="` + escape(sanitizeSheetName(sheetName)) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+    if err := writePart(zw, "xl/workbook.xml", workbook); err != nil {
+        return nil, err
+    }
+
+    part, err := zw.Create("xl/worksheets/sheet1.xml")
+    if err != nil {
+        return nil, err
+    }
+    sheet := bufio.NewWriter(part)
+    sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+    return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow menulis satu baris; semua nilai disimpan sebagai teks (inline string)
+func (w *Writer) WriteRow(values []string) error {
+    w.row++
+    fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+    for i, value := range values {
+        if value == "" {
+            continue
+        }
+        if utf8.RuneCountInString(value) > maxCellLength {
+            value = string([]rune(value)[:maxCellLength])
+        }
+        fmt.Fprintf(w.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, columnName(i), w.row, escape(value))
+    }
+    _, err := w.sheet.WriteString(`</row>`)
+    return err
+}
+
+// Close menutup sheet dan arsip ZIP; writer di bawahnya tidak ditutup
+func (w *Writer) Close() error {
+    w.sheet.WriteString(`</sheetData></worksheet>`)
+    if err := w.sheet.Flush(); err != nil {
+        return err
+    }
+    return w.zw.Close()
+}
+
+func writePart(zw *zip.Writer, name, content string) error {
+    part, err := zw.Create(name)
+    if err != nil {
+        return err
+    }
+    _, err = io.WriteString(part, content)
+    return err
+}
+
+func escape(s string) string {
+    var sb strings.Builder
+    xml.EscapeText(&sb, []byte(s))
+    return sb.String()
+}
+
+// sanitizeSheetName mengikuti aturan Excel: maksimal 31 karakter, tanpa : \ / ? * [ ]
+func sanitizeSheetName(name string) string {
+    name = strings.Map(func(r rune) rune {
+        if strings.ContainsRune(`:\/?*[]`, r) {
+            return '_'
+        }
+        return r
+    }, name)
+    if utf8.RuneCountInString(name) > 31 {
+        name = string([]rune(name)[:31])
+    }
+    if name == "" {
+        name = "Sheet1"
+    }
+    return name
+}
+
+// columnName adalah kebalikan columnIndex: 0 -> "A", 27 -> "AB"
+func columnName(idx int) string {
+    name := ""
+    for idx >= 0 {
+        name = string(rune('A'+idx%26)) + name
+        idx = idx/26 - 1
+    }
+    return name
+}
@@ -0,0 +1,185 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildWorkbook menyusun .xlsx minimal dengan sheet1.xml dan sharedStrings.xml yang diberikan
+func buildWorkbook(t *testing.T, sheetData, shared string) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    zw := zip.NewWriter(&buf)
+    parts := map[string]string{
+        "xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheetData + `</sheetData></worksheet>`,
+    }
+    if shared != "" {
+        parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + shared + `</sst>`
+    }
+    for name, content := range parts {
+        w, err := zw.Create(name)
+        if err != nil {
+            t.Fatal(err)
+        }
+        w.Write([]byte(content))
+    }
+    if err := zw.Close(); err != nil {
+        t.Fatal(err)
+    }
+    return buf.Bytes()
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+    rows := [][]string{
+        {"Nomor", "Judul", "Keterangan"},
+        {"1", "Cuti <Tahunan> & \"Besar\"", ""},
+        {"", "", "kolom C saja"},
+    }
+    var buf bytes.Buffer
+    w, err := NewWriter(&buf, "Peraturan: 2024/[draft]")
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, row := range rows {
+        if err := w.WriteRow(row); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := w.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    got, err := ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := [][]string{
+        {"Nomor", "Judul", "Keterangan"},
+        {"1", "Cuti <Tahunan> & \"Besar\""},
+        {"", "", "kolom C saja"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("rows = %q, want %q", got, want)
+    }
+}
+
+func TestWriteTruncatesLongCell(t *testing.T) {
+    var buf bytes.Buffer
+    w, _ := NewWriter(&buf, "Sheet")
+    w.WriteRow([]string{strings.Repeat("é", maxCellLength+10)})
+    w.Close()
+
+    got, err := ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n := len([]rune(got[0][0])); n != maxCellLength {
+        t.Fatalf("cell length = %d, want %d", n, maxCellLength)
+    }
+}
+
+func TestReadSharedAndRichText(t *testing.T) {
+    data := buildWorkbook(t,
+        `<row r="1"><c r="A1" t="s"><v>1</v></c><c r="c1" t="s"><v>0</v></c><c r="D1"><v>44197</v></c></row>`,
+        `<si><t>pertama</t></si><si><r><t>ka</t></r><r><t>tegori</t></r></si>`)
+    got, err := ReadRows(bytes.NewReader(data), int64(len(data)))
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := [][]string{{"kategori", "", "pertama", "44197"}}
+    if !reflect.DeepEqual(got, want) {
+        t.Fatalf("rows = %q, want %q", got, want)
+    }
+}
+
+func TestReadMalformedWorkbook(t *testing.T) {
+    cases := []struct {
+        name   string
+        sheet  string
+        shared string
+    }{
+        {"digit-only ref", `<row><c r="12"><v>x</v></c></row>`, ""},
+        {"ref without row", `<row><c r="AB"><v>x</v></c></row>`, ""},
+        {"ref with junk", `<row><c r="A1:B2"><v>x</v></c></row>`, ""},
+        {"column past XFD", `<row><c r="XFE1"><v>x</v></c></row>`, ""},
+        {"huge column", `<row><c r="ZZZZZZZZZZ1"><v>x</v></c></row>`, ""},
+        {"negative shared index", `<row><c r="A1" t="s"><v>-1</v></c></row>`, `<si><t>a</t></si>`},
+        {"shared index out of range", `<row><c r="A1" t="s"><v>5</v></c></row>`, `<si><t>a</t></si>`},
+        {"broken sheet xml", `<row><c r="A1"><v>x</row>`, ""},
+    }
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            data := buildWorkbook(t, tc.sheet, tc.shared)
+            if _, err := ReadRows(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrInvalidWorkbook) {
+                t.Fatalf("err = %v, want ErrInvalidWorkbook", err)
+            }
+        })
+    }
+
+    t.Run("not a zip", func(t *testing.T) {
+        data := []byte("Nomor,Judul\n1,Cuti\n")
+        if _, err := ReadRows(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrInvalidWorkbook) {
+            t.Fatalf("err = %v, want ErrInvalidWorkbook", err)
+        }
+    })
+}
+
+func TestColumnIndex(t *testing.T) {
+    cases := []struct {
+        ref  string
+        want int
+        ok   bool
+    }{
+        {"A1", 0, true},
+        {"Z9", 25, true},
+        {"AA10", 26, true},
+        {"ab12", 27, true},
+        {"XFD1048576", maxColumn, true},
+        {"XFE1", 0, false},
+        {"ZZZZZZZZZZZZZZZZZZZZ1", 0, false},
+        {"", 0, false},
+        {"1", 0, false},
+        {"A", 0, false},
+        {"A-1", 0, false},
+        {"Ä1", 0, false},
+    }
+    for _, tc := range cases {
+        got, err := columnIndex(tc.ref)
+        if (err == nil) != tc.ok || (tc.ok && got != tc.want) {
+            t.Errorf("columnIndex(%q) = %d, %v; want %d, ok=%v", tc.ref, got, err, tc.want, tc.ok)
+        }
+    }
+}
+
+func TestColumnName(t *testing.T) {
+    for _, idx := range []int{0, 25, 26, 27, 701, 702, maxColumn} {
+        got, err := columnIndex(columnName(idx) + "1")
+        if err != nil || got != idx {
+            t.Errorf("columnIndex(columnName(%d)) = %d, %v", idx, got, err)
+        }
+    }
+}
+
+func TestParseDate(t *testing.T) {
+    cases := []struct {
+        serial string
+        want   string
+        ok     bool
+    }{
+        {"44197", "2021-01-01", true},
+        {"44197.75", "2021-01-01", true},
+        {"0", "", false},
+        {"-5", "", false},
+        {"tanggal", "", false},
+    }
+    for _, tc := range cases {
+        got, ok := ParseDate(tc.serial)
+        if ok != tc.ok || (ok && got.Format("2006-01-02") != tc.want) {
+            t.Errorf("ParseDate(%q) = %v, %v; want %s, %v", tc.serial, got, ok, tc.want, tc.ok)
+        }
+    }
+}